@@ -0,0 +1,137 @@
+package bootstrap
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrUnknownNonce  = errors.New("unknown nonce")
+	ErrNonceUsed     = errors.New("nonce already used")
+	ErrNonceExpired  = errors.New("nonce expired")
+	ErrAgentMismatch = errors.New("nonce issued for another agent")
+)
+
+const nonceLength = 32
+
+type grant struct {
+	agent   string
+	token   string
+	expires time.Time
+	used    bool
+}
+
+// Server hands out agent tokens in exchange for single-use nonces, so the
+// token itself never has to be part of an instance's user data. A nonce is
+// bound to the agent it was issued for and only valid once and until its TTL
+// expired; spent nonces are remembered until then so replays can be told
+// apart from guesses.
+type Server struct {
+	url    string
+	ttl    time.Duration
+	now    func() time.Time
+	mu     sync.Mutex
+	grants map[string]*grant
+}
+
+// New returns a bootstrap server agents reach at url, issuing nonces valid
+// for ttl.
+func New(url string, ttl time.Duration) *Server {
+	return &Server{
+		url:    url,
+		ttl:    ttl,
+		now:    time.Now,
+		grants: make(map[string]*grant),
+	}
+}
+
+// URL returns the address agents exchange their nonce at.
+func (s *Server) URL() string {
+	return s.url
+}
+
+// Issue returns a new nonce for the agent's token. A nonce issued earlier for
+// the same agent is revoked, as only the latest user data gets deployed.
+func (s *Server) Issue(agent, token string) (string, error) {
+	b := make([]byte, nonceLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, g := range s.grants {
+		if g.agent == agent || now.After(g.expires) {
+			delete(s.grants, key)
+		}
+	}
+	s.grants[nonce] = &grant{
+		agent:   agent,
+		token:   token,
+		expires: now.Add(s.ttl),
+	}
+
+	return nonce, nil
+}
+
+// Exchange returns the token the nonce was issued for and spends the nonce.
+func (s *Server) Exchange(agent, nonce string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.grants[nonce]
+	switch {
+	case !ok:
+		return "", ErrUnknownNonce
+	case g.agent != agent:
+		return "", ErrAgentMismatch
+	case g.used:
+		return "", ErrNonceUsed
+	case s.now().After(g.expires):
+		return "", ErrNonceExpired
+	}
+
+	g.used = true
+	token := g.token
+	g.token = ""
+
+	return token, nil
+}
+
+// ServeHTTP exchanges the nonce of a POST request with the form values agent
+// and nonce and responds with the plain token.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	agent, nonce := r.PostFormValue("agent"), r.PostFormValue("nonce")
+	if agent == "" || nonce == "" {
+		http.Error(w, "agent and nonce are required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := s.Exchange(agent, nonce)
+	if err != nil {
+		log.Warn().Err(err).Str("agent", agent).Str("remote", r.RemoteAddr).Msg("rejected bootstrap request")
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	log.Info().Str("agent", agent).Str("remote", r.RemoteAddr).Msg("agent token handed out")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(token))
+}
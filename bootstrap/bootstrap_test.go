@@ -0,0 +1,99 @@
+package bootstrap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchange(t *testing.T) {
+	now := time.Now()
+	s := New("https://autoscaler.example.com/bootstrap", 10*time.Minute)
+	s.now = func() time.Time { return now }
+
+	nonce, err := s.Issue("pool-1-agent-abcd", "secret")
+	require.NoError(t, err)
+	assert.Len(t, nonce, 2*nonceLength)
+
+	_, err = s.Exchange("pool-1-agent-other", nonce)
+	assert.ErrorIs(t, err, ErrAgentMismatch)
+
+	token, err := s.Exchange("pool-1-agent-abcd", nonce)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+
+	_, err = s.Exchange("pool-1-agent-abcd", nonce)
+	assert.ErrorIs(t, err, ErrNonceUsed)
+
+	_, err = s.Exchange("pool-1-agent-abcd", "guessed")
+	assert.ErrorIs(t, err, ErrUnknownNonce)
+}
+
+func TestExchangeExpired(t *testing.T) {
+	now := time.Now()
+	s := New("", 10*time.Minute)
+	s.now = func() time.Time { return now }
+
+	nonce, err := s.Issue("pool-1-agent-abcd", "secret")
+	require.NoError(t, err)
+
+	now = now.Add(11 * time.Minute)
+	_, err = s.Exchange("pool-1-agent-abcd", nonce)
+	assert.ErrorIs(t, err, ErrNonceExpired)
+}
+
+func TestIssueRevokesPreviousNonce(t *testing.T) {
+	s := New("", 10*time.Minute)
+
+	first, err := s.Issue("pool-1-agent-abcd", "secret")
+	require.NoError(t, err)
+	second, err := s.Issue("pool-1-agent-abcd", "secret")
+	require.NoError(t, err)
+
+	_, err = s.Exchange("pool-1-agent-abcd", first)
+	assert.ErrorIs(t, err, ErrUnknownNonce)
+
+	token, err := s.Exchange("pool-1-agent-abcd", second)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", token)
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := New("", 10*time.Minute)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	nonce, err := s.Issue("pool-1-agent-abcd", "secret")
+	require.NoError(t, err)
+
+	exchange := func(agent, nonce string) (int, string) {
+		resp, err := http.PostForm(srv.URL, url.Values{"agent": {agent}, "nonce": {nonce}})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := exchange("pool-1-agent-abcd", nonce)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "secret", body)
+
+	status, body = exchange("pool-1-agent-abcd", nonce)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.NotContains(t, body, "secret")
+
+	status, _ = exchange("", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/bootstrap"
+)
+
+// bootstrapReadHeaderTimeout bounds how long a client may take to send the
+// request headers to the bootstrap endpoint.
+const bootstrapReadHeaderTimeout = 10 * time.Second
+
+// startBootstrap starts the bootstrap endpoint if bootstrap-addr is set. It is
+// stopped once ctx is done.
+func startBootstrap(ctx context.Context, cmd *cli.Command) (*bootstrap.Server, error) {
+	addr := cmd.String("bootstrap-addr")
+	if addr == "" {
+		return nil, nil
+	}
+
+	url := cmd.String("bootstrap-url")
+	if url == "" {
+		return nil, fmt.Errorf("bootstrap-url is required if bootstrap-addr is set")
+	}
+
+	ttl, err := time.ParseDuration(cmd.String("bootstrap-nonce-ttl"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse bootstrap-nonce-ttl: %w", err)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("bootstrap-nonce-ttl must be greater than 0, got %s", ttl)
+	}
+
+	cert, key := cmd.String("bootstrap-tls-cert"), cmd.String("bootstrap-tls-key")
+	if (cert == "") != (key == "") {
+		return nil, fmt.Errorf("bootstrap-tls-cert and bootstrap-tls-key must be set together")
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: %w", err)
+	}
+
+	s := bootstrap.New(url, ttl)
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: bootstrapReadHeaderTimeout,
+	}
+
+	go func() {
+		var err error
+		if cert != "" {
+			err = srv.ServeTLS(listener, cert, key)
+		} else {
+			err = srv.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("bootstrap endpoint failed")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	log.Info().Str("addr", addr).Str("url", url).Msg("bootstrap endpoint started")
+
+	return s, nil
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/providers/aws"
+	"go.woodpecker-ci.org/autoscaler/providers/azure"
+	"go.woodpecker-ci.org/autoscaler/providers/digitalocean"
+	"go.woodpecker-ci.org/autoscaler/providers/equinixmetal"
+	"go.woodpecker-ci.org/autoscaler/providers/exoscale"
+	"go.woodpecker-ci.org/autoscaler/providers/gce"
+	"go.woodpecker-ci.org/autoscaler/providers/hetznercloud"
+	"go.woodpecker-ci.org/autoscaler/providers/linode"
+	"go.woodpecker-ci.org/autoscaler/providers/multi"
+	"go.woodpecker-ci.org/autoscaler/providers/oci"
+	"go.woodpecker-ci.org/autoscaler/providers/openstack"
+	"go.woodpecker-ci.org/autoscaler/providers/scaleway"
+	"go.woodpecker-ci.org/autoscaler/providers/upcloud"
+	"go.woodpecker-ci.org/autoscaler/providers/vultr"
+	"go.woodpecker-ci.org/autoscaler/utils"
+)
+
+// providers lists the names setupProvider accepts. Each provider owns the
+// flags prefixed with its name.
+var providers = []string{
+	"aws",
+	"azure",
+	"digitalocean",
+	"equinixmetal",
+	"exoscale",
+	"gce",
+	"hetznercloud",
+	"incus",
+	"kubernetes",
+	"libvirt",
+	"linode",
+	"local",
+	"multi",
+	"oci",
+	"openstack",
+	"plugin",
+	"proxmox",
+	"scaleway",
+	"upcloud",
+	"vultr",
+	"webhook",
+}
+
+var sha256Sum = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// userDataLimits are the maximum user data sizes of the providers in bytes.
+var userDataLimits = map[string]int{
+	"aws":          aws.UserDataLimit,
+	"azure":        azure.UserDataLimit,
+	"digitalocean": digitalocean.UserDataLimit,
+	"equinixmetal": equinixmetal.UserDataLimit,
+	"exoscale":     exoscale.UserDataLimit,
+	"gce":          gce.UserDataLimit,
+	"hetznercloud": hetznercloud.UserDataLimit,
+	"linode":       linode.UserDataLimit,
+	"oci":          oci.UserDataLimit,
+	"openstack":    openstack.UserDataLimit,
+	"scaleway":     scaleway.UserDataLimit,
+	"upcloud":      upcloud.UserDataLimit,
+	"vultr":        vultr.UserDataLimit,
+}
+
+// containerProviders run agents as containers, they use no user data.
+var containerProviders = []string{
+	"kubernetes",
+	"local",
+}
+
+// previewUserData renders the user data for a placeholder agent and rejects a
+// config whose user data is invalid or exceeds the provider's size limit.
+func previewUserData(cmd *cli.Command, config *config.Config) error {
+	selected, err := selectedProviders(cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range selected {
+		if provider == "multi" || slices.Contains(containerProviders, provider) {
+			continue
+		}
+		userData, err := inits.PreviewUserData(config, provider, userDataLimits[provider])
+		if err != nil {
+			return err
+		}
+
+		log.Debug().Int("bytes", len(userData)).Msgf("rendered user data preview:\n%s", userData)
+	}
+
+	return nil
+}
+
+// selectedProviders returns the selected provider and, for the multi
+// provider, its members.
+func selectedProviders(cmd *cli.Command) ([]string, error) {
+	provider := cmd.String("provider")
+	if provider != "multi" {
+		return []string{provider}, nil
+	}
+
+	members, err := multi.ParseMembers(cmd.StringSlice("multi-providers"))
+	if err != nil {
+		return nil, err
+	}
+	selected := []string{provider}
+	for _, member := range members {
+		selected = append(selected, member.Name)
+	}
+	return selected, nil
+}
+
+// loadConfigFile applies the settings of the configuration file, if one is
+// given, to all flags not set on the command line or through the environment.
+func loadConfigFile(cmd *cli.Command) error {
+	path := cmd.String("config-file")
+	if path == "" {
+		return nil
+	}
+
+	file, err := config.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	settings, err := file.Resolve(cmd.Flags)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := settings.Apply(cmd); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	selected, err := selectedProviders(cmd)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := checkProviderSettings(selected, settings); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	log.Debug().Str("file", path).Int("settings", len(settings)).Msg("loaded config file")
+
+	return nil
+}
+
+// checkProviderSettings rejects settings for a provider other than the
+// selected ones, as they would be silently ignored.
+func checkProviderSettings(selected []string, settings config.Settings) error {
+	for _, key := range settings.Keys() {
+		prefix, _, _ := strings.Cut(key, "-")
+		if !slices.Contains(selected, prefix) && slices.Contains(providers, prefix) {
+			return fmt.Errorf("%w: %s is set but the selected provider is %q", config.ErrInvalidConfig, key, selected[0])
+		}
+	}
+
+	return nil
+}
+
+// buildConfig assembles and validates the engine config from the flags.
+func buildConfig(cmd *cli.Command) (*config.Config, error) {
+	agentEnvironment, err := utils.SliceToMap(cmd.StringSlice("agent-env"), "=")
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent environment variable: %w", err)
+	}
+
+	agentLabels, err := utils.SliceToMap(cmd.StringSlice("agent-labels"), "=")
+	if err != nil {
+		return nil, fmt.Errorf("invalid agent labels variable: %w", err)
+	}
+
+	candidateWorkflows := make(map[string]int)
+	for _, pair := range cmd.StringSlice("candidate-workflows") {
+		instanceType, workflows, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid candidate-workflows: %q is not a type=workflows pair", pair)
+		}
+		candidateWorkflows[instanceType], err = strconv.Atoi(workflows)
+		if err != nil {
+			return nil, fmt.Errorf("invalid candidate-workflows of %s: %w", instanceType, err)
+		}
+	}
+
+	if _, exist := agentEnvironment["WOODPECKER_AGENT_LABELS"]; exist {
+		log.Error().Msg("setting WOODPECKER_AGENT_LABELS via WOODPECKER_AGENT_ENV is forbidden, use native autoscaler setting for that")
+		return nil, fmt.Errorf("'WOODPECKER_AGENT_ENV' has forbidden env var set: \"WOODPECKER_AGENT_LABELS\"")
+	}
+
+	config := &config.Config{
+		MinAgents:          cmd.Int("min-agents"),
+		MaxAgents:          cmd.Int("max-agents"),
+		WorkflowsPerAgent:  cmd.Int("workflows-per-agent"),
+		CandidateWorkflows: candidateWorkflows,
+		WorkflowCPUs:       cmd.Float("workflow-cpus"),
+		WorkflowMemory:     cmd.Float("workflow-memory"),
+		PoolID:             cmd.String("pool-id"),
+		GRPCAddress:        cmd.String("grpc-addr"),
+		GRPCSecure:         cmd.Bool("grpc-secure"),
+		Image:              cmd.String("agent-image"),
+		InitSystem:         cmd.String("init-system"),
+		UserData:           cmd.String("cloudinit-template"),
+		OSFamily:           cmd.String("cloudinit-os-family"),
+		UserDataMergeHow:   cmd.String("cloudinit-merge-how"),
+		InstallMode:        cmd.String("install-mode"),
+		ExtraAgentLabels:   agentLabels,
+		Environment:        agentEnvironment,
+	}
+
+	if !slices.Contains(inits.Systems, config.InitSystem) {
+		return nil, fmt.Errorf("unknown init-system %q, must be one of %s", config.InitSystem, strings.Join(inits.Systems, ", "))
+	}
+	if config.InitSystem == inits.Ignition && config.UserData != "" {
+		return nil, fmt.Errorf("cloudinit-template can not be used with init-system %s", inits.Ignition)
+	}
+
+	for _, path := range cmd.StringSlice("cloudinit-parts") {
+		if config.InitSystem == inits.Ignition {
+			return nil, fmt.Errorf("cloudinit-parts can not be used with init-system %s", inits.Ignition)
+		}
+		part, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("can't read cloudinit part: %w", err)
+		}
+		config.UserDataParts = append(config.UserDataParts, string(part))
+	}
+
+	if err := buildInstallMode(cmd, config); err != nil {
+		return nil, err
+	}
+
+	config.Docker, err = buildDockerDaemon(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Docker.IsZero() && config.InstallMode != cloudinit.InstallModeDockerCompose {
+		return nil, fmt.Errorf("docker settings can not be used with install-mode %s", config.InstallMode)
+	}
+
+	if config.OSFamily != "" && !slices.Contains(cloudinit.OSFamilies(), config.OSFamily) {
+		return nil, fmt.Errorf("unknown cloudinit-os-family %q, must be one of %s",
+			config.OSFamily, strings.Join(cloudinit.OSFamilies(), ", "))
+	}
+
+	config.AgentInactivityTimeout, err = time.ParseDuration(cmd.String("agent-inactivity-timeout"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse agent-inactivity-timeout: %w", err)
+	}
+
+	config.AgentIdleTimeout, err = time.ParseDuration(cmd.String("agent-idle-timeout"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse agent-idle-timeout: %w", err)
+	}
+
+	config.AgentBillingTeardownMargin, err = time.ParseDuration(cmd.String("agent-billing-teardown-margin"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse agent-billing-teardown-margin: %w", err)
+	}
+
+	config.ReconciliationInterval, err = time.ParseDuration(cmd.String("reconciliation-interval"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse reconciliation-interval: %w", err)
+	}
+
+	config.SecretRefreshInterval, err = time.ParseDuration(cmd.String("secret-refresh-interval"))
+	if err != nil {
+		return nil, fmt.Errorf("can't parse secret-refresh-interval: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// buildInstallMode validates the install mode and sets up the agent release
+// the binary install mode installs.
+func buildInstallMode(cmd *cli.Command, cfg *config.Config) error {
+	if !slices.Contains(cloudinit.InstallModes, cfg.InstallMode) {
+		return fmt.Errorf("unknown install-mode %q, must be one of %s", cfg.InstallMode, strings.Join(cloudinit.InstallModes, ", "))
+	}
+	if cfg.InitSystem == inits.Ignition && cfg.InstallMode != cloudinit.InstallModeDockerCompose {
+		return fmt.Errorf("install-mode %s can not be used with init-system %s", cfg.InstallMode, inits.Ignition)
+	}
+	if cfg.InstallMode != cloudinit.InstallModeBinary {
+		return nil
+	}
+
+	checksums, err := utils.SliceToMap(cmd.StringSlice("agent-binary-checksums"), "=")
+	if err != nil {
+		return fmt.Errorf("invalid agent-binary-checksums: %w", err)
+	}
+	if len(checksums) == 0 {
+		return fmt.Errorf("install-mode binary requires agent-binary-checksums")
+	}
+	for arch, checksum := range checksums {
+		if !sha256Sum.MatchString(checksum) {
+			return fmt.Errorf("agent-binary-checksums: %s is not a sha256 sum: %q", arch, checksum)
+		}
+	}
+
+	cfg.AgentBinary = config.AgentBinary{
+		URL:       strings.TrimSuffix(cmd.String("agent-binary-url"), "/"),
+		Version:   cmd.String("agent-binary-version"),
+		Checksums: checksums,
+	}
+	if cfg.AgentBinary.Version == "" {
+		return fmt.Errorf("install-mode binary requires agent-binary-version")
+	}
+
+	return nil
+}
+
+// buildDockerDaemon assembles the daemon.json settings of the agents' docker,
+// they are validated along with the config.
+func buildDockerDaemon(cmd *cli.Command) (config.DockerDaemon, error) {
+	daemon := config.DockerDaemon{
+		RegistryMirrors:    cmd.StringSlice("docker-registry-mirrors"),
+		InsecureRegistries: cmd.StringSlice("docker-insecure-registries"),
+		MTU:                cmd.Int("docker-mtu"),
+		DataRoot:           cmd.String("docker-data-root"),
+		LogOpts: config.LogOpts{
+			MaxSize: cmd.String("docker-log-max-size"),
+		},
+	}
+	if cmd.IsSet("docker-log-max-file") {
+		daemon.LogOpts.MaxFile = strconv.Itoa(cmd.Int("docker-log-max-file"))
+	}
+	if daemon.LogOpts != (config.LogOpts{}) {
+		daemon.LogDriver = "json-file"
+	}
+
+	for _, pool := range cmd.StringSlice("docker-address-pools") {
+		base, size, ok := strings.Cut(pool, "=")
+		if !ok {
+			return daemon, fmt.Errorf("invalid docker-address-pools: %q is not a base=size pair", pool)
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return daemon, fmt.Errorf("invalid docker-address-pools: size of %s: %w", base, err)
+		}
+		daemon.DefaultAddressPools = append(daemon.DefaultAddressPools, config.AddressPool{Base: base, Size: n})
+	}
+
+	return daemon, nil
+}
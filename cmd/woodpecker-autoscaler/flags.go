@@ -4,118 +4,251 @@ import (
 	"os"
 
 	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 )
 
 //nolint:mnd
-var flags = []cli.Flag{
-	&cli.StringFlag{
-		Name:    "log-level",
-		Value:   "info",
-		Usage:   "default log level",
-		Sources: cli.EnvVars("WOODPECKER_LOG_LEVEL"),
-	},
-	&cli.StringFlag{
-		Name:    "reconciliation-interval",
-		Value:   "1m",
-		Usage:   "interval at which the autoscaler will reconcile as duration string like 2h45m (https://pkg.go.dev/time#ParseDuration)",
-		Sources: cli.EnvVars("WOODPECKER_RECONCILIATION_INTERVAL"),
-	},
-	&cli.StringFlag{
-		Name:    "pool-id",
-		Value:   "1",
-		Usage:   "id of the autoscaler pool",
-		Sources: cli.EnvVars("WOODPECKER_POOL_ID"),
-	},
-	&cli.IntFlag{
-		Name:    "min-agents",
-		Value:   1,
-		Usage:   "minimum amount of agents",
-		Sources: cli.EnvVars("WOODPECKER_MIN_AGENTS"),
-	},
-	&cli.IntFlag{
-		Name:    "max-agents",
-		Value:   10,
-		Usage:   "maximum amount of agents",
-		Sources: cli.EnvVars("WOODPECKER_MAX_AGENTS"),
-	},
-	&cli.StringFlag{
-		Name:    "agent-inactivity-timeout",
-		Value:   "10m",
-		Usage:   "time an agent is allowed to be inactive before it can be terminated as duration string like 2h45m (https://pkg.go.dev/time#ParseDuration)",
-		Sources: cli.EnvVars("WOODPECKER_AGENT_INACTIVITY_TIMEOUT", "WOODPECKER_AGENT_ALLOWED_STARTUP_TIME"),
-	},
-	&cli.StringFlag{
-		Name:    "agent-idle-timeout",
-		Value:   "10m",
-		Usage:   "time an agent is allowed to be idle before it can be terminated as duration string like 2h45m (https://pkg.go.dev/time#ParseDuration)",
-		Sources: cli.EnvVars("WOODPECKER_AGENT_IDLE_TIMEOUT"),
-	},
-	&cli.StringFlag{
-		Name:    "agent-billing-teardown-margin",
-		Value:   "2m",
-		Usage:   "for providers billed by the rounded-up hour (e.g. linode, hetznercloud), how long before each paid-hour boundary an idle agent becomes eligible for teardown",
-		Sources: cli.EnvVars("WOODPECKER_AGENT_BILLING_TEARDOWN_MARGIN"),
-	},
-	&cli.IntFlag{
-		Name:    "workflows-per-agent",
-		Value:   2,
-		Usage:   "max workflows an agent will executed in parallel",
-		Sources: cli.EnvVars("WOODPECKER_WORKFLOWS_PER_AGENT"),
-	},
-	&cli.StringFlag{
-		Name:    "server-url",
-		Value:   "http://localhost:8000",
-		Usage:   "woodpecker server address",
-		Sources: cli.EnvVars("WOODPECKER_SERVER"),
-	},
-	&cli.StringFlag{
-		Name:  "server-token",
-		Usage: "woodpecker api token",
-		Sources: cli.NewValueSourceChain(
-			cli.EnvVar("WOODPECKER_TOKEN"),
-			cli.File(os.Getenv("WOODPECKER_TOKEN_FILE")),
-		),
-	},
-	&cli.StringFlag{
-		Name:    "grpc-addr",
-		Value:   "woodpecker-server:9000",
-		Usage:   "grpc address of the woodpecker server",
-		Sources: cli.EnvVars("WOODPECKER_GRPC_ADDR"),
-	},
-	&cli.BoolFlag{
-		Name:    "grpc-secure",
-		Value:   false,
-		Usage:   "use secure grpc connection to the woodpecker server",
-		Sources: cli.EnvVars("WOODPECKER_GRPC_SECURE"),
-	},
-	&cli.StringFlag{
-		Name:    "provider",
-		Value:   "",
-		Usage:   "cloud provider to use",
-		Sources: cli.EnvVars("WOODPECKER_PROVIDER"),
-	},
-	&cli.StringFlag{
-		Name:  "cloudinit-template",
-		Usage: "cloudinit userdata template to setup the provider instance",
-		Sources: cli.NewValueSourceChain(
-			cli.EnvVar("WOODPECKER_CLOUDINIT_TEMPLATE"),
-			cli.File(os.Getenv("WOODPECKER_CLOUDINIT_TEMPLATE_FILE")),
-		),
-	},
-	&cli.StringFlag{
-		Name:    "agent-image",
-		Value:   "woodpeckerci/woodpecker-agent:next",
-		Usage:   "agent image to use",
-		Sources: cli.EnvVars("WOODPECKER_AGENT_IMAGE"),
-	},
-	&cli.StringSliceFlag{
-		Name:    "agent-env",
-		Usage:   "additional agent environment variables as list with key=value pairs",
-		Sources: cli.EnvVars("WOODPECKER_AGENT_ENV"),
-	},
-	&cli.StringSliceFlag{
-		Name:    "agent-labels",
-		Usage:   "add additional labels the agent will report to the server. list with key=value pairs",
-		Sources: cli.EnvVars("WOODPECKER_AGENT_LABELS"),
-	},
+func globalFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config-file",
+			Usage:   "YAML configuration file; flags and environment variables take precedence over its settings",
+			Sources: cli.EnvVars("WOODPECKER_CONFIG_FILE"),
+		},
+		&cli.StringFlag{
+			Name:    "log-level",
+			Value:   "info",
+			Usage:   "default log level",
+			Sources: cli.EnvVars("WOODPECKER_LOG_LEVEL"),
+		},
+		&cli.StringFlag{
+			Name:    "reconciliation-interval",
+			Value:   "1m",
+			Usage:   "interval at which the autoscaler will reconcile as duration string like 2h45m (https://pkg.go.dev/time#ParseDuration)",
+			Sources: cli.EnvVars("WOODPECKER_RECONCILIATION_INTERVAL"),
+		},
+		&cli.StringFlag{
+			Name:    "secret-refresh-interval",
+			Value:   "5m",
+			Usage:   "interval at which secrets (file:, env: or exec: references and _FILE variables) are read again to pick up rotated credentials; 0 disables it",
+			Sources: cli.EnvVars("WOODPECKER_SECRET_REFRESH_INTERVAL"),
+		},
+		&cli.StringFlag{
+			Name:    "pool-id",
+			Value:   "1",
+			Usage:   "id of the autoscaler pool",
+			Sources: cli.EnvVars("WOODPECKER_POOL_ID"),
+		},
+		&cli.IntFlag{
+			Name:    "min-agents",
+			Value:   1,
+			Usage:   "minimum amount of agents",
+			Sources: cli.EnvVars("WOODPECKER_MIN_AGENTS"),
+		},
+		&cli.IntFlag{
+			Name:    "max-agents",
+			Value:   10,
+			Usage:   "maximum amount of agents",
+			Sources: cli.EnvVars("WOODPECKER_MAX_AGENTS"),
+		},
+		&cli.StringFlag{
+			Name:    "agent-inactivity-timeout",
+			Value:   "10m",
+			Usage:   "time an agent is allowed to be inactive before it can be terminated as duration string like 2h45m (https://pkg.go.dev/time#ParseDuration)",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_INACTIVITY_TIMEOUT", "WOODPECKER_AGENT_ALLOWED_STARTUP_TIME"),
+		},
+		&cli.StringFlag{
+			Name:    "agent-idle-timeout",
+			Value:   "10m",
+			Usage:   "time an agent is allowed to be idle before it can be terminated as duration string like 2h45m (https://pkg.go.dev/time#ParseDuration)",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_IDLE_TIMEOUT"),
+		},
+		&cli.StringFlag{
+			Name:    "agent-billing-teardown-margin",
+			Value:   "2m",
+			Usage:   "for providers billed by the rounded-up hour (e.g. linode, hetznercloud), how long before each paid-hour boundary an idle agent becomes eligible for teardown",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_BILLING_TEARDOWN_MARGIN"),
+		},
+		&cli.IntFlag{
+			Name:    "workflows-per-agent",
+			Value:   2,
+			Usage:   "max workflows an agent will executed in parallel",
+			Sources: cli.EnvVars("WOODPECKER_WORKFLOWS_PER_AGENT"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "candidate-workflows",
+			Usage:   "max workflows by instance type as list with type=workflows pairs, e.g. cx22=1,cx52=4",
+			Sources: cli.EnvVars("WOODPECKER_CANDIDATE_WORKFLOWS"),
+		},
+		&cli.FloatFlag{
+			Name:    "workflow-cpus",
+			Usage:   "vCPUs one workflow needs, to derive the max workflows of an instance type from its vCPUs",
+			Sources: cli.EnvVars("WOODPECKER_WORKFLOW_CPUS"),
+		},
+		&cli.FloatFlag{
+			Name:    "workflow-memory",
+			Usage:   "memory in GiB one workflow needs, to derive the max workflows of an instance type from its memory",
+			Sources: cli.EnvVars("WOODPECKER_WORKFLOW_MEMORY"),
+		},
+		&cli.StringFlag{
+			Name:    "server-url",
+			Value:   "http://localhost:8000",
+			Usage:   "woodpecker server address",
+			Sources: cli.EnvVars("WOODPECKER_SERVER"),
+		},
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:    "server-token",
+			Usage:   "woodpecker api token",
+			Sources: config.SecretSources("WOODPECKER_TOKEN"),
+		}},
+		&cli.StringFlag{
+			Name:    "bootstrap-addr",
+			Usage:   "listen address (e.g. :8080) of the bootstrap endpoint agents fetch their token from; if unset, the token is part of the user data",
+			Sources: cli.EnvVars("WOODPECKER_BOOTSTRAP_ADDR"),
+		},
+		&cli.StringFlag{
+			Name:    "bootstrap-url",
+			Usage:   "url of the bootstrap endpoint, publicly accessible from the agents",
+			Sources: cli.EnvVars("WOODPECKER_BOOTSTRAP_URL"),
+		},
+		&cli.StringFlag{
+			Name:    "bootstrap-nonce-ttl",
+			Value:   "15m",
+			Usage:   "how long an agent can exchange its bootstrap nonce for the token after it was deployed",
+			Sources: cli.EnvVars("WOODPECKER_BOOTSTRAP_NONCE_TTL"),
+		},
+		&cli.StringFlag{
+			Name:    "bootstrap-tls-cert",
+			Usage:   "certificate file to serve the bootstrap endpoint with TLS",
+			Sources: cli.EnvVars("WOODPECKER_BOOTSTRAP_TLS_CERT"),
+		},
+		&cli.StringFlag{
+			Name:    "bootstrap-tls-key",
+			Usage:   "key file to serve the bootstrap endpoint with TLS",
+			Sources: cli.EnvVars("WOODPECKER_BOOTSTRAP_TLS_KEY"),
+		},
+		&cli.StringFlag{
+			Name:    "grpc-addr",
+			Value:   "woodpecker-server:9000",
+			Usage:   "grpc address of the woodpecker server",
+			Sources: cli.EnvVars("WOODPECKER_GRPC_ADDR"),
+		},
+		&cli.BoolFlag{
+			Name:    "grpc-secure",
+			Value:   false,
+			Usage:   "use secure grpc connection to the woodpecker server",
+			Sources: cli.EnvVars("WOODPECKER_GRPC_SECURE"),
+		},
+		&cli.StringFlag{
+			Name:    "provider",
+			Value:   "",
+			Usage:   "cloud provider to use",
+			Sources: cli.EnvVars("WOODPECKER_PROVIDER"),
+		},
+		&cli.StringFlag{
+			Name:    "init-system",
+			Value:   "cloudinit",
+			Usage:   "init system the agent user data is rendered for: cloudinit or ignition (Fedora CoreOS, Flatcar)",
+			Sources: cli.EnvVars("WOODPECKER_INIT_SYSTEM"),
+		},
+		&cli.StringFlag{
+			Name:  "cloudinit-template",
+			Usage: "cloudinit userdata template to setup the provider instance",
+			Sources: cli.NewValueSourceChain(
+				cli.EnvVar("WOODPECKER_CLOUDINIT_TEMPLATE"),
+				cli.File(os.Getenv("WOODPECKER_CLOUDINIT_TEMPLATE_FILE")),
+			),
+		},
+		&cli.StringSliceFlag{
+			Name:    "cloudinit-parts",
+			Usage:   "files with extra cloudinit parts (cloud-config, #cloud-boothook or #! scripts) to add to the template",
+			Sources: cli.EnvVars("WOODPECKER_CLOUDINIT_PARTS"),
+		},
+		&cli.StringFlag{
+			Name:    "cloudinit-merge-how",
+			Value:   cloudinit.DefaultMergeHow,
+			Usage:   "how cloud-config parts without their own merge_how are merged into the template",
+			Sources: cli.EnvVars("WOODPECKER_CLOUDINIT_MERGE_HOW"),
+		},
+		&cli.StringFlag{
+			Name:    "cloudinit-os-family",
+			Usage:   "built-in cloudinit template to use (ubuntu, debian, fedora, rhel, amazon, suse) instead of the one matching the image; ignored if cloudinit-template is set",
+			Sources: cli.EnvVars("WOODPECKER_CLOUDINIT_OS_FAMILY"),
+		},
+		&cli.StringFlag{
+			Name:    "install-mode",
+			Value:   cloudinit.InstallModeDockerCompose,
+			Usage:   "how the built-in cloudinit templates run the agent: docker-compose, podman or binary",
+			Sources: cli.EnvVars("WOODPECKER_INSTALL_MODE"),
+		},
+		&cli.StringFlag{
+			Name:    "agent-binary-version",
+			Usage:   "agent release to install with install-mode binary, e.g. v3.5.0",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_BINARY_VERSION"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "agent-binary-checksums",
+			Usage:   "sha256 sums of the agent release archives as list with arch=sha256 pairs, e.g. amd64=...",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_BINARY_CHECKSUMS"),
+		},
+		&cli.StringFlag{
+			Name:    "agent-binary-url",
+			Value:   cloudinit.DefaultAgentBinaryURL,
+			Usage:   "url the agent release archives are downloaded from, followed by the version and the archive name",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_BINARY_URL"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "docker-registry-mirrors",
+			Usage:   "registry mirrors of the agents' docker daemon",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_REGISTRY_MIRRORS"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "docker-insecure-registries",
+			Usage:   "registries (host[:port] or CIDR) the agents' docker daemon may reach without TLS",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_INSECURE_REGISTRIES"),
+		},
+		&cli.IntFlag{
+			Name:    "docker-mtu",
+			Usage:   "MTU of the agents' docker bridge network",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_MTU"),
+		},
+		&cli.StringFlag{
+			Name:    "docker-data-root",
+			Usage:   "directory the agents' docker daemon stores images and containers in",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_DATA_ROOT"),
+		},
+		&cli.StringFlag{
+			Name:    "docker-log-max-size",
+			Usage:   "size container logs of the agents' docker daemon are rotated at, e.g. 10m",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_LOG_MAX_SIZE"),
+		},
+		&cli.IntFlag{
+			Name:    "docker-log-max-file",
+			Usage:   "number of rotated container log files the agents' docker daemon keeps",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_LOG_MAX_FILE"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "docker-address-pools",
+			Usage:   "default address pools of the agents' docker daemon as list with base=size pairs, e.g. 10.10.0.0/16=24",
+			Sources: cli.EnvVars("WOODPECKER_DOCKER_ADDRESS_POOLS"),
+		},
+		&cli.StringFlag{
+			Name:    "agent-image",
+			Value:   "woodpeckerci/woodpecker-agent:next",
+			Usage:   "agent image to use",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_IMAGE"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "agent-env",
+			Usage:   "additional agent environment variables as list with key=value pairs",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_ENV"),
+		},
+		&cli.StringSliceFlag{
+			Name:    "agent-labels",
+			Usage:   "add additional labels the agent will report to the server. list with key=value pairs",
+			Sources: cli.EnvVars("WOODPECKER_AGENT_LABELS"),
+		},
+	}
 }
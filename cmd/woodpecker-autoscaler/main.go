@@ -4,7 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
@@ -16,19 +17,37 @@ import (
 	"go.woodpecker-ci.org/autoscaler/engine"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/providers/aws"
+	"go.woodpecker-ci.org/autoscaler/providers/azure"
 	"go.woodpecker-ci.org/autoscaler/providers/digitalocean"
 	"go.woodpecker-ci.org/autoscaler/providers/equinixmetal"
+	"go.woodpecker-ci.org/autoscaler/providers/exoscale"
+	"go.woodpecker-ci.org/autoscaler/providers/gce"
 	"go.woodpecker-ci.org/autoscaler/providers/hetznercloud"
+	"go.woodpecker-ci.org/autoscaler/providers/incus"
+	"go.woodpecker-ci.org/autoscaler/providers/kubernetes"
+	"go.woodpecker-ci.org/autoscaler/providers/libvirt"
 	"go.woodpecker-ci.org/autoscaler/providers/linode"
+	"go.woodpecker-ci.org/autoscaler/providers/local"
+	"go.woodpecker-ci.org/autoscaler/providers/multi"
+	"go.woodpecker-ci.org/autoscaler/providers/oci"
 	"go.woodpecker-ci.org/autoscaler/providers/openstack"
+	"go.woodpecker-ci.org/autoscaler/providers/plugin"
+	"go.woodpecker-ci.org/autoscaler/providers/proxmox"
 	"go.woodpecker-ci.org/autoscaler/providers/scaleway"
+	"go.woodpecker-ci.org/autoscaler/providers/upcloud"
 	"go.woodpecker-ci.org/autoscaler/providers/vultr"
+	"go.woodpecker-ci.org/autoscaler/providers/webhook"
 	"go.woodpecker-ci.org/autoscaler/server"
 	"go.woodpecker-ci.org/autoscaler/version"
 )
 
 func setupProvider(ctx context.Context, cmd *cli.Command, config *config.Config) (types.Provider, error) {
-	switch cmd.String("provider") {
+	return newProvider(ctx, cmd, cmd.String("provider"), config)
+}
+
+// newProvider creates the named provider, configured by its settings.
+func newProvider(ctx context.Context, cmd *cli.Command, name string, config *config.Config) (types.Provider, error) {
+	switch name {
 	case "aws":
 		return aws.New(ctx, cmd, config)
 	case "digitalocean":
@@ -41,15 +60,43 @@ func setupProvider(ctx context.Context, cmd *cli.Command, config *config.Config)
 		return linode.New(ctx, cmd, config)
 	case "vultr":
 		return vultr.New(ctx, cmd, config)
+	case "exoscale":
+		return exoscale.New(ctx, cmd, config)
+	case "upcloud":
+		return upcloud.New(ctx, cmd, config)
 	case "openstack":
 		return openstack.New(ctx, cmd, config)
 	case "scaleway":
 		return scaleway.New(ctx, cmd, config)
+	case "gce":
+		return gce.New(ctx, cmd, config)
+	case "azure":
+		return azure.New(ctx, cmd, config)
+	case "kubernetes":
+		return kubernetes.New(ctx, cmd, config)
+	case "local":
+		return local.New(ctx, cmd, config)
+	case "proxmox":
+		return proxmox.New(ctx, cmd, config)
+	case "incus":
+		return incus.New(ctx, cmd, config)
+	case "libvirt":
+		return libvirt.New(ctx, cmd, config)
+	case "oci":
+		return oci.New(ctx, cmd, config)
+	case "plugin":
+		return plugin.New(ctx, cmd, config)
+	case "webhook":
+		return webhook.New(ctx, cmd, config)
+	case "multi":
+		return multi.New(ctx, cmd, config, func(ctx context.Context, member string) (types.Provider, error) {
+			return newProvider(ctx, cmd, member, config)
+		})
 	case "":
 		return nil, fmt.Errorf("please select a provider")
 	}
 
-	return nil, fmt.Errorf("unknown provider: %s", cmd.String("provider"))
+	return nil, fmt.Errorf("unknown provider: %s", name)
 }
 
 func run(ctx context.Context, cmd *cli.Command) error {
@@ -60,40 +107,21 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	agentEnvironment := make(map[string]string)
-	for _, env := range cmd.StringSlice("agent-env") {
-		before, after, _ := strings.Cut(env, "=")
-		if before == "" || after == "" {
-			return fmt.Errorf("invalid agent environment variable: %s", env)
-		}
-		agentEnvironment[before] = after
+	config, err := buildConfig(cmd)
+	if err != nil {
+		return err
 	}
 
-	agentLabels := make(map[string]string)
-	for _, env := range cmd.StringSlice("agent-labels") {
-		before, after, _ := strings.Cut(env, "=")
-		if before == "" || after == "" {
-			return fmt.Errorf("invalid agent labels variable: %s", env)
-		}
-		agentLabels[before] = after
+	bootstrap, err := startBootstrap(ctx, cmd)
+	if err != nil {
+		return err
 	}
-
-	if _, exist := agentEnvironment["WOODPECKER_AGENT_LABELS"]; exist {
-		log.Error().Msg("setting WOODPECKER_AGENT_LABELS via WOODPECKER_AGENT_ENV is forbidden, use native autoscaler setting for that")
-		return fmt.Errorf("'WOODPECKER_AGENT_ENV' has forbidden env var set: \"WOODPECKER_AGENT_LABELS\"")
+	if bootstrap != nil {
+		config.Bootstrap = bootstrap
 	}
 
-	config := &config.Config{
-		MinAgents:         cmd.Int("min-agents"),
-		MaxAgents:         cmd.Int("max-agents"),
-		WorkflowsPerAgent: cmd.Int("workflows-per-agent"),
-		PoolID:            cmd.String("pool-id"),
-		GRPCAddress:       cmd.String("grpc-addr"),
-		GRPCSecure:        cmd.Bool("grpc-secure"),
-		Image:             cmd.String("agent-image"),
-		UserData:          cmd.String("cloudinit-template"),
-		ExtraAgentLabels:  agentLabels,
-		Environment:       agentEnvironment,
+	if err := previewUserData(cmd, config); err != nil {
+		return err
 	}
 
 	provider, err := setupProvider(ctx, cmd, config)
@@ -103,55 +131,107 @@ func run(ctx context.Context, cmd *cli.Command) error {
 	config.BillingModel = provider.BillingModel()
 
 	autoscaler := engine.NewAutoscaler(provider, client, config)
+	reconciliationInterval := config.ReconciliationInterval
 
-	config.AgentInactivityTimeout, err = time.ParseDuration(cmd.String("agent-inactivity-timeout"))
-	if err != nil {
-		return fmt.Errorf("can't parse agent-inactivity-timeout: %w", err)
+	if config.BillingModel == types.BillingHourlyRoundUp {
+		log.Info().
+			Str("provider", cmd.String("provider")).
+			Str("teardown-window", (config.AgentBillingTeardownMargin + reconciliationInterval).String()).
+			Msg("hourly-round-up billing: idle agents are kept warm until just before each paid-hour boundary")
 	}
 
-	config.AgentIdleTimeout, err = time.ParseDuration(cmd.String("agent-idle-timeout"))
-	if err != nil {
-		return fmt.Errorf("can't parse agent-idle-timeout: %w", err)
-	}
+	reloader := newReloader(os.Args, cmd, config, &autoscaler)
 
-	config.AgentBillingTeardownMargin, err = time.ParseDuration(cmd.String("agent-billing-teardown-margin"))
-	if err != nil {
-		return fmt.Errorf("can't parse agent-billing-teardown-margin: %w", err)
-	}
+	// a changed config file is picked up on SIGHUP or once its content changes
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
 
-	reconciliationInterval, err := time.ParseDuration(cmd.String("reconciliation-interval"))
-	if err != nil {
-		return fmt.Errorf("can't parse reconciliation-interval: %w", err)
+	var watch <-chan time.Time
+	if cmd.String("config-file") != "" {
+		ticker := time.NewTicker(configWatchInterval)
+		defer ticker.Stop()
+		watch = ticker.C
 	}
-	config.ReconciliationInterval = reconciliationInterval
 
-	if config.BillingModel == types.BillingHourlyRoundUp {
-		log.Info().
-			Str("provider", cmd.String("provider")).
-			Str("teardown-window", (config.AgentBillingTeardownMargin + reconciliationInterval).String()).
-			Msg("hourly-round-up billing: idle agents are kept warm until just before each paid-hour boundary")
+	// secrets are read again periodically to pick up rotated credentials
+	var refresh <-chan time.Time
+	if config.SecretRefreshInterval > 0 {
+		ticker := time.NewTicker(config.SecretRefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
 	}
 
+	reconcile := time.NewTimer(reconciliationInterval)
+	defer reconcile.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-time.After(reconciliationInterval):
+		case <-hangup:
+			reloader.changed()
+			if err := reloader.reload(ctx); err != nil {
+				log.Error().Err(err).Msg("config reload failed, keeping the previous config")
+			}
+		case <-watch:
+			if !reloader.changed() {
+				continue
+			}
+			if err := reloader.reload(ctx); err != nil {
+				log.Error().Err(err).Msg("config reload failed, keeping the previous config")
+			}
+		case <-refresh:
+			if err := reloader.reload(ctx); err != nil {
+				log.Error().Err(err).Msg("secret refresh failed, keeping the previous config")
+			}
+		case <-reconcile.C:
 			err := autoscaler.Reconcile(ctx)
 			if err != nil {
 				log.Error().Err(err).Msg("reconciliation failed")
 			}
+			reconcile.Reset(reloader.config.ReconciliationInterval)
 		}
 	}
 }
 
-func main() {
-	app := &cli.Command{
+func newCommand() *cli.Command {
+	flags := globalFlags()
+	flags = append(flags, hetznercloud.ProviderFlags()...)
+	flags = append(flags, equinixmetal.ProviderFlags()...)
+	flags = append(flags, scaleway.ProviderFlags()...)
+	flags = append(flags, linode.ProviderFlags()...)
+	flags = append(flags, aws.ProviderFlags()...)
+	flags = append(flags, digitalocean.ProviderFlags()...)
+	flags = append(flags, vultr.ProviderFlags()...)
+	flags = append(flags, exoscale.ProviderFlags()...)
+	flags = append(flags, upcloud.ProviderFlags()...)
+	flags = append(flags, openstack.ProviderFlags()...)
+	flags = append(flags, gce.ProviderFlags()...)
+	flags = append(flags, azure.ProviderFlags()...)
+	flags = append(flags, kubernetes.ProviderFlags()...)
+	flags = append(flags, local.ProviderFlags()...)
+	flags = append(flags, proxmox.ProviderFlags()...)
+	flags = append(flags, incus.ProviderFlags()...)
+	flags = append(flags, libvirt.ProviderFlags()...)
+	flags = append(flags, oci.ProviderFlags()...)
+	flags = append(flags, plugin.ProviderFlags()...)
+	flags = append(flags, webhook.ProviderFlags()...)
+	flags = append(flags, multi.ProviderFlags()...)
+
+	return &cli.Command{
 		Name:    "autoscaler",
 		Version: version.String(),
 		Usage:   "scale to the moon and back",
 		Flags:   flags,
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if err := loadConfigFile(cmd); err != nil {
+				return ctx, err
+			}
+			if err := config.ResolveSecrets(ctx, cmd); err != nil {
+				return ctx, err
+			}
+
 			zerolog.SetGlobalLevel(zerolog.InfoLevel)
 			if cmd.IsSet("log-level") {
 				logLevelFlag := cmd.String("log-level")
@@ -171,17 +251,10 @@ func main() {
 		},
 		Action: run,
 	}
+}
 
-	app.Flags = append(app.Flags, hetznercloud.ProviderFlags...)
-	app.Flags = append(app.Flags, equinixmetal.ProviderFlags...)
-	app.Flags = append(app.Flags, scaleway.ProviderFlags...)
-	app.Flags = append(app.Flags, linode.ProviderFlags...)
-	app.Flags = append(app.Flags, aws.ProviderFlags...)
-	app.Flags = append(app.Flags, digitalocean.ProviderFlags...)
-	app.Flags = append(app.Flags, vultr.ProviderFlags...)
-	app.Flags = append(app.Flags, openstack.ProviderFlags...)
-
-	if err := app.Run(context.Background(), os.Args); err != nil {
+func main() {
+	if err := newCommand().Run(context.Background(), os.Args); err != nil {
 		log.Error().Err(err).Msg("got error while try to run autoscaler")
 	}
 }
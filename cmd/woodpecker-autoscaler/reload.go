@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/server"
+)
+
+// configWatchInterval is how often the config file is checked for changes.
+const configWatchInterval = 10 * time.Second
+
+// serverSettings configure the connection to the woodpecker server, the
+// client is only set up again if one of them changed.
+var serverSettings = []string{"server-url", "server-token"}
+
+// restartSettings are read once at startup, a changed value only takes effect
+// after a restart.
+var restartSettings = []string{
+	"log-level",
+	"bootstrap-addr",
+	"bootstrap-url",
+	"bootstrap-nonce-ttl",
+	"bootstrap-tls-cert",
+	"bootstrap-tls-key",
+}
+
+// reloader applies changes of the configuration file and of secrets to a
+// running autoscaler. A file that does not result in a valid configuration is
+// rejected and the previous configuration stays in effect.
+type reloader struct {
+	args       []string
+	cmd        *cli.Command
+	config     *config.Config
+	autoscaler *engine.Autoscaler
+	digest     [sha256.Size]byte
+}
+
+func newReloader(args []string, cmd *cli.Command, config *config.Config, autoscaler *engine.Autoscaler) *reloader {
+	r := &reloader{
+		args:       args,
+		cmd:        cmd,
+		config:     config,
+		autoscaler: autoscaler,
+	}
+	r.changed()
+
+	return r
+}
+
+// changed reports whether the content of the config file differs from the one
+// seen last. A file that can not be read counts as a change once, so the
+// failing reload gets logged.
+func (r *reloader) changed() bool {
+	data, _ := os.ReadFile(r.cmd.String("config-file"))
+	digest := sha256.Sum256(data)
+	if digest == r.digest {
+		return false
+	}
+
+	r.digest = digest
+	return true
+}
+
+// reload parses the command line, environment and config file again, resolves
+// all secrets and applies the result. The server client and the provider are
+// only set up again if their settings changed, otherwise the running provider
+// picks up the new config in place.
+func (r *reloader) reload(ctx context.Context) error {
+	cmd, err := parseCommand(ctx, r.args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := buildConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range restartSettings {
+		if !reflect.DeepEqual(r.cmd.Value(name), cmd.Value(name)) {
+			log.Warn().Str("setting", name).Msg("changed setting only takes effect after a restart")
+		}
+	}
+	cfg.Bootstrap = r.config.Bootstrap
+
+	if err := previewUserData(cmd, cfg); err != nil {
+		return err
+	}
+
+	var client server.Client
+	if settingsChanged(r.cmd, cmd, serverSettings) {
+		client, err = server.NewClient(ctx, cmd)
+		if err != nil {
+			return err
+		}
+	}
+
+	var provider types.Provider
+	if providerSettingsChanged(r.cmd, cmd) {
+		provider, err = setupProvider(ctx, cmd, cfg)
+		if err != nil {
+			return err
+		}
+		cfg.BillingModel = provider.BillingModel()
+	} else {
+		cfg.BillingModel = r.config.BillingModel
+	}
+
+	changed := client != nil || provider != nil || !reflect.DeepEqual(*r.config, *cfg)
+
+	if client != nil {
+		r.autoscaler.SetClient(client)
+		log.Info().Msg("server client set up with reloaded settings")
+	}
+
+	if provider != nil {
+		if previous := r.cmd.String("provider"); previous != cmd.String("provider") {
+			log.Warn().
+				Str("previous", previous).
+				Str("provider", cmd.String("provider")).
+				Msg("provider changed, agents deployed by the previous provider are not removed")
+		}
+
+		r.autoscaler.SetProvider(provider, cfg)
+		r.config = cfg
+		log.Info().Str("provider", cmd.String("provider")).Msg("provider set up with reloaded settings")
+	} else {
+		*r.config = *cfg
+	}
+	r.cmd = cmd
+
+	if changed {
+		log.Info().Msg("config reloaded")
+	} else {
+		log.Debug().Msg("config unchanged")
+	}
+
+	return nil
+}
+
+// parseCommand parses the command line with fresh flags and applies the
+// config file, without running the autoscaler.
+func parseCommand(ctx context.Context, args []string) (*cli.Command, error) {
+	var parsed *cli.Command
+
+	cmd := newCommand()
+	cmd.Before = func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+		if err := loadConfigFile(cmd); err != nil {
+			return ctx, err
+		}
+		return ctx, config.ResolveSecrets(ctx, cmd)
+	}
+	cmd.Action = func(_ context.Context, cmd *cli.Command) error {
+		parsed = cmd
+		return nil
+	}
+
+	if err := cmd.Run(ctx, args); err != nil {
+		return nil, err
+	}
+	if parsed == nil {
+		return nil, fmt.Errorf("parse command line: autoscaler would not run")
+	}
+
+	return parsed, nil
+}
+
+// providerSettingsChanged reports whether the selected provider or any of its
+// settings differ between both commands.
+func providerSettingsChanged(previous, cmd *cli.Command) bool {
+	provider := cmd.String("provider")
+	if previous.String("provider") != provider {
+		return true
+	}
+
+	// the settings of the members of the multi provider count as its own
+	selected, err := selectedProviders(cmd)
+	if err != nil {
+		return true
+	}
+
+	var names []string
+	for _, flag := range cmd.Flags {
+		prefix, _, _ := strings.Cut(flag.Names()[0], "-")
+		if slices.Contains(selected, prefix) {
+			names = append(names, flag.Names()[0])
+		}
+	}
+
+	return settingsChanged(previous, cmd, names)
+}
+
+// settingsChanged reports whether any of the named settings differs between
+// both commands.
+func settingsChanged(previous, cmd *cli.Command, names []string) bool {
+	for _, name := range names {
+		if !reflect.DeepEqual(previous.Value(name), cmd.Value(name)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,15 +1,43 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 )
 
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Bootstrap hands out agent tokens in exchange for single-use nonces, see
+// package bootstrap.
+type Bootstrap interface {
+	// Issue returns a nonce the agent exchanges for its token once.
+	Issue(agent, token string) (string, error)
+	// URL returns the address agents exchange their nonce at.
+	URL() string
+}
+
+// AgentBinary is a pinned agent release.
+type AgentBinary struct {
+	// URL the release archives are downloaded from, followed by the version
+	// and the archive name.
+	URL     string
+	Version string
+	// Checksums are the sha256 sums of the release archives by architecture
+	// (amd64, arm64).
+	Checksums map[string]string
+}
+
 type Config struct {
 	MinAgents              int
 	MaxAgents              int
 	WorkflowsPerAgent      int
+	CandidateWorkflows     map[string]int // workflows by instance type, overriding WorkflowsPerAgent and the derived capacity
+	WorkflowCPUs           float64        // vCPUs one workflow needs, to derive the capacity of an instance type; 0 ignores them
+	WorkflowMemory         float64        // memory in GiB one workflow needs, to derive the capacity of an instance type; 0 ignores it
 	PoolID                 string
 	Image                  string
 	Environment            map[string]string
@@ -17,9 +45,20 @@ type Config struct {
 	GRPCSecure             bool
 	AgentInactivityTimeout time.Duration
 	AgentIdleTimeout       time.Duration
-	UserData               string // cloudinit template
+	InitSystem             string       // cloudinit (default) or ignition
+	UserData               string       // cloudinit template
+	OSFamily               string       // built-in cloudinit template, detected from the image if empty
+	UserDataParts          []string     // extra cloudinit parts, assembled with the template into a multipart document
+	UserDataMergeHow       string       // merge type of cloud-config parts that do not set their own
+	InstallMode            string       // how the built-in templates run the agent, docker-compose (default), podman or binary
+	AgentBinary            AgentBinary  // agent release the binary install mode installs
+	Docker                 DockerDaemon // daemon.json of the agents' docker
 	ExtraAgentLabels       map[string]string
 
+	// Bootstrap, if set, replaces the agent token in the user data by a
+	// nonce exchanged for it at boot.
+	Bootstrap Bootstrap
+
 	// BillingModel is taken from the selected provider and selects the teardown
 	// policy the engine applies to idle agents.
 	BillingModel types.BillingModel
@@ -27,8 +66,94 @@ type Config struct {
 	// AgentBillingTeardownMargin so the billing-hour teardown window can never
 	// be skipped between two reconciliations.
 	ReconciliationInterval time.Duration
+	// SecretRefreshInterval is how often secrets are read again, 0 disables
+	// it.
+	SecretRefreshInterval time.Duration
 	// AgentBillingTeardownMargin is, for BillingHourlyRoundUp providers, how
 	// long before each paid-hour boundary an idle agent becomes eligible for
 	// teardown.
 	AgentBillingTeardownMargin time.Duration
 }
+
+// Validate reports every invalid setting at once, so a broken configuration
+// can be fixed in one go instead of one restart per mistake.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidConfig, fmt.Sprintf(format, a...)))
+	}
+
+	if c.MinAgents < 0 {
+		invalid("min-agents must not be negative, got %d", c.MinAgents)
+	}
+	if c.MaxAgents < c.MinAgents {
+		invalid("min-agents (%d) must not exceed max-agents (%d)", c.MinAgents, c.MaxAgents)
+	}
+	if c.WorkflowsPerAgent <= 0 {
+		invalid("workflows-per-agent must be greater than 0, got %d", c.WorkflowsPerAgent)
+	}
+	for instanceType, workflows := range c.CandidateWorkflows {
+		if workflows <= 0 {
+			invalid("candidate-workflows of %s must be greater than 0, got %d", instanceType, workflows)
+		}
+	}
+	if c.WorkflowCPUs < 0 {
+		invalid("workflow-cpus must not be negative, got %g", c.WorkflowCPUs)
+	}
+	if c.WorkflowMemory < 0 {
+		invalid("workflow-memory must not be negative, got %g", c.WorkflowMemory)
+	}
+	if c.PoolID == "" {
+		invalid("pool-id must not be empty")
+	}
+	if c.Image == "" {
+		invalid("agent-image must not be empty")
+	}
+	if c.ReconciliationInterval <= 0 {
+		invalid("reconciliation-interval must be greater than 0, got %s", c.ReconciliationInterval)
+	}
+	if c.SecretRefreshInterval < 0 {
+		invalid("secret-refresh-interval must not be negative, got %s", c.SecretRefreshInterval)
+	}
+	if c.AgentInactivityTimeout <= 0 {
+		invalid("agent-inactivity-timeout must be greater than 0, got %s", c.AgentInactivityTimeout)
+	}
+	if c.AgentIdleTimeout < 0 {
+		invalid("agent-idle-timeout must not be negative, got %s", c.AgentIdleTimeout)
+	}
+	if c.AgentBillingTeardownMargin < 0 {
+		invalid("agent-billing-teardown-margin must not be negative, got %s", c.AgentBillingTeardownMargin)
+	}
+
+	if err := c.Docker.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+const mibPerGiB = 1024
+
+// Workflows returns how many workflows an agent on the instance type runs in
+// parallel: as configured for the instance type, else as many as its vCPUs
+// and memory (in MiB) fit, else WorkflowsPerAgent. Providers that do not know
+// the resources of an instance type pass 0.
+func (c *Config) Workflows(instanceType string, cpus int, memory int64) int {
+	if workflows, ok := c.CandidateWorkflows[instanceType]; ok {
+		return workflows
+	}
+
+	workflows := math.MaxInt
+	if c.WorkflowCPUs > 0 && cpus > 0 {
+		workflows = min(workflows, int(float64(cpus)/c.WorkflowCPUs))
+	}
+	if c.WorkflowMemory > 0 && memory > 0 {
+		workflows = min(workflows, int(float64(memory)/mibPerGiB/c.WorkflowMemory))
+	}
+	if workflows == math.MaxInt {
+		return c.WorkflowsPerAgent
+	}
+
+	// a machine too small for one workflow still runs one
+	return max(workflows, 1)
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+func validConfig() *config.Config {
+	return &config.Config{
+		MinAgents:              1,
+		MaxAgents:              3,
+		WorkflowsPerAgent:      2,
+		PoolID:                 "1",
+		Image:                  "woodpeckerci/woodpecker-agent:next",
+		AgentInactivityTimeout: 10 * time.Minute,
+		AgentIdleTimeout:       10 * time.Minute,
+		ReconciliationInterval: time.Minute,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*config.Config)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(*config.Config) {},
+		},
+		{
+			name:   "min exceeds max",
+			modify: func(c *config.Config) { c.MinAgents = 4 },
+			want:   []string{"min-agents (4) must not exceed max-agents (3)"},
+		},
+		{
+			name:   "no workflows per agent",
+			modify: func(c *config.Config) { c.WorkflowsPerAgent = 0 },
+			want:   []string{"workflows-per-agent"},
+		},
+		{
+			name:   "negative secret refresh interval",
+			modify: func(c *config.Config) { c.SecretRefreshInterval = -time.Minute },
+			want:   []string{"secret-refresh-interval"},
+		},
+		{
+			name:   "no candidate workflows",
+			modify: func(c *config.Config) { c.CandidateWorkflows = map[string]int{"cx22": 0} },
+			want:   []string{"candidate-workflows"},
+		},
+		{
+			name:   "negative workflow memory",
+			modify: func(c *config.Config) { c.WorkflowMemory = -1 },
+			want:   []string{"workflow-memory"},
+		},
+		{
+			name: "all errors are reported",
+			modify: func(c *config.Config) {
+				c.MinAgents = -1
+				c.PoolID = ""
+				c.ReconciliationInterval = 0
+			},
+			want: []string{"min-agents must not be negative", "pool-id", "reconciliation-interval"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, config.ErrInvalidConfig)
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestWorkflows(t *testing.T) {
+	c := validConfig()
+	c.CandidateWorkflows = map[string]int{"cx52": 3}
+
+	// nothing to derive the capacity from
+	assert.Equal(t, 2, c.Workflows("cx22", 2, 4096))
+	assert.Equal(t, 3, c.Workflows("cx52", 16, 32768))
+
+	c.WorkflowCPUs = 2
+	c.WorkflowMemory = 4
+	assert.Equal(t, 4, c.Workflows("cx42", 8, 16384))
+	// memory bound
+	assert.Equal(t, 2, c.Workflows("cpx41", 8, 8192))
+	// too small for a single workflow
+	assert.Equal(t, 1, c.Workflows("cx11", 1, 2048))
+	// unknown resources
+	assert.Equal(t, 2, c.Workflows("c3.small.x86", 0, 0))
+	assert.Equal(t, 3, c.Workflows("cx52", 16, 32768))
+}
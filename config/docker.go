@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DockerDaemon are the settings written to the daemon.json of the agents'
+// docker daemon; unset fields keep docker's defaults.
+type DockerDaemon struct {
+	RegistryMirrors     []string      `json:"registry-mirrors,omitempty"`
+	InsecureRegistries  []string      `json:"insecure-registries,omitempty"`
+	MTU                 int           `json:"mtu,omitempty"`
+	DataRoot            string        `json:"data-root,omitempty"`
+	LogDriver           string        `json:"log-driver,omitempty"`
+	LogOpts             LogOpts       `json:"log-opts,omitzero"`
+	DefaultAddressPools []AddressPool `json:"default-address-pools,omitempty"`
+}
+
+// LogOpts configure the rotation of the json-file log driver.
+type LogOpts struct {
+	MaxSize string `json:"max-size,omitempty"`
+	MaxFile string `json:"max-file,omitempty"`
+}
+
+// AddressPool is a range networks of the given prefix size are allocated from.
+type AddressPool struct {
+	Base string `json:"base"`
+	Size int    `json:"size"`
+}
+
+const (
+	minMTU = 68
+	maxMTU = 65535
+)
+
+var logSize = regexp.MustCompile(`^[1-9][0-9]*[kmg]?$`)
+
+// IsZero reports whether no setting is set, so no daemon.json is written.
+func (d DockerDaemon) IsZero() bool {
+	return len(d.RegistryMirrors) == 0 && len(d.InsecureRegistries) == 0 && d.MTU == 0 && d.DataRoot == "" &&
+		d.LogDriver == "" && d.LogOpts == LogOpts{} && len(d.DefaultAddressPools) == 0
+}
+
+// Validate reports every invalid docker daemon setting at once.
+func (d DockerDaemon) Validate() error {
+	var errs []error
+	invalid := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidConfig, fmt.Sprintf(format, a...)))
+	}
+
+	for _, mirror := range d.RegistryMirrors {
+		u, err := url.Parse(mirror)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("docker-registry-mirrors: %q is not an http(s) url", mirror)
+		}
+	}
+	for _, registry := range d.InsecureRegistries {
+		if strings.Contains(registry, "/") {
+			if _, _, err := net.ParseCIDR(registry); err != nil {
+				invalid("docker-insecure-registries: %q is neither host[:port] nor a CIDR", registry)
+			}
+		} else if registry == "" || strings.ContainsAny(registry, " \t") {
+			invalid("docker-insecure-registries: %q is neither host[:port] nor a CIDR", registry)
+		}
+	}
+	if d.MTU != 0 && (d.MTU < minMTU || d.MTU > maxMTU) {
+		invalid("docker-mtu must be between %d and %d, got %d", minMTU, maxMTU, d.MTU)
+	}
+	if d.DataRoot != "" && (!path.IsAbs(d.DataRoot) || path.Clean(d.DataRoot) == "/") {
+		invalid("docker-data-root must be an absolute path other than /, got %q", d.DataRoot)
+	}
+	if d.LogOpts.MaxSize != "" && !logSize.MatchString(d.LogOpts.MaxSize) {
+		invalid("docker-log-max-size must be a size like 10m, got %q", d.LogOpts.MaxSize)
+	}
+	if d.LogOpts.MaxFile != "" {
+		if d.LogOpts.MaxSize == "" {
+			invalid("docker-log-max-file requires docker-log-max-size")
+		}
+		if n, err := strconv.Atoi(d.LogOpts.MaxFile); err != nil || n < 1 {
+			invalid("docker-log-max-file must be a number greater than 0, got %q", d.LogOpts.MaxFile)
+		}
+	}
+	for _, pool := range d.DefaultAddressPools {
+		_, base, err := net.ParseCIDR(pool.Base)
+		if err != nil {
+			invalid("docker-address-pools: %q is not a CIDR", pool.Base)
+			continue
+		}
+		ones, bits := base.Mask.Size()
+		if pool.Size < ones || pool.Size > bits {
+			invalid("docker-address-pools: size of %s must be between %d and %d, got %d", pool.Base, ones, bits, pool.Size)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+func TestDockerDaemonValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		daemon config.DockerDaemon
+		want   []string
+	}{
+		{
+			name: "valid",
+			daemon: config.DockerDaemon{
+				RegistryMirrors:     []string{"https://mirror.example.com"},
+				InsecureRegistries:  []string{"registry.local:5000", "10.0.0.0/8"},
+				MTU:                 1400,
+				DataRoot:            "/mnt/docker",
+				LogOpts:             config.LogOpts{MaxSize: "10m", MaxFile: "3"},
+				DefaultAddressPools: []config.AddressPool{{Base: "10.10.0.0/16", Size: 24}},
+			},
+		},
+		{
+			name:   "empty",
+			daemon: config.DockerDaemon{},
+		},
+		{
+			name: "invalid",
+			daemon: config.DockerDaemon{
+				RegistryMirrors:     []string{"mirror.example.com"},
+				InsecureRegistries:  []string{"10.0.0.0/33"},
+				MTU:                 10,
+				DataRoot:            "docker",
+				LogOpts:             config.LogOpts{MaxSize: "10 MB", MaxFile: "0"},
+				DefaultAddressPools: []config.AddressPool{{Base: "10.10.0.0/16", Size: 8}, {Base: "10.10.0.0", Size: 24}},
+			},
+			want: []string{
+				`docker-registry-mirrors: "mirror.example.com" is not an http(s) url`,
+				`docker-insecure-registries: "10.0.0.0/33" is neither host[:port] nor a CIDR`,
+				"docker-mtu must be between 68 and 65535, got 10",
+				`docker-data-root must be an absolute path other than /, got "docker"`,
+				`docker-log-max-size must be a size like 10m, got "10 MB"`,
+				`docker-log-max-file must be a number greater than 0, got "0"`,
+				"docker-address-pools: size of 10.10.0.0/16 must be between 16 and 32, got 8",
+				`docker-address-pools: "10.10.0.0" is not a CIDR`,
+			},
+		},
+		{
+			name:   "max-file without max-size",
+			daemon: config.DockerDaemon{LogOpts: config.LogOpts{MaxFile: "3"}},
+			want:   []string{"docker-log-max-file requires docker-log-max-size"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.daemon.Validate()
+
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, config.ErrInvalidConfig)
+			for _, want := range tt.want {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestDockerDaemonIsZero(t *testing.T) {
+	assert.True(t, config.DockerDaemon{}.IsZero())
+	assert.False(t, config.DockerDaemon{MTU: 1400}.IsZero())
+	assert.False(t, config.DockerDaemon{LogOpts: config.LogOpts{MaxSize: "10m"}}.IsZero())
+}
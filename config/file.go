@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown configuration key")
+	ErrInvalidValue = errors.New("invalid configuration value")
+)
+
+// File is a parsed configuration file. Its keys are flag names; nested
+// mappings are sections whose key prefixes the flag names inside them, so
+//
+//	hetznercloud:
+//	  server-type: [cx22:nbg1, cx52:fsn1]
+//
+// sets the hetznercloud-server-type flag.
+type File map[string]any
+
+// Settings are the flag values a configuration file sets, keyed by flag name.
+type Settings map[string][]string
+
+// ReadFile reads and parses a YAML (or JSON) configuration file.
+func ReadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	return ParseFile(data)
+}
+
+// ParseFile parses YAML (or JSON) configuration data.
+func ParseFile(data []byte) (File, error) {
+	f := File{}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse config file: %w", err)
+	}
+
+	return f, nil
+}
+
+// Resolve maps the file onto the given flags. A mapping under a key that is a
+// flag itself becomes a list of key=value pairs (e.g. agent-env), any other
+// mapping is a section. Keys matching neither a flag nor a section are
+// reported together, so a typo does not silently fall back to the default.
+func (f File) Resolve(flags []cli.Flag) (Settings, error) {
+	byName := make(map[string]cli.Flag)
+	for _, flag := range flags {
+		for _, name := range flag.Names() {
+			byName[name] = flag
+		}
+	}
+
+	settings := Settings{}
+	var unknown []string
+	if err := resolveSection("", f, byName, settings, &unknown); err != nil {
+		return nil, err
+	}
+
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, strings.Join(unknown, ", "))
+	}
+
+	return settings, nil
+}
+
+func resolveSection(prefix string, section map[string]any, flags map[string]cli.Flag, settings Settings, unknown *[]string) error {
+	for key, value := range section {
+		name := prefix + key
+
+		if flag, ok := flags[name]; ok {
+			values, err := flagValues(name, flag, value)
+			if err != nil {
+				return err
+			}
+			settings[name] = values
+			continue
+		}
+
+		nested, isMapping := asMapping(value)
+		if isMapping && hasFlagWithPrefix(flags, name+"-") {
+			if err := resolveSection(name+"-", nested, flags, settings, unknown); err != nil {
+				return err
+			}
+			continue
+		}
+
+		*unknown = append(*unknown, name)
+	}
+
+	return nil
+}
+
+func hasFlagWithPrefix(flags map[string]cli.Flag, prefix string) bool {
+	for name := range flags {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// asMapping returns value as mapping. The YAML decoder reuses the File type
+// for nested mappings, so both forms have to be accepted.
+func asMapping(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case File:
+		return v, true
+	case map[string]any:
+		return v, true
+	}
+	return nil, false
+}
+
+func flagValues(name string, flag cli.Flag, value any) ([]string, error) {
+	mapping, isMapping := asMapping(value)
+	list, isList := value.([]any)
+
+	var values []string
+	switch {
+	case value == nil:
+		return nil, nil
+	case isMapping:
+		keys := make([]string, 0, len(mapping))
+		for key := range mapping {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			s, err := scalar(name+"."+key, mapping[key])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, key+"="+s)
+		}
+	case isList:
+		for _, item := range list {
+			s, err := scalar(name, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+	default:
+		s, err := scalar(name, value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+
+	if multi, ok := flag.(cli.DocGenerationMultiValueFlag); !ok || !multi.IsMultiValueFlag() {
+		return nil, fmt.Errorf("%w: %s expects a single value", ErrInvalidValue, name)
+	}
+
+	return values, nil
+}
+
+func scalar(name string, value any) (string, error) {
+	_, isMapping := asMapping(value)
+	_, isList := value.([]any)
+	if isMapping || isList {
+		return "", fmt.Errorf("%w: %s must not be nested", ErrInvalidValue, name)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// Keys returns the flag names the settings cover in sorted order.
+func (s Settings) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Apply sets every flag that was not given on the command line or through its
+// environment variables, so both keep precedence over the file. List values
+// are set as a whole, as setting them one by one would split them on commas.
+func (s Settings) Apply(cmd *cli.Command) error {
+	for _, name := range s.Keys() {
+		if cmd.IsSet(name) {
+			continue
+		}
+
+		values := s[name]
+		if isStringSlice(cmd, name) {
+			values = []string{cli.NewStringSlice(values...).Serialize()}
+		}
+		for _, value := range values {
+			if err := cmd.Set(name, value); err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrInvalidValue, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isStringSlice reports whether the flag of the command is a string slice.
+func isStringSlice(cmd *cli.Command, name string) bool {
+	for _, flag := range cmd.Flags {
+		if slices.Contains(flag.Names(), name) {
+			_, ok := flag.(*cli.StringSliceFlag)
+			return ok
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+func testFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{Name: "max-agents", Value: 10, Sources: cli.EnvVars("TEST_MAX_AGENTS")},
+		&cli.StringFlag{Name: "agent-image", Value: "woodpeckerci/woodpecker-agent:next"},
+		&cli.StringSliceFlag{Name: "agent-env"},
+		&cli.StringFlag{Name: "cloudinit-template"},
+		&cli.StringSliceFlag{Name: "hetznercloud-server-type", Value: []string{"cx11:nbg1"}},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	file, err := config.ParseFile([]byte(`
+max-agents: 3
+agent:
+  image: woodpeckerci/woodpecker-agent:v3
+  env:
+    FOO: bar
+    DEBUG: true
+cloudinit-template: |
+  #cloud-config
+hetznercloud:
+  server-type: [cx22:nbg1, cx52:fsn1]
+`))
+	require.NoError(t, err)
+
+	settings, err := file.Resolve(testFlags())
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Settings{
+		"max-agents":               {"3"},
+		"agent-image":              {"woodpeckerci/woodpecker-agent:v3"},
+		"agent-env":                {"DEBUG=true", "FOO=bar"},
+		"cloudinit-template":       {"#cloud-config\n"},
+		"hetznercloud-server-type": {"cx22:nbg1", "cx52:fsn1"},
+	}, settings)
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "unknown keys are reported together",
+			data:    "max-agent: 3\nagent:\n  imgae: x\n",
+			wantErr: config.ErrUnknownKey,
+			want:    "agent-imgae, max-agent",
+		},
+		{
+			name:    "unknown section",
+			data:    "gce:\n  zone: a\n",
+			wantErr: config.ErrUnknownKey,
+			want:    "gce",
+		},
+		{
+			name:    "list for a single value flag",
+			data:    "max-agents: [1, 2]\n",
+			wantErr: config.ErrInvalidValue,
+			want:    "max-agents",
+		},
+		{
+			name:    "nested list items",
+			data:    "agent-env: [[a]]\n",
+			wantErr: config.ErrInvalidValue,
+			want:    "agent-env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := config.ParseFile([]byte(tt.data))
+			require.NoError(t, err)
+
+			_, err = file.Resolve(testFlags())
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParseFileInvalidYAML(t *testing.T) {
+	_, err := config.ParseFile([]byte("max-agents: [1"))
+	assert.Error(t, err)
+}
+
+func TestApplyKeepsFlagsAndEnvironment(t *testing.T) {
+	t.Setenv("TEST_MAX_AGENTS", "7")
+
+	settings := config.Settings{
+		"max-agents":               {"3"},
+		"agent-image":              {"from-file"},
+		"hetznercloud-server-type": {"cx22:nbg1", "cx52:fsn1"},
+	}
+
+	cmd := &cli.Command{
+		Name:  "autoscaler",
+		Flags: testFlags(),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			require.NoError(t, settings.Apply(cmd))
+
+			assert.Equal(t, 7, cmd.Int("max-agents"))
+			assert.Equal(t, "from-flag", cmd.String("agent-image"))
+			assert.Equal(t, []string{"cx22:nbg1", "cx52:fsn1"}, cmd.StringSlice("hetznercloud-server-type"))
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(t.Context(), []string{"autoscaler", "--agent-image", "from-flag"}))
+}
+
+func TestApplyKeepsCommas(t *testing.T) {
+	file, err := config.ParseFile([]byte(`
+agent-env:
+  WOODPECKER_FILTER_LABELS: "a=1,b=2"
+hetznercloud:
+  server-type: ["cx22:nbg1,fsn1"]
+`))
+	require.NoError(t, err)
+	settings, err := file.Resolve(testFlags())
+	require.NoError(t, err)
+
+	cmd := &cli.Command{
+		Name:  "autoscaler",
+		Flags: testFlags(),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			require.NoError(t, settings.Apply(cmd))
+
+			assert.Equal(t, []string{"WOODPECKER_FILTER_LABELS=a=1,b=2"}, cmd.StringSlice("agent-env"))
+			assert.Equal(t, []string{"cx22:nbg1,fsn1"}, cmd.StringSlice("hetznercloud-server-type"))
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(t.Context(), []string{"autoscaler"}))
+}
+
+func TestApplyInvalidValue(t *testing.T) {
+	cmd := &cli.Command{
+		Name:  "autoscaler",
+		Flags: testFlags(),
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return config.Settings{"max-agents": {"many"}}.Apply(cmd)
+		},
+	}
+
+	err := cmd.Run(t.Context(), []string{"autoscaler"})
+	assert.ErrorIs(t, err, config.ErrInvalidValue)
+}
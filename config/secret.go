@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+var ErrSecret = errors.New("can't resolve secret")
+
+// secretExecTimeout bounds how long an exec: helper may take.
+const secretExecTimeout = 30 * time.Second
+
+// SecretFlag is a string flag holding a credential. Wherever it is set (flag,
+// environment, file or config file), its value may reference the secret
+// instead of containing it:
+//
+//	file:/run/secrets/token   content of the file
+//	env:OTHER_VARIABLE        value of another environment variable
+//	exec:vault read -field=…  standard output of a command run by sh -c
+//
+// ResolveSecrets replaces the references by the secrets they point to.
+type SecretFlag struct {
+	cli.StringFlag
+}
+
+// SecretSources returns the sources of a secret flag: the given environment
+// variables, followed by the file named by the first of them suffixed with
+// _FILE.
+func SecretSources(envVars ...string) cli.ValueSourceChain {
+	chain := cli.EnvVars(envVars...)
+	if len(envVars) > 0 {
+		chain.Chain = append(chain.Chain, cli.File(os.Getenv(envVars[0]+"_FILE")))
+	}
+	return chain
+}
+
+// ResolveSecrets replaces the value of every set secret flag of cmd by the
+// secret it references.
+func ResolveSecrets(ctx context.Context, cmd *cli.Command) error {
+	for _, flag := range cmd.Flags {
+		secret, ok := flag.(*SecretFlag)
+		if !ok || !secret.IsSet() {
+			continue
+		}
+
+		value, err := ResolveSecret(ctx, cmd.String(secret.Name))
+		if err != nil {
+			return fmt.Errorf("%s: %w", secret.Name, err)
+		}
+		if err := cmd.Set(secret.Name, value); err != nil {
+			return fmt.Errorf("%s: %w", secret.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ResolveSecret returns the secret ref points to. A value without a file:,
+// env: or exec: prefix is the secret itself and returned unchanged.
+func ResolveSecret(ctx context.Context, ref string) (string, error) {
+	kind, target, found := strings.Cut(ref, ":")
+	if !found {
+		return ref, nil
+	}
+
+	switch kind {
+	case "file":
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrSecret, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case "env":
+		value, ok := os.LookupEnv(target)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %s is not set", ErrSecret, target)
+		}
+		return value, nil
+
+	case "exec":
+		ctx, cancel := context.WithTimeout(ctx, secretExecTimeout)
+		defer cancel()
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", target)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%w: %s: %w: %s", ErrSecret, target, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	return ref, nil
+}
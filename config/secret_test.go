@@ -0,0 +1,97 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+func TestResolveSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+	t.Setenv("TEST_SECRET", "from-env")
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "plain", want: "plain"},
+		{ref: "file:" + path, want: "from-file"},
+		{ref: "env:TEST_SECRET", want: "from-env"},
+		{ref: "exec:echo from-exec", want: "from-exec"},
+		{ref: "https://keystone:5000", want: "https://keystone:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := config.ResolveSecret(t.Context(), tt.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveSecretErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{name: "missing file", ref: "file:/nonexistent/token", want: "no such file"},
+		{name: "unset variable", ref: "env:TEST_SECRET_UNSET", want: "TEST_SECRET_UNSET is not set"},
+		{name: "failing command", ref: "exec:echo denied >&2; exit 1", want: "denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.ResolveSecret(t.Context(), tt.ref)
+			assert.ErrorIs(t, err, config.ErrSecret)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("TEST_TOKEN", "env:TEST_SECRET")
+	t.Setenv("TEST_SECRET", "s3cr3t")
+
+	cmd := &cli.Command{
+		Flags: []cli.Flag{
+			&config.SecretFlag{StringFlag: cli.StringFlag{Name: "token", Sources: config.SecretSources("TEST_TOKEN")}},
+			&config.SecretFlag{StringFlag: cli.StringFlag{Name: "unset"}},
+			&cli.StringFlag{Name: "image", Value: "env:TEST_SECRET"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			require.NoError(t, config.ResolveSecrets(ctx, cmd))
+			assert.Equal(t, "s3cr3t", cmd.String("token"))
+			assert.Empty(t, cmd.String("unset"))
+			assert.Equal(t, "env:TEST_SECRET", cmd.String("image"))
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(t.Context(), []string{"autoscaler"}))
+}
+
+func TestSecretSourcesReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("from-file"), 0o600))
+	t.Setenv("TEST_TOKEN_FILE", path)
+
+	cmd := &cli.Command{
+		Flags: []cli.Flag{
+			&config.SecretFlag{StringFlag: cli.StringFlag{Name: "token", Sources: config.SecretSources("TEST_TOKEN")}},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			assert.Equal(t, "from-file", cmd.String("token"))
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(t.Context(), []string{"autoscaler"}))
+}
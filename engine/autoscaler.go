@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"slices"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -34,6 +35,19 @@ func NewAutoscaler(p types.Provider, client server.Client, config *config.Config
 	}
 }
 
+// SetProvider replaces the provider and its config, e.g. after a config reload.
+// Subsequent reconciliations use the new ones.
+func (a *Autoscaler) SetProvider(p types.Provider, config *config.Config) {
+	a.provider = p
+	a.config = config
+}
+
+// SetClient replaces the woodpecker server client, e.g. after the server token
+// was rotated.
+func (a *Autoscaler) SetClient(client server.Client) {
+	a.client = client
+}
+
 // inTeardownWindow reports whether the agent is currently within the teardown
 // window before one of its paid-hour boundaries (anchored at its creation
 // time). Agents that have not reported a creation time are never in the window.
@@ -88,7 +102,19 @@ func (a *Autoscaler) getPoolAgents(excludeNoSchedule bool) []*woodpecker.Agent {
 	return agents
 }
 
-func (a *Autoscaler) createAgents(ctx context.Context, amount int) error {
+// agentCapacity returns how many workflows the agent runs in parallel: as it
+// reported or as recorded on deploy, else WorkflowsPerAgent.
+func (a *Autoscaler) agentCapacity(agent *woodpecker.Agent) int {
+	if agent.Capacity > 0 {
+		return int(agent.Capacity)
+	}
+	return a.config.WorkflowsPerAgent
+}
+
+// createAgents deploys up to amount agents for the missing workflows. Deploy
+// candidates can differ in capacity, so it sums the capacity each agent got and
+// stops once the workflows are covered and min-agents are running.
+func (a *Autoscaler) createAgents(ctx context.Context, amount, workflows int) error {
 	suffixLength := 4
 
 	reactivatedAgents := 0
@@ -104,12 +130,17 @@ func (a *Autoscaler) createAgents(ctx context.Context, amount int) error {
 					return fmt.Errorf("client.AgentUpdate: %w", err)
 				}
 				reactivatedAgents++
+				workflows -= a.agentCapacity(agent)
 			}
 		}
 	}
 
 	// create new agents
 	for i := 0; i < amount-reactivatedAgents; i++ {
+		if workflows <= 0 && len(a.getPoolAgents(true)) >= a.config.MinAgents {
+			break
+		}
+
 		agent, err := a.client.AgentCreate(&woodpecker.Agent{
 			Name: fmt.Sprintf("pool-%s-agent-%s", a.config.PoolID, utils.RandomString(suffixLength)),
 		})
@@ -123,6 +154,7 @@ func (a *Autoscaler) createAgents(ctx context.Context, amount int) error {
 		if err != nil {
 			return fmt.Errorf("types.DeployAgent: %w", err)
 		}
+		workflows -= a.agentCapacity(agent)
 
 		a.agents = append(a.agents, agent)
 	}
@@ -130,39 +162,57 @@ func (a *Autoscaler) createAgents(ctx context.Context, amount int) error {
 	return nil
 }
 
-func (a *Autoscaler) drainAgents(_ context.Context, amount int) error {
-	for i := 0; i < amount; i++ {
-		for _, agent := range a.agents {
-			// agent is already marked for draining
-			if agent.NoSchedule {
-				continue
-			}
+// drainAgents drains up to amount agents whose capacity fits in the surplus
+// of free workflow slots, smallest first like calcAgents counted them. Agents
+// above max-agents are drained regardless of the surplus.
+func (a *Autoscaler) drainAgents(_ context.Context, amount, surplus int) error {
+	excess := len(a.getPoolAgents(true)) - a.config.MaxAgents
 
-			// agent has never contacted the server => not ready for draining
-			if agent.LastContact == 0 {
-				continue
-			}
+	agents := make([]*woodpecker.Agent, 0)
+	for _, agent := range a.agents {
+		// agent is already marked for draining
+		if agent.NoSchedule {
+			continue
+		}
 
-			if a.config.BillingModel == types.BillingHourlyRoundUp {
-				// hourly-round-up: the hour is already paid for, so keep the
-				// agent schedulable until just before its hour boundary even
-				// while idle, then drain it inside the teardown window.
-				if !a.inTeardownWindow(agent) {
-					continue
-				}
-			} else if time.Since(time.Unix(agent.LastWork, 0)) < a.config.AgentIdleTimeout {
-				// agent has recently done work => not ready for draining
+		// agent has never contacted the server => not ready for draining
+		if agent.LastContact == 0 {
+			continue
+		}
+
+		if a.config.BillingModel == types.BillingHourlyRoundUp {
+			// hourly-round-up: the hour is already paid for, so keep the
+			// agent schedulable until just before its hour boundary even
+			// while idle, then drain it inside the teardown window.
+			if !a.inTeardownWindow(agent) {
 				continue
 			}
+		} else if time.Since(time.Unix(agent.LastWork, 0)) < a.config.AgentIdleTimeout {
+			// agent has recently done work => not ready for draining
+			continue
+		}
 
-			log.Info().Str("agent", agent.Name).Msg("drain agent")
-			agent.NoSchedule = true
-			_, err := a.client.AgentUpdate(agent)
-			if err != nil {
-				return fmt.Errorf("client.AgentUpdate: %w", err)
-			}
+		agents = append(agents, agent)
+	}
+	slices.SortStableFunc(agents, func(x, y *woodpecker.Agent) int {
+		return a.agentCapacity(x) - a.agentCapacity(y)
+	})
+
+	for _, agent := range agents {
+		capacity := a.agentCapacity(agent)
+		if amount <= 0 || (capacity > surplus && excess <= 0) {
 			break
 		}
+
+		log.Info().Str("agent", agent.Name).Msg("drain agent")
+		agent.NoSchedule = true
+		_, err := a.client.AgentUpdate(agent)
+		if err != nil {
+			return fmt.Errorf("client.AgentUpdate: %w", err)
+		}
+		amount--
+		excess--
+		surplus -= capacity
 	}
 
 	return nil
@@ -347,27 +397,52 @@ func (a *Autoscaler) getQueueInfo(_ context.Context) (freeTasks, runningTasks, p
 	return queueInfo.Stats.Workers, queueInfo.Stats.Running, queueInfo.Stats.Pending, nil
 }
 
-func (a *Autoscaler) calcAgents(ctx context.Context) (float64, error) {
+// calcAgents returns how many agents to add (or drain if negative) and how
+// many workflows find no free slot (or how many slots are left over if
+// negative).
+func (a *Autoscaler) calcAgents(ctx context.Context) (float64, int, error) {
 	freeTasks, runningTasks, pendingTasks, err := a.getQueueInfo(ctx)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	log.Debug().Msgf("queue info: freeTasks = %v runningTasks = %v pendingTasks = %v", freeTasks, runningTasks, pendingTasks)
-	availableAgents := math.Ceil(float64(freeTasks+runningTasks) / float64(a.config.WorkflowsPerAgent))
-	reqAgents := math.Ceil(float64(pendingTasks+runningTasks) / float64(a.config.WorkflowsPerAgent))
 
-	availablePoolAgents := len(a.getPoolAgents(true))
+	poolAgents := a.getPoolAgents(true)
+	availablePoolAgents := len(poolAgents)
 	maxUp := float64(a.config.MaxAgents - availablePoolAgents)
 	maxDown := float64(availablePoolAgents - a.config.MinAgents)
 
-	reqPoolAgents := math.Ceil(reqAgents - availableAgents)
+	// workflows that find no free slot, negative if slots are left over
+	missingWorkflows := pendingTasks - freeTasks
+
+	var reqPoolAgents float64
+	if missingWorkflows > 0 {
+		// new agents are estimated to run workflows-per-agent, createAgents
+		// sums the capacity they really get and stops early if it is more
+		reqPoolAgents = math.Ceil(float64(missingWorkflows) / float64(a.config.WorkflowsPerAgent))
+	} else {
+		// agents whose slots are all left over, smallest first
+		capacities := make([]int, 0, len(poolAgents))
+		for _, agent := range poolAgents {
+			capacities = append(capacities, a.agentCapacity(agent))
+		}
+		slices.Sort(capacities)
+		surplus := -missingWorkflows
+		for _, capacity := range capacities {
+			if capacity > surplus {
+				break
+			}
+			surplus -= capacity
+			reqPoolAgents--
+		}
+	}
 	reqPoolAgents = math.Max(reqPoolAgents, -maxDown)
 	reqPoolAgents = math.Min(reqPoolAgents, maxUp)
 
-	log.Debug().Msgf("capacity info: agents = %v/%v pool = %v/%v limits = %v/%v", availableAgents, reqAgents, availablePoolAgents, reqPoolAgents, maxUp, maxDown)
+	log.Debug().Msgf("capacity info: workflows = %v pool = %v/%v limits = %v/%v", missingWorkflows, availablePoolAgents, reqPoolAgents, maxUp, maxDown)
 
-	return reqPoolAgents, nil
+	return reqPoolAgents, missingWorkflows, nil
 }
 
 // Reconcile periodically checks the status of the agent pool and adjusts it to match
@@ -377,7 +452,7 @@ func (a *Autoscaler) Reconcile(ctx context.Context) error {
 		return fmt.Errorf("loading agents failed: %w", err)
 	}
 
-	reqPoolAgents, err := a.calcAgents(ctx)
+	reqPoolAgents, missingWorkflows, err := a.calcAgents(ctx)
 	if err != nil {
 		return fmt.Errorf("calculating agents failed: %w", err)
 	}
@@ -386,7 +461,7 @@ func (a *Autoscaler) Reconcile(ctx context.Context) error {
 		num := int(math.Abs(reqPoolAgents))
 		log.Debug().Msgf("starting %d additional agents", num)
 
-		if err := a.createAgents(ctx, num); err != nil {
+		if err := a.createAgents(ctx, num, missingWorkflows); err != nil {
 			return fmt.Errorf("creating agents failed: %w", err)
 		}
 	}
@@ -395,7 +470,7 @@ func (a *Autoscaler) Reconcile(ctx context.Context) error {
 		num := int(math.Abs(reqPoolAgents))
 
 		log.Debug().Msgf("checking %d agents if ready for draining", num)
-		if err := a.drainAgents(ctx, num); err != nil {
+		if err := a.drainAgents(ctx, num, -missingWorkflows); err != nil {
 			return fmt.Errorf("draining agents failed: %w", err)
 		}
 	}
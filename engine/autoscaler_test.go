@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -61,7 +62,7 @@ func Test_calcAgents(t *testing.T) {
 			MinAgents:         1,
 		}}
 
-		value, _ := autoscaler.calcAgents(t.Context())
+		value, _, _ := autoscaler.calcAgents(t.Context())
 		assert.Equal(t, float64(1), value)
 	})
 
@@ -73,7 +74,7 @@ func Test_calcAgents(t *testing.T) {
 			MaxAgents:         3,
 		}}
 
-		value, _ := autoscaler.calcAgents(t.Context())
+		value, _, _ := autoscaler.calcAgents(t.Context())
 		assert.Equal(t, float64(1), value)
 	})
 
@@ -85,7 +86,7 @@ func Test_calcAgents(t *testing.T) {
 			MaxAgents:         3,
 		}}
 
-		value, _ := autoscaler.calcAgents(t.Context())
+		value, _, _ := autoscaler.calcAgents(t.Context())
 		assert.Equal(t, float64(2), value)
 	})
 
@@ -99,7 +100,7 @@ func Test_calcAgents(t *testing.T) {
 			{Name: "pool-1-agent-1234"},
 		}}
 
-		value, _ := autoscaler.calcAgents(t.Context())
+		value, _, _ := autoscaler.calcAgents(t.Context())
 		assert.Equal(t, float64(1), value)
 	})
 
@@ -112,7 +113,7 @@ func Test_calcAgents(t *testing.T) {
 			MaxAgents:         2,
 		}}
 
-		value, _ := autoscaler.calcAgents(t.Context())
+		value, _, _ := autoscaler.calcAgents(t.Context())
 		assert.Equal(t, float64(0), value)
 	})
 
@@ -134,9 +135,41 @@ func Test_calcAgents(t *testing.T) {
 			{Name: "pool-1-agent-3333"},
 		}}
 
-		value, _ := autoscaler.calcAgents(t.Context())
+		value, _, _ := autoscaler.calcAgents(t.Context())
 		assert.Equal(t, float64(2), value)
 	})
+
+	t.Run("should return the missing workflows", func(t *testing.T) {
+		autoscaler := Autoscaler{client: &MockClient{
+			workers: 1,
+			pending: 9,
+		}, config: &config.Config{
+			WorkflowsPerAgent: 4,
+			MaxAgents:         10,
+		}}
+
+		value, workflows, _ := autoscaler.calcAgents(t.Context())
+		assert.Equal(t, float64(2), value)
+		assert.Equal(t, 8, workflows)
+	})
+
+	t.Run("should drain agents by their capacity", func(t *testing.T) {
+		// 5 free slots fit the agents with 1 and 2 workflows, but not the one with 4
+		autoscaler := Autoscaler{client: &MockClient{
+			workers: 5,
+			running: 2,
+		}, config: &config.Config{
+			WorkflowsPerAgent: 1,
+			MaxAgents:         10,
+		}, agents: []*woodpecker.Agent{
+			{Name: "pool-1-agent-1111", Capacity: 4},
+			{Name: "pool-1-agent-2222", Capacity: 2},
+			{Name: "pool-1-agent-3333", Capacity: 1},
+		}}
+
+		value, _, _ := autoscaler.calcAgents(t.Context())
+		assert.Equal(t, float64(-2), value)
+	})
 }
 
 func Test_getQueueInfo(t *testing.T) {
@@ -190,10 +223,56 @@ func Test_createAgents(t *testing.T) {
 		client.On("AgentCreate", mock.Anything).Return(&woodpecker.Agent{Name: "pool-1-agent-1"}, nil)
 		provider.On("DeployAgent", ctx, mock.Anything).Return(nil)
 
-		err := autoscaler.createAgents(ctx, 1)
+		err := autoscaler.createAgents(ctx, 1, 1)
 		assert.NoError(t, err)
 	})
 
+	t.Run("should stop once the deployed capacity covers the workflows", func(t *testing.T) {
+		ctx := t.Context()
+		client := mocks_server.NewMockClient(t)
+		provider := mocks_provider.NewMockProvider(t)
+		autoscaler := Autoscaler{
+			client:   client,
+			provider: provider,
+			config: &config.Config{
+				PoolID:            "1",
+				WorkflowsPerAgent: 1,
+			},
+		}
+
+		client.On("AgentCreate", mock.Anything).Return(&woodpecker.Agent{Name: "pool-1-agent-1"}, nil).Twice()
+		provider.On("DeployAgent", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*woodpecker.Agent).Capacity = 4
+		}).Return(nil).Twice()
+
+		// a fallback candidate runs 4 workflows instead of the estimated 1
+		err := autoscaler.createAgents(ctx, 6, 6)
+		assert.NoError(t, err)
+		assert.Len(t, autoscaler.agents, 2)
+	})
+
+	t.Run("should create agents for min-agents without workflows", func(t *testing.T) {
+		ctx := t.Context()
+		client := mocks_server.NewMockClient(t)
+		provider := mocks_provider.NewMockProvider(t)
+		autoscaler := Autoscaler{
+			client:   client,
+			provider: provider,
+			config: &config.Config{
+				PoolID:            "1",
+				WorkflowsPerAgent: 1,
+				MinAgents:         1,
+			},
+		}
+
+		client.On("AgentCreate", mock.Anything).Return(&woodpecker.Agent{Name: "pool-1-agent-1"}, nil).Once()
+		provider.On("DeployAgent", ctx, mock.Anything).Return(nil).Once()
+
+		err := autoscaler.createAgents(ctx, 1, 0)
+		assert.NoError(t, err)
+		assert.Len(t, autoscaler.agents, 1)
+	})
+
 	t.Run("should reuse an no-schedule agent first before creating a new one", func(t *testing.T) {
 		ctx := t.Context()
 		client := mocks_server.NewMockClient(t)
@@ -218,11 +297,31 @@ func Test_createAgents(t *testing.T) {
 		client.On("AgentCreate", mock.Anything).Return(&woodpecker.Agent{Name: "pool-1-agent-1"}, nil)
 		provider.On("DeployAgent", ctx, mock.Anything).Return(nil)
 
-		err := autoscaler.createAgents(ctx, 2)
+		err := autoscaler.createAgents(ctx, 2, 2)
 		assert.NoError(t, err)
 	})
 }
 
+func Test_SetProvider(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+
+	ctx := t.Context()
+	client := mocks_server.NewMockClient(t)
+	previous := mocks_provider.NewMockProvider(t)
+	provider := mocks_provider.NewMockProvider(t)
+	autoscaler := NewAutoscaler(previous, client, &config.Config{PoolID: "1"})
+
+	autoscaler.SetProvider(provider, &config.Config{PoolID: "2"})
+
+	client.On("AgentCreate", mock.MatchedBy(func(agent *woodpecker.Agent) bool {
+		return strings.HasPrefix(agent.Name, "pool-2-agent-")
+	})).Return(&woodpecker.Agent{Name: "pool-2-agent-1"}, nil)
+	provider.On("DeployAgent", ctx, mock.Anything).Return(nil)
+
+	err := autoscaler.createAgents(ctx, 1, 1)
+	assert.NoError(t, err)
+}
+
 func Test_cleanupDanglingAgents(t *testing.T) {
 	t.Run("should remove agent that is only present on woodpecker (not provider)", func(t *testing.T) {
 		ctx := t.Context()
@@ -432,12 +531,43 @@ func Test_drainAgents(t *testing.T) {
 			return (agent.ID == 1 || agent.ID == 4) && agent.NoSchedule == true
 		})).Return(nil, nil)
 
-		err := autoscaler.drainAgents(ctx, 2)
+		err := autoscaler.drainAgents(ctx, 2, 2)
 		assert.NoError(t, err)
 		assert.True(t, autoscaler.agents[0].NoSchedule)
 		assert.True(t, autoscaler.agents[3].NoSchedule)
 	})
 
+	t.Run("should drain the smallest agents fitting the surplus", func(t *testing.T) {
+		ctx := t.Context()
+		client := mocks_server.NewMockClient(t)
+		provider := mocks_provider.NewMockProvider(t)
+		lastContact := time.Now().Add(-time.Minute * 2).Unix()
+		autoscaler := Autoscaler{
+			agents: []*woodpecker.Agent{
+				{ID: 1, Name: "pool-1-agent-1", Capacity: 4, LastContact: lastContact},
+				{ID: 2, Name: "pool-1-agent-2", Capacity: 2, LastContact: lastContact},
+				{ID: 3, Name: "pool-1-agent-3", Capacity: 1, LastContact: lastContact},
+			},
+			provider: provider,
+			client:   client,
+			config: &config.Config{
+				MaxAgents:        10,
+				AgentIdleTimeout: time.Minute * 15,
+			},
+		}
+
+		client.On("AgentUpdate", mock.MatchedBy(func(agent *woodpecker.Agent) bool {
+			return (agent.ID == 2 || agent.ID == 3) && agent.NoSchedule
+		})).Return(nil, nil)
+
+		// 5 free slots fit the agents with 1 and 2 workflows, but not the one with 4
+		err := autoscaler.drainAgents(ctx, 3, 5)
+		assert.NoError(t, err)
+		assert.False(t, autoscaler.agents[0].NoSchedule)
+		assert.True(t, autoscaler.agents[1].NoSchedule)
+		assert.True(t, autoscaler.agents[2].NoSchedule)
+	})
+
 	t.Run("should not remove an agent that never connected", func(t *testing.T) {
 		ctx := t.Context()
 		client := mocks_server.NewMockClient(t)
@@ -453,7 +583,7 @@ func Test_drainAgents(t *testing.T) {
 			},
 		}
 
-		err := autoscaler.drainAgents(ctx, 1)
+		err := autoscaler.drainAgents(ctx, 1, 1)
 		assert.NoError(t, err)
 		assert.False(t, autoscaler.agents[0].NoSchedule)
 	})
@@ -479,7 +609,7 @@ func Test_drainAgents(t *testing.T) {
 			},
 		}
 
-		err := autoscaler.drainAgents(ctx, 1)
+		err := autoscaler.drainAgents(ctx, 1, 1)
 		assert.NoError(t, err)
 		assert.False(t, autoscaler.agents[0].NoSchedule)
 	})
@@ -605,7 +735,7 @@ func Test_drainAgents_hourlyRoundUp(t *testing.T) {
 			return agent.ID == 2 && agent.NoSchedule
 		})).Return(nil, nil)
 
-		err := autoscaler.drainAgents(ctx, 2)
+		err := autoscaler.drainAgents(ctx, 2, 2)
 		assert.NoError(t, err)
 		assert.False(t, autoscaler.agents[0].NoSchedule)
 		assert.True(t, autoscaler.agents[1].NoSchedule)
@@ -3,9 +3,13 @@ package cloudinit
 import (
 	"bytes"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"text/template"
 
+	"github.com/rs/zerolog/log"
+
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
 )
@@ -13,48 +17,140 @@ import (
 type RenderOption struct {
 	PreExec  []string
 	PostExec []string
+	// OSFamily of the image, as detected by the provider. It selects the
+	// built-in template, unless the config overrides it.
+	OSFamily OSFamily
+	// Provider is the name of the provider deploying the agent.
+	Provider string
+	// Candidate is what the provider deploys the agent on.
+	Candidate Candidate
+}
+
+// Candidate describes the machine an agent gets deployed on. Fields the
+// provider does not know are empty.
+type Candidate struct {
+	// InstanceType is the provider specific instance type, size or plan.
+	InstanceType string
+	// Region is the region, zone or location.
+	Region string
+	// Arch is the CPU architecture in GOARCH notation (amd64, arm64).
+	Arch string
+	// Spot is set for spot (preemptible) instances.
+	Spot bool
+	// Workflows is how many workflows the agent runs in parallel, see
+	// config.Config.Workflows. 0 falls back to WorkflowsPerAgent.
+	Workflows int
+}
+
+// NormalizeArch maps the architecture names providers use to GOARCH notation.
+func NormalizeArch(arch string) string {
+	switch arch = strings.ToLower(arch); arch {
+	case "x86_64", "x86":
+		return "amd64"
+	case "aarch64", "arm":
+		return "arm64"
+	}
+	return arch
+}
+
+// TemplateContext is the data user data templates are executed with.
+type TemplateContext struct {
+	// Image is the agent container image.
+	Image string
+	// Environment of the agent container.
+	Environment map[string]string
+	// Bootstrap is set if the agent fetches its token at boot, see
+	// Bootstrap.
+	Bootstrap *Bootstrap
+	// PreExec commands run before, PostExec commands after the agent was
+	// started.
+	PreExec  []string
+	PostExec []string
+	// AgentName is the name the agent is registered with at the server.
+	AgentName string
+	// PoolID is the ID of the autoscaler pool.
+	PoolID string
+	// Provider is the name of the provider deploying the agent.
+	Provider string
+	// Candidate is what the provider deploys the agent on.
+	Candidate Candidate
+	// Labels are the agent labels as passed in WOODPECKER_AGENT_LABELS, see
+	// AgentLabels.
+	Labels map[string]string
+	// InstallMode is how the agent is run, see InstallModes.
+	InstallMode string
+	// Binary is the agent release to install in the binary install mode.
+	Binary config.AgentBinary
 }
 
 // RenderUserDataTemplate renders the user data template for an Agent
-// using the provided configuration.
+// using the provided configuration. Extra parts are rendered with the same
+// context and assembled with it, and the daemon.json of the docker settings,
+// into a multipart document.
 func RenderUserDataTemplate(config *config.Config, agent *woodpecker.Agent, r RenderOption) (string, error) {
-	var err error
-	var tmpl *template.Template
+	text := config.UserData
+	if text == "" {
+		text = builtinTemplate(config, r)
+	}
 
-	if config.UserData != "" {
-		tmpl, err = template.New("user-data").Parse(config.UserData)
-	} else {
-		tmpl, err = template.New("user-data").Parse(CloudInitUserDataUbuntuDefault)
+	environment, bootstrap, err := AgentEnvironment(config, agent, r)
+	if err != nil {
+		return "", err
 	}
+
+	params := TemplateContext{
+		Image:       config.Image,
+		Environment: environment,
+		Bootstrap:   bootstrap,
+		PreExec:     r.PreExec,
+		PostExec:    r.PostExec,
+		AgentName:   agent.Name,
+		PoolID:      config.PoolID,
+		Provider:    r.Provider,
+		Candidate:   r.Candidate,
+		Labels:      AgentLabels(config, r),
+		InstallMode: config.InstallMode,
+		Binary:      config.AgentBinary,
+	}
+
+	userData, err := render("user-data", text, params)
 	if err != nil {
-		return "", fmt.Errorf("template.New.Parse %w", err)
+		return "", err
 	}
 
-	params := struct {
-		Image       string
-		Environment map[string]string
-		PreExec     []string
-		PostExec    []string
-	}{
-		Image: config.Image,
-		Environment: map[string]string{
-			"WOODPECKER_SERVER":        config.GRPCAddress,
-			"WOODPECKER_AGENT_SECRET":  agent.Token,
-			"WOODPECKER_MAX_WORKFLOWS": fmt.Sprintf("%d", config.WorkflowsPerAgent),
-		},
-		PreExec:  r.PreExec,
-		PostExec: r.PostExec,
+	parts := []string{userData}
+
+	daemon, err := DockerDaemonJSON(config)
+	if err != nil {
+		return "", err
+	}
+	if daemon != nil {
+		part, err := dockerDaemonPart(daemon)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
 	}
 
-	if config.GRPCSecure {
-		params.Environment["WOODPECKER_GRPC_SECURE"] = "true"
+	for i, part := range config.UserDataParts {
+		rendered, err := render(fmt.Sprintf("part-%d", i+1), part, params)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, rendered)
 	}
 
-	for key, value := range config.Environment {
-		params.Environment[key] = value
+	if len(parts) == 1 {
+		return userData, nil
 	}
+	return Multipart(parts, config.UserDataMergeHow)
+}
 
-	params.Environment["WOODPECKER_AGENT_LABELS"] = genExtraAgentLabels(config.ExtraAgentLabels)
+func render(name, text string, params TemplateContext) (string, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("template.New.Parse %w", err)
+	}
 
 	var userData bytes.Buffer
 	if err := tmpl.Execute(&userData, params); err != nil {
@@ -67,10 +163,91 @@ func RenderUserDataTemplate(config *config.Config, agent *woodpecker.Agent, r Re
 	return strings.TrimSpace(userData.String()), nil
 }
 
+// AgentEnvironment returns the environment of the agent container. With a
+// bootstrap server configured, it does not contain the agent token but the
+// returned Bootstrap describes how to fetch it.
+func AgentEnvironment(config *config.Config, agent *woodpecker.Agent, r RenderOption) (map[string]string, *Bootstrap, error) {
+	environment := map[string]string{
+		"WOODPECKER_SERVER":        config.GRPCAddress,
+		"WOODPECKER_MAX_WORKFLOWS": fmt.Sprintf("%d", MaxWorkflows(config, r)),
+	}
+
+	// with a bootstrap server the token is fetched at boot, the user data
+	// only carries a nonce that is worthless once spent
+	var bootstrap *Bootstrap
+	if config.Bootstrap != nil {
+		nonce, err := config.Bootstrap.Issue(agent.Name, agent.Token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bootstrap.Issue: %w", err)
+		}
+		bootstrap = &Bootstrap{
+			URL:   config.Bootstrap.URL(),
+			Agent: agent.Name,
+			Nonce: nonce,
+		}
+	} else {
+		environment["WOODPECKER_AGENT_SECRET"] = agent.Token
+	}
+
+	if config.GRPCSecure {
+		environment["WOODPECKER_GRPC_SECURE"] = "true"
+	}
+
+	for key, value := range config.Environment {
+		environment[key] = value
+	}
+
+	environment["WOODPECKER_AGENT_LABELS"] = genExtraAgentLabels(AgentLabels(config, r))
+
+	return environment, bootstrap, nil
+}
+
+// MaxWorkflows returns how many workflows the agent runs in parallel, the
+// capacity of the candidate or else WorkflowsPerAgent.
+func MaxWorkflows(config *config.Config, r RenderOption) int {
+	if r.Candidate.Workflows > 0 {
+		return r.Candidate.Workflows
+	}
+	return config.WorkflowsPerAgent
+}
+
+// Bootstrap is what an instance needs to fetch its agent token at boot: POST
+// the form values agent and nonce to URL, the response is the token.
+type Bootstrap struct {
+	URL   string
+	Agent string
+	Nonce string
+}
+
+// builtinTemplate returns the built-in template of the install mode. Agents
+// run with docker use the template of the configured OS family, falling back
+// to the detected one and then to Ubuntu.
+func builtinTemplate(config *config.Config, r RenderOption) string {
+	switch config.InstallMode {
+	case InstallModePodman:
+		return CloudInitUserDataPodman
+	case InstallModeBinary:
+		return CloudInitUserDataBinary
+	}
+
+	family := r.OSFamily
+	if config.OSFamily != "" {
+		family = OSFamily(config.OSFamily)
+	}
+
+	if tmpl, ok := Templates[family]; ok {
+		return tmpl
+	}
+	if family != "" {
+		log.Debug().Str("os-family", string(family)).Msg("no built-in cloudinit template for os family, using ubuntu")
+	}
+	return CloudInitUserDataUbuntuDefault
+}
+
 func genExtraAgentLabels(conf map[string]string) string {
 	out := make([]string, 0, len(conf))
-	for k, v := range conf {
-		out = append(out, fmt.Sprintf("%s=%s", k, v))
+	for _, k := range slices.Sorted(maps.Keys(conf)) {
+		out = append(out, fmt.Sprintf("%s=%s", k, conf[k]))
 	}
 	return strings.Join(out, ",")
 }
@@ -113,6 +290,10 @@ write_files:
         restart: always
         volumes:
           - /var/run/docker.sock:/var/run/docker.sock
+        {{- if .Bootstrap }}
+        env_file:
+          - agent.env
+        {{- end }}
         environment:
           {{- range $key, $value := .Environment }}
           - {{ $key }}={{ $value }}
@@ -122,6 +303,9 @@ runcmd:
   {{- range .PreExec }}
   - {{ . }}
   {{- end }}
+  {{- with .Bootstrap }}
+  - sh -ec 'umask 077; token=$(curl -fsS --retry 10 --retry-connrefused -d agent={{ .Agent }} -d nonce={{ .Nonce }} {{ .URL }}); echo "WOODPECKER_AGENT_SECRET=$token" > /root/agent.env'
+  {{- end }}
   - sh -xc "cd /root; docker compose up -d"
   {{- range .PostExec }}
   - {{ . }}
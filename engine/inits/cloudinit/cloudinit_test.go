@@ -148,3 +148,231 @@ runcmd:
 final_message: "The system is finally up, after $UPTIME seconds"`, conf)
 	// editorconfig-checker-enable
 }
+
+type testBootstrap struct {
+	agent, token string
+}
+
+func (b *testBootstrap) Issue(agent, token string) (string, error) {
+	b.agent, b.token = agent, token
+	return "test-nonce", nil
+}
+
+func (b *testBootstrap) URL() string {
+	return "https://autoscaler.example.com/bootstrap"
+}
+
+func TestRenderUserDataTemplate_Bootstrap(t *testing.T) {
+	bootstrap := &testBootstrap{}
+	config := &config.Config{
+		Image:     "test-image",
+		Bootstrap: bootstrap,
+	}
+	agent := &woodpecker.Agent{
+		Name:  "pool-1-agent-abcd",
+		Token: "test-token",
+	}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, agent, cloudinit.RenderOption{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pool-1-agent-abcd", bootstrap.agent)
+	assert.Equal(t, "test-token", bootstrap.token)
+	assert.NotContains(t, userData, "test-token")
+	assert.Contains(t, userData, "-d agent=pool-1-agent-abcd -d nonce=test-nonce https://autoscaler.example.com/bootstrap")
+	assert.Contains(t, userData, "env_file:")
+}
+
+func TestDetectOSFamily(t *testing.T) {
+	tests := []struct {
+		metadata string
+		want     cloudinit.OSFamily
+	}{
+		{metadata: "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-20250115", want: cloudinit.OSFamilyUbuntu},
+		{metadata: "debian-12-amd64-20250112-1991", want: cloudinit.OSFamilyDebian},
+		{metadata: "al2023-ami-2023.6.20250115.0-kernel-6.1-x86_64", want: cloudinit.OSFamilyAmazon},
+		{metadata: "suse-sles-15-sp6-v20250110-hvm-ssd-x86_64", want: cloudinit.OSFamilySUSE},
+		{metadata: "rocky", want: cloudinit.OSFamilyRHEL},
+		{metadata: "AlmaLinux", want: cloudinit.OSFamilyRHEL},
+		{metadata: "CentOS", want: cloudinit.OSFamilyRHEL},
+		{metadata: "Fedora", want: cloudinit.OSFamilyFedora},
+		{metadata: "unknown", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metadata, func(t *testing.T) {
+			assert.Equal(t, tt.want, cloudinit.DetectOSFamily(tt.metadata))
+		})
+	}
+}
+
+func TestRenderUserDataTemplate_OSFamily(t *testing.T) {
+	agent := &woodpecker.Agent{Token: "test-token"}
+
+	for _, family := range cloudinit.OSFamilies() {
+		t.Run(family, func(t *testing.T) {
+			userData, err := cloudinit.RenderUserDataTemplate(&config.Config{Image: "test-image"}, agent, cloudinit.RenderOption{
+				OSFamily: cloudinit.OSFamily(family),
+			})
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(userData, "#cloud-config\n"))
+			assert.Contains(t, userData, "test-image")
+			assert.Contains(t, userData, "WOODPECKER_AGENT_SECRET=test-token")
+		})
+	}
+
+	t.Run("detected family", func(t *testing.T) {
+		userData, err := cloudinit.RenderUserDataTemplate(&config.Config{}, agent, cloudinit.RenderOption{
+			OSFamily: cloudinit.OSFamilyFedora,
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, userData, "https://download.docker.com/linux/fedora/")
+	})
+
+	t.Run("config overrides detected family", func(t *testing.T) {
+		userData, err := cloudinit.RenderUserDataTemplate(&config.Config{OSFamily: "debian"}, agent, cloudinit.RenderOption{
+			OSFamily: cloudinit.OSFamilyFedora,
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, userData, "https://download.docker.com/linux/debian")
+	})
+
+	t.Run("unknown family falls back to ubuntu", func(t *testing.T) {
+		userData, err := cloudinit.RenderUserDataTemplate(&config.Config{}, agent, cloudinit.RenderOption{
+			OSFamily: "plan9",
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, userData, "https://download.docker.com/linux/ubuntu")
+	})
+}
+
+func TestRenderUserDataTemplate_Context(t *testing.T) {
+	config := &config.Config{
+		PoolID:           "1",
+		ExtraAgentLabels: map[string]string{"gpu": "true"},
+		UserData: `agent: {{ .AgentName }}
+pool: {{ .PoolID }}
+provider: {{ .Provider }}
+candidate: {{ .Candidate.InstanceType }}/{{ .Candidate.Region }}/{{ .Candidate.Arch }}
+gpu: {{ index .Labels "gpu" }}`,
+	}
+	agent := &woodpecker.Agent{Name: "pool-1-agent-abcd"}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, agent, cloudinit.RenderOption{
+		Provider: "hetznercloud",
+		Candidate: cloudinit.Candidate{
+			InstanceType: "cax11",
+			Region:       "fsn1",
+			Arch:         cloudinit.NormalizeArch("aarch64"),
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `agent: pool-1-agent-abcd
+pool: 1
+provider: hetznercloud
+candidate: cax11/fsn1/arm64
+gpu: true`, userData)
+}
+
+func TestRenderUserDataTemplate_Funcs(t *testing.T) {
+	t.Setenv("TEST_REGISTRY_MIRROR", "https://mirror.example.com")
+
+	tests := []struct {
+		name     string
+		template string
+		region   string
+		want     string
+	}{
+		{"yamlQuote", `{{ yamlQuote "a: \"b\"" }}`, "", `"a: \"b\""`},
+		{"base64", `{{ base64 "hello" }}`, "", "aGVsbG8="},
+		{"indent", "content:\n{{ indent 2 \"a\\nb\" }}", "", "content:\n  a\n  b"},
+		{"default empty", `{{ default "eu-central" .Candidate.Region }}`, "", "eu-central"},
+		{"default set", `{{ .Candidate.Region | default "eu-central" }}`, "fsn1", "fsn1"},
+		{"default nil", `{{ default "none" .Bootstrap }}`, "", "none"},
+		{"env", `{{ env "TEST_REGISTRY_MIRROR" }}`, "", "https://mirror.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userData, err := cloudinit.RenderUserDataTemplate(&config.Config{UserData: tt.template}, &woodpecker.Agent{}, cloudinit.RenderOption{
+				Candidate: cloudinit.Candidate{Region: tt.region},
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, userData)
+		})
+	}
+}
+
+func TestNormalizeArch(t *testing.T) {
+	assert.Equal(t, "amd64", cloudinit.NormalizeArch("x86_64"))
+	assert.Equal(t, "amd64", cloudinit.NormalizeArch("X86"))
+	assert.Equal(t, "arm64", cloudinit.NormalizeArch("aarch64"))
+	assert.Equal(t, "arm64", cloudinit.NormalizeArch("arm"))
+	assert.Equal(t, "arm64", cloudinit.NormalizeArch("arm64"))
+	assert.Equal(t, "", cloudinit.NormalizeArch(""))
+}
+
+func TestAgentLabels(t *testing.T) {
+	config := &config.Config{
+		ExtraAgentLabels: map[string]string{
+			"gpu":             "true",
+			"autoscaler.arch": "x86",
+		},
+	}
+
+	assert.Equal(t, map[string]string{"gpu": "true", "autoscaler.arch": "x86"}, cloudinit.AgentLabels(config, cloudinit.RenderOption{}))
+
+	labels := cloudinit.AgentLabels(config, cloudinit.RenderOption{
+		Provider: "aws",
+		Candidate: cloudinit.Candidate{
+			InstanceType: "m7g.large",
+			Region:       "eu-central-1",
+			Arch:         "arm64",
+			Spot:         true,
+		},
+	})
+	assert.Equal(t, map[string]string{
+		cloudinit.LabelProvider:     "aws",
+		cloudinit.LabelInstanceType: "m7g.large",
+		cloudinit.LabelRegion:       "eu-central-1",
+		cloudinit.LabelArch:         "x86",
+		cloudinit.LabelMarket:       "spot",
+		"gpu":                       "true",
+	}, labels)
+}
+
+func TestRenderUserDataTemplate_AgentLabels(t *testing.T) {
+	config := &config.Config{
+		UserData:         testUserDataStr,
+		ExtraAgentLabels: map[string]string{"gpu": "true"},
+	}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, &woodpecker.Agent{}, cloudinit.RenderOption{
+		Provider:  "hetznercloud",
+		Candidate: cloudinit.Candidate{InstanceType: "cax11", Region: "fsn1", Arch: "arm64"},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, userData, "WOODPECKER_AGENT_LABELS=autoscaler.arch=arm64,autoscaler.instance_type=cax11,autoscaler.market=on-demand,autoscaler.provider=hetznercloud,autoscaler.region=fsn1,gpu=true\n")
+}
+
+func TestRenderUserDataTemplate_MaxWorkflows(t *testing.T) {
+	config := &config.Config{
+		UserData:          testUserDataStr,
+		WorkflowsPerAgent: 2,
+	}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, &woodpecker.Agent{}, cloudinit.RenderOption{})
+	assert.NoError(t, err)
+	assert.Contains(t, userData, "WOODPECKER_MAX_WORKFLOWS=2\n")
+
+	userData, err = cloudinit.RenderUserDataTemplate(config, &woodpecker.Agent{}, cloudinit.RenderOption{
+		Candidate: cloudinit.Candidate{InstanceType: "cx42", Workflows: 4},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, userData, "WOODPECKER_MAX_WORKFLOWS=4\n")
+}
@@ -0,0 +1,56 @@
+package cloudinit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+// DockerDaemonPath is where docker reads its daemon settings from.
+const DockerDaemonPath = "/etc/docker/daemon.json"
+
+// DockerDaemonJSON returns the daemon.json for the configured docker
+// settings, or nil if none is set.
+func DockerDaemonJSON(config *config.Config) ([]byte, error) {
+	if config.Docker.IsZero() {
+		return nil, nil
+	}
+
+	daemon, err := json.MarshalIndent(config.Docker, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return append(daemon, '\n'), nil
+}
+
+// dockerDaemonPart returns a cloud-config part writing the daemon.json. Files
+// are written before packages get installed, so docker starts with these
+// settings. The part sets its own merge semantics, to add the file to the
+// write_files of any template regardless of cloudinit-merge-how.
+func dockerDaemonPart(daemon []byte) (string, error) {
+	type writeFile struct {
+		Path        string `yaml:"path"`
+		Permissions string `yaml:"permissions"`
+		Content     string `yaml:"content"`
+	}
+	part, err := yaml.Marshal(struct {
+		MergeHow   string      `yaml:"merge_how"`
+		WriteFiles []writeFile `yaml:"write_files"`
+	}{
+		MergeHow: DefaultMergeHow,
+		WriteFiles: []writeFile{{
+			Path:        DockerDaemonPath,
+			Permissions: "0644",
+			Content:     string(daemon),
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("yaml.Marshal: %w", err)
+	}
+
+	return Header + "\n" + string(part), nil
+}
@@ -0,0 +1,78 @@
+package cloudinit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func TestDockerDaemonJSON(t *testing.T) {
+	daemon, err := cloudinit.DockerDaemonJSON(&config.Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, daemon)
+
+	daemon, err = cloudinit.DockerDaemonJSON(&config.Config{Docker: config.DockerDaemon{
+		RegistryMirrors:    []string{"https://mirror.example.com"},
+		InsecureRegistries: []string{"registry.local:5000", "10.0.0.0/8"},
+		MTU:                1400,
+		DataRoot:           "/mnt/docker",
+		LogDriver:          "json-file",
+		LogOpts:            config.LogOpts{MaxSize: "10m", MaxFile: "3"},
+		DefaultAddressPools: []config.AddressPool{
+			{Base: "10.10.0.0/16", Size: 24},
+		},
+	}})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"registry-mirrors": ["https://mirror.example.com"],
+		"insecure-registries": ["registry.local:5000", "10.0.0.0/8"],
+		"mtu": 1400,
+		"data-root": "/mnt/docker",
+		"log-driver": "json-file",
+		"log-opts": {"max-size": "10m", "max-file": "3"},
+		"default-address-pools": [{"base": "10.10.0.0/16", "size": 24}]
+	}`, string(daemon))
+}
+
+func TestRenderUserDataTemplate_DockerDaemon(t *testing.T) {
+	for name, userData := range map[string]string{
+		"builtin": "",
+		"custom":  "#cloud-config\nruncmd:\n  - docker run {{ .Image }}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := &config.Config{
+				Image:    "test-image",
+				UserData: userData,
+				Docker:   config.DockerDaemon{MTU: 1400},
+			}
+
+			rendered, err := cloudinit.RenderUserDataTemplate(config, &woodpecker.Agent{}, cloudinit.RenderOption{})
+			require.NoError(t, err)
+			assert.NoError(t, cloudinit.Validate(rendered))
+
+			parts := readMultipart(t, rendered)
+			require.Len(t, parts, 2)
+			assert.Contains(t, parts[0].content, "test-image")
+
+			var part struct {
+				MergeHow   string `yaml:"merge_how"`
+				WriteFiles []struct {
+					Path    string `yaml:"path"`
+					Content string `yaml:"content"`
+				} `yaml:"write_files"`
+			}
+			require.NoError(t, yaml.Unmarshal([]byte(parts[1].content), &part))
+			assert.Equal(t, cloudinit.DefaultMergeHow, part.MergeHow)
+			require.Len(t, part.WriteFiles, 1)
+			assert.Equal(t, cloudinit.DockerDaemonPath, part.WriteFiles[0].Path)
+			assert.JSONEq(t, `{"mtu": 1400}`, part.WriteFiles[0].Content)
+		})
+	}
+}
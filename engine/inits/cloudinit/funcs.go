@@ -0,0 +1,50 @@
+package cloudinit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the functions available in user data templates:
+//
+//	yamlQuote  quotes a value as YAML (and JSON) string: {{ yamlQuote .AgentName }}
+//	base64     base64 encodes a value: {{ base64 .Image }}
+//	indent     indents every line by n spaces: {{ indent 4 $config }}
+//	default    returns the value, or the fallback if it is empty: {{ default "eu" .Candidate.Region }}
+//	env        looks up an environment variable of the autoscaler: {{ env "REGISTRY_MIRROR" }}
+var TemplateFuncs = template.FuncMap{
+	"yamlQuote": yamlQuote,
+	"base64":    base64Encode,
+	"indent":    indent,
+	"default":   defaultValue,
+	"env":       os.Getenv,
+}
+
+func yamlQuote(value string) string {
+	// a JSON string is a valid YAML double-quoted scalar
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+func base64Encode(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+func indent(spaces int, value string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(value, "\n", "\n"+pad)
+}
+
+func defaultValue(fallback, value any) any {
+	if value == nil {
+		return fallback
+	}
+	if v := reflect.ValueOf(value); v.IsZero() || (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.Len() == 0 {
+		return fallback
+	}
+	return value
+}
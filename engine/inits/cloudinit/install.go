@@ -0,0 +1,155 @@
+package cloudinit
+
+// Install modes select how the built-in templates run the agent.
+const (
+	// InstallModeDockerCompose runs the agent container with docker, mounting
+	// the docker socket.
+	InstallModeDockerCompose = "docker-compose"
+	// InstallModePodman runs the agent container as podman quadlet, mounting
+	// the podman socket as docker socket.
+	InstallModePodman = "podman"
+	// InstallModeBinary installs a pinned agent release and runs it as systemd
+	// unit, e.g. for the local backend.
+	InstallModeBinary = "binary"
+)
+
+// InstallModes lists the supported install modes.
+var InstallModes = []string{InstallModeDockerCompose, InstallModePodman, InstallModeBinary}
+
+// DefaultAgentBinaryURL is where agent releases are downloaded from by
+// default, followed by the version and the archive name.
+const DefaultAgentBinaryURL = "https://github.com/woodpecker-ci/woodpecker/releases/download"
+
+// editorconfig-checker-disable
+var CloudInitUserDataPodman = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+packages:
+  - podman
+
+write_files:
+- path: /etc/woodpecker/agent.env
+  permissions: '0600'
+  content: |
+    {{- range $key, $value := .Environment }}
+    {{ $key }}={{ $value }}
+    {{- end }}
+- path: /etc/containers/systemd/woodpecker-agent.container
+  content: |
+    [Unit]
+    Description=Woodpecker agent
+    Wants=network-online.target
+    After=network-online.target podman.socket
+    Requires=podman.socket
+
+    [Container]
+    ContainerName=woodpecker-agent
+    Image={{ .Image }}
+    Volume=/run/podman/podman.sock:/var/run/docker.sock
+    EnvironmentFile=/etc/woodpecker/agent.env
+    {{- if .Bootstrap }}
+    EnvironmentFile=/etc/woodpecker/token.env
+    {{- end }}
+
+    [Service]
+    Restart=always
+    RestartSec=5
+
+    [Install]
+    WantedBy=multi-user.target
+
+runcmd:
+  {{- range .PreExec }}
+  - {{ . }}
+  {{- end }}
+  - systemctl enable --now podman.socket
+  {{- with .Bootstrap }}
+  - sh -ec 'umask 077; token=$(curl -fsS --retry 10 --retry-connrefused -d agent={{ .Agent }} -d nonce={{ .Nonce }} {{ .URL }}); echo "WOODPECKER_AGENT_SECRET=$token" > /etc/woodpecker/token.env'
+  {{- end }}
+  - systemctl daemon-reload
+  - systemctl start woodpecker-agent
+  {{- range .PostExec }}
+  - {{ . }}
+  {{- end }}
+
+final_message: "The system is finally up, after $UPTIME seconds"
+`
+
+// The release archive is verified against the pinned checksum of the
+// machine's architecture before the agent gets installed.
+var CloudInitUserDataBinary = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+packages:
+  - curl
+  - tar
+
+write_files:
+- path: /etc/woodpecker/agent.env
+  permissions: '0600'
+  content: |
+    {{- range $key, $value := .Environment }}
+    {{ $key }}={{ $value }}
+    {{- end }}
+- path: /etc/woodpecker/install-agent.sh
+  permissions: '0700'
+  content: |
+    #!/bin/sh
+    set -e
+    case "$(uname -m)" in
+      x86_64) arch=amd64 ;;
+      aarch64) arch=arm64 ;;
+      *) arch=$(uname -m) ;;
+    esac
+    case "$arch" in
+      {{- range $arch, $checksum := .Binary.Checksums }}
+      {{ $arch }}) checksum={{ $checksum }} ;;
+      {{- end }}
+      *) echo "no woodpecker-agent checksum for $arch" >&2; exit 1 ;;
+    esac
+    archive=/tmp/woodpecker-agent.tar.gz
+    curl -fsSL --retry 10 --retry-connrefused -o $archive '{{ .Binary.URL }}/{{ .Binary.Version }}/woodpecker-agent_linux_'$arch'.tar.gz'
+    echo "$checksum  $archive" | sha256sum -c -
+    tar -xzf $archive -C /usr/local/bin woodpecker-agent
+    rm $archive
+- path: /etc/systemd/system/woodpecker-agent.service
+  content: |
+    [Unit]
+    Description=Woodpecker agent
+    Wants=network-online.target
+    After=network-online.target
+
+    [Service]
+    EnvironmentFile=/etc/woodpecker/agent.env
+    {{- if .Bootstrap }}
+    EnvironmentFile=/etc/woodpecker/token.env
+    {{- end }}
+    ExecStart=/usr/local/bin/woodpecker-agent
+    Restart=always
+    RestartSec=5
+
+    [Install]
+    WantedBy=multi-user.target
+
+runcmd:
+  {{- range .PreExec }}
+  - {{ . }}
+  {{- end }}
+  - /etc/woodpecker/install-agent.sh
+  {{- with .Bootstrap }}
+  - sh -ec 'umask 077; token=$(curl -fsS --retry 10 --retry-connrefused -d agent={{ .Agent }} -d nonce={{ .Nonce }} {{ .URL }}); echo "WOODPECKER_AGENT_SECRET=$token" > /etc/woodpecker/token.env'
+  {{- end }}
+  - systemctl daemon-reload
+  - systemctl enable --now woodpecker-agent
+  {{- range .PostExec }}
+  - {{ . }}
+  {{- end }}
+
+final_message: "The system is finally up, after $UPTIME seconds"
+` // editorconfig-checker-enable
@@ -0,0 +1,62 @@
+package cloudinit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+const testChecksum = "3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b"
+
+func TestRenderUserDataTemplate_InstallModePodman(t *testing.T) {
+	config := &config.Config{
+		Image:       "test-image",
+		InstallMode: cloudinit.InstallModePodman,
+		OSFamily:    "fedora",
+	}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, &woodpecker.Agent{Token: "test-token"}, cloudinit.RenderOption{})
+	require.NoError(t, err)
+	assert.NoError(t, cloudinit.Validate(userData))
+
+	assert.Contains(t, userData, "  - podman\n")
+	assert.NotContains(t, userData, "docker-ce")
+	assert.Contains(t, userData, "Image=test-image\n")
+	assert.Contains(t, userData, "Volume=/run/podman/podman.sock:/var/run/docker.sock\n")
+	assert.Contains(t, userData, "WOODPECKER_AGENT_SECRET=test-token\n")
+	assert.Contains(t, userData, "systemctl start woodpecker-agent\n")
+}
+
+func TestRenderUserDataTemplate_InstallModeBinary(t *testing.T) {
+	config := &config.Config{
+		Image:       "test-image",
+		InstallMode: cloudinit.InstallModeBinary,
+		AgentBinary: config.AgentBinary{
+			URL:       cloudinit.DefaultAgentBinaryURL,
+			Version:   "v3.5.0",
+			Checksums: map[string]string{"amd64": testChecksum},
+		},
+		Bootstrap: &testBootstrap{},
+	}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "test-token"}, cloudinit.RenderOption{
+		PreExec: []string{"echo pre"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, cloudinit.Validate(userData))
+
+	assert.NotContains(t, userData, "docker")
+	assert.NotContains(t, userData, "test-token")
+	assert.Contains(t, userData, "amd64) checksum="+testChecksum+" ;;\n")
+	assert.Contains(t, userData, "'https://github.com/woodpecker-ci/woodpecker/releases/download/v3.5.0/woodpecker-agent_linux_'$arch'.tar.gz'")
+	assert.Contains(t, userData, `echo "$checksum  $archive" | sha256sum -c -`)
+	assert.Contains(t, userData, "EnvironmentFile=/etc/woodpecker/token.env\n")
+	assert.Contains(t, userData, "systemctl enable --now woodpecker-agent\n")
+	assert.Less(t, strings.Index(userData, "- echo pre"), strings.Index(userData, "- /etc/woodpecker/install-agent.sh"))
+}
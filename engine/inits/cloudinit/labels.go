@@ -0,0 +1,44 @@
+package cloudinit
+
+import "go.woodpecker-ci.org/autoscaler/config"
+
+// Agent labels describing where an agent was deployed, set automatically so
+// workflows can select agents of a heterogeneous pool by them.
+const (
+	LabelProvider     = "autoscaler.provider"
+	LabelInstanceType = "autoscaler.instance_type"
+	LabelRegion       = "autoscaler.region"
+	LabelArch         = "autoscaler.arch"
+	// LabelMarket is spot for spot instances, on-demand otherwise.
+	LabelMarket = "autoscaler.market"
+)
+
+// AgentLabels returns the labels the agent reports: those of the provider
+// and the candidate it deployed the agent on, and the extra labels of the
+// config, which take precedence.
+func AgentLabels(config *config.Config, r RenderOption) map[string]string {
+	labels := make(map[string]string)
+
+	if r.Provider != "" {
+		labels[LabelProvider] = r.Provider
+		labels[LabelMarket] = "on-demand"
+		if r.Candidate.Spot {
+			labels[LabelMarket] = "spot"
+		}
+	}
+	if r.Candidate.InstanceType != "" {
+		labels[LabelInstanceType] = r.Candidate.InstanceType
+	}
+	if r.Candidate.Region != "" {
+		labels[LabelRegion] = r.Candidate.Region
+	}
+	if r.Candidate.Arch != "" {
+		labels[LabelArch] = r.Candidate.Arch
+	}
+
+	for key, value := range config.ExtraAgentLabels {
+		labels[key] = value
+	}
+
+	return labels
+}
@@ -0,0 +1,116 @@
+package cloudinit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// DefaultMergeHow merges cloud-config parts into the ones before them by
+// appending to lists and merging mappings, so a part adding packages or
+// runcmd entries does not replace those of the template.
+const DefaultMergeHow = "list(append)+dict(recurse_array)+str()"
+
+// part types by the header their content starts with
+var partTypes = []struct {
+	header      string
+	contentType string
+}{
+	{Header, "text/cloud-config"},
+	{"#cloud-boothook", "text/cloud-boothook"},
+	{"#!", "text/x-shellscript"},
+}
+
+// ownMergeHow matches a cloud-config part that sets its merge semantics itself.
+var ownMergeHow = regexp.MustCompile(`(?m)^merge_(how|type):`)
+
+// PartType returns the MIME type of a user data part, a cloud-config, a
+// boothook or a shell script, by its header.
+func PartType(part string) (string, error) {
+	for _, t := range partTypes {
+		if strings.HasPrefix(part, t.header) {
+			return t.contentType, nil
+		}
+	}
+	return "", fmt.Errorf("%w: part must start with %s, #cloud-boothook or #!", ErrInvalidUserData, Header)
+}
+
+// Multipart assembles the parts into a multipart/mixed document cloud-init
+// processes in order. Cloud-config parts that set neither merge_how nor
+// merge_type are merged as mergeHow says, cloud-init's own default replaces
+// lists.
+func Multipart(parts []string, mergeHow string) (string, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\nMIME-Version: 1.0\r\n\r\n", w.Boundary())
+
+	for i, part := range parts {
+		contentType, err := PartType(part)
+		if err != nil {
+			return "", fmt.Errorf("part %d: %w", i+1, err)
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", contentType+`; charset="utf-8"`)
+		header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="part-%03d"`, i+1))
+		if contentType == "text/cloud-config" && mergeHow != "" && !ownMergeHow.MatchString(part) {
+			header.Set("Merge-Type", mergeHow)
+		}
+
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return "", fmt.Errorf("multipart.CreatePart: %w", err)
+		}
+		if _, err := io.WriteString(pw, part+"\n"); err != nil {
+			return "", fmt.Errorf("multipart.Write: %w", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("multipart.Close: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// validateMultipart validates every cloud-config part of a multipart
+// document.
+func validateMultipart(userData string) error {
+	msg, err := mail.ReadMessage(strings.NewReader(userData))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidUserData, err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidUserData, err)
+	}
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for i := 1; ; i++ {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: part %d: %w", ErrInvalidUserData, i, err)
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		if mediaType != "text/cloud-config" {
+			continue
+		}
+		content, err := io.ReadAll(p)
+		if err != nil {
+			return fmt.Errorf("%w: part %d: %w", ErrInvalidUserData, i, err)
+		}
+		if err := Validate(strings.TrimSpace(strings.ReplaceAll(string(content), "\r\n", "\n"))); err != nil {
+			return fmt.Errorf("part %d: %w", i, err)
+		}
+	}
+}
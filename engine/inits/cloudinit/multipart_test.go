@@ -0,0 +1,105 @@
+package cloudinit_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+type part struct {
+	contentType string
+	mergeType   string
+	content     string
+}
+
+func readMultipart(t *testing.T, userData string) []part {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(userData))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	var parts []part
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(p)
+		require.NoError(t, err)
+		contentType, _, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		parts = append(parts, part{
+			contentType: contentType,
+			mergeType:   p.Header.Get("Merge-Type"),
+			content:     strings.TrimSpace(string(content)),
+		})
+	}
+}
+
+func TestRenderUserDataTemplate_Parts(t *testing.T) {
+	config := &config.Config{
+		Image: "test-image",
+		UserDataParts: []string{
+			"#cloud-config\npackages:\n  - jq\n",
+			"#cloud-config\nmerge_how: list(replace)\nruncmd:\n  - echo {{ .AgentName }}",
+			"#!/bin/sh\necho {{ .Candidate.Region }}",
+			"#cloud-boothook\necho boot",
+		},
+		UserDataMergeHow: cloudinit.DefaultMergeHow,
+	}
+	agent := &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "test-token"}
+
+	userData, err := cloudinit.RenderUserDataTemplate(config, agent, cloudinit.RenderOption{
+		Candidate: cloudinit.Candidate{Region: "fsn1"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, cloudinit.Validate(userData))
+
+	parts := readMultipart(t, userData)
+	require.Len(t, parts, 5)
+
+	assert.Equal(t, "text/cloud-config", parts[0].contentType)
+	assert.Equal(t, cloudinit.DefaultMergeHow, parts[0].mergeType)
+	assert.Contains(t, parts[0].content, "test-image")
+
+	assert.Equal(t, part{"text/cloud-config", cloudinit.DefaultMergeHow, "#cloud-config\npackages:\n  - jq"}, parts[1])
+	assert.Equal(t, part{"text/cloud-config", "", "#cloud-config\nmerge_how: list(replace)\nruncmd:\n  - echo pool-1-agent-abcd"}, parts[2])
+	assert.Equal(t, part{"text/x-shellscript", "", "#!/bin/sh\necho fsn1"}, parts[3])
+	assert.Equal(t, part{"text/cloud-boothook", "", "#cloud-boothook\necho boot"}, parts[4])
+}
+
+func TestRenderUserDataTemplate_PartsError(t *testing.T) {
+	_, err := cloudinit.RenderUserDataTemplate(&config.Config{UserDataParts: []string{"packages: [jq]"}}, &woodpecker.Agent{}, cloudinit.RenderOption{})
+	assert.ErrorIs(t, err, cloudinit.ErrInvalidUserData)
+	assert.ErrorContains(t, err, "part 2")
+
+	_, err = cloudinit.RenderUserDataTemplate(&config.Config{UserDataParts: []string{"#!/bin/sh\n{{ .Unknown }}"}}, &woodpecker.Agent{}, cloudinit.RenderOption{})
+	assert.Error(t, err)
+}
+
+func TestValidate_Multipart(t *testing.T) {
+	userData, err := cloudinit.Multipart([]string{
+		"#!/bin/sh\necho hello",
+		"#cloud-config\npackages:\n  - jq\nruncmds:\n  - echo a",
+	}, "")
+	require.NoError(t, err)
+
+	err = cloudinit.Validate(userData)
+	assert.ErrorIs(t, err, cloudinit.ErrInvalidUserData)
+	assert.ErrorContains(t, err, `part 2: invalid user data: line 4: unknown cloud-config key "runcmds"`)
+}
@@ -0,0 +1,238 @@
+package cloudinit
+
+import (
+	"slices"
+	"strings"
+)
+
+// OSFamily selects the built-in user data template matching the operating
+// system of the agent image.
+type OSFamily string
+
+const (
+	OSFamilyUbuntu OSFamily = "ubuntu"
+	OSFamilyDebian OSFamily = "debian"
+	OSFamilyFedora OSFamily = "fedora"
+	// OSFamilyRHEL covers RHEL and its rebuilds Rocky Linux, AlmaLinux and
+	// CentOS Stream.
+	OSFamilyRHEL   OSFamily = "rhel"
+	OSFamilyAmazon OSFamily = "amazon"
+	OSFamilySUSE   OSFamily = "suse"
+)
+
+// Templates are the built-in user data templates by OS family.
+var Templates = map[OSFamily]string{
+	OSFamilyUbuntu: CloudInitUserDataUbuntuDefault,
+	OSFamilyDebian: CloudInitUserDataDebian,
+	OSFamilyFedora: CloudInitUserDataFedora,
+	OSFamilyRHEL:   CloudInitUserDataRHEL,
+	OSFamilyAmazon: CloudInitUserDataAmazon,
+	OSFamilySUSE:   CloudInitUserDataSUSE,
+}
+
+// OSFamilies returns the names of all OS families with a built-in template.
+func OSFamilies() []string {
+	families := make([]string, 0, len(Templates))
+	for family := range Templates {
+		families = append(families, string(family))
+	}
+	slices.Sort(families)
+	return families
+}
+
+// osFamilyKeywords maps the names distributions go by in image metadata (AMI
+// names, Hetzner OS flavors, DigitalOcean distributions) to their OS family.
+var osFamilyKeywords = []struct {
+	keyword string
+	family  OSFamily
+}{
+	{"ubuntu", OSFamilyUbuntu},
+	{"debian", OSFamilyDebian},
+	{"fedora", OSFamilyFedora},
+	{"rocky", OSFamilyRHEL},
+	{"alma", OSFamilyRHEL},
+	{"centos", OSFamilyRHEL},
+	{"rhel", OSFamilyRHEL},
+	{"red hat", OSFamilyRHEL},
+	{"al2023", OSFamilyAmazon},
+	{"amzn", OSFamilyAmazon},
+	{"amazon", OSFamilyAmazon},
+	{"suse", OSFamilySUSE},
+	{"sles", OSFamilySUSE},
+}
+
+// DetectOSFamily returns the OS family named in the image metadata, or an
+// empty family if it names none it knows.
+func DetectOSFamily(metadata string) OSFamily {
+	metadata = strings.ToLower(metadata)
+	for _, k := range osFamilyKeywords {
+		if strings.Contains(metadata, k.keyword) {
+			return k.family
+		}
+	}
+	return ""
+}
+
+// editorconfig-checker-disable
+var CloudInitUserDataDebian = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+groups:
+  - docker
+
+system_info:
+  default_user:
+    groups: [ docker ]
+
+apt:
+  sources:
+    docker.list:
+      keyid: 9DC858229FC7DD38854AE2D88D81803C0EBFCD88
+      keyserver: https://download.docker.com/linux/debian/gpg
+      source: deb [signed-by=$KEY_FILE] https://download.docker.com/linux/debian $RELEASE stable
+
+packages:
+  - docker-ce
+  - docker-compose-plugin
+  - binfmt-support
+  - qemu-user-static
+` + composeAgent
+
+var CloudInitUserDataFedora = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+groups:
+  - docker
+
+system_info:
+  default_user:
+    groups: [ docker ]
+
+yum_repos:
+  docker-ce-stable:
+    name: Docker CE Stable
+    baseurl: https://download.docker.com/linux/fedora/$releasever/$basearch/stable
+    enabled: true
+    gpgcheck: true
+    gpgkey: https://download.docker.com/linux/fedora/gpg
+
+packages:
+  - docker-ce
+  - docker-compose-plugin
+  - qemu-user-static
+` + composeAgent
+
+var CloudInitUserDataRHEL = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+groups:
+  - docker
+
+system_info:
+  default_user:
+    groups: [ docker ]
+
+yum_repos:
+  docker-ce-stable:
+    name: Docker CE Stable
+    baseurl: https://download.docker.com/linux/centos/$releasever/$basearch/stable
+    enabled: true
+    gpgcheck: true
+    gpgkey: https://download.docker.com/linux/centos/gpg
+
+packages:
+  - docker-ce
+  - docker-compose-plugin
+` + composeAgent
+
+// Amazon Linux and SUSE ship docker but no compose plugin, so the agent is
+// started with docker run.
+var CloudInitUserDataAmazon = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+packages:
+  - docker
+` + dockerRunAgent
+
+var CloudInitUserDataSUSE = `#cloud-config
+
+package_reboot_if_required: false
+package_update: true
+package_upgrade: false
+
+packages:
+  - docker
+` + dockerRunAgent
+
+var composeAgent = `
+write_files:
+- path: /root/docker-compose.yml
+  content: |
+    # docker-compose.yml
+    services:
+      woodpecker-agent:
+        image: {{ .Image }}
+        restart: always
+        volumes:
+          - /var/run/docker.sock:/var/run/docker.sock
+        {{- if .Bootstrap }}
+        env_file:
+          - agent.env
+        {{- end }}
+        environment:
+          {{- range $key, $value := .Environment }}
+          - {{ $key }}={{ $value }}
+          {{- end }}
+
+runcmd:
+  {{- range .PreExec }}
+  - {{ . }}
+  {{- end }}
+  - systemctl enable --now docker
+  {{- with .Bootstrap }}
+  - sh -ec 'umask 077; token=$(curl -fsS --retry 10 --retry-connrefused -d agent={{ .Agent }} -d nonce={{ .Nonce }} {{ .URL }}); echo "WOODPECKER_AGENT_SECRET=$token" > /root/agent.env'
+  {{- end }}
+  - sh -xc "cd /root; docker compose up -d"
+  {{- range .PostExec }}
+  - {{ . }}
+  {{- end }}
+
+final_message: "The system is finally up, after $UPTIME seconds"
+`
+
+var dockerRunAgent = `
+write_files:
+- path: /root/woodpecker.env
+  permissions: '0600'
+  content: |
+    {{- range $key, $value := .Environment }}
+    {{ $key }}={{ $value }}
+    {{- end }}
+
+runcmd:
+  {{- range .PreExec }}
+  - {{ . }}
+  {{- end }}
+  - systemctl enable --now docker
+  {{- with .Bootstrap }}
+  - sh -ec 'umask 077; token=$(curl -fsS --retry 10 --retry-connrefused -d agent={{ .Agent }} -d nonce={{ .Nonce }} {{ .URL }}); echo "WOODPECKER_AGENT_SECRET=$token" > /root/agent.env'
+  {{- end }}
+  - docker run -d --name woodpecker-agent --restart always -v /var/run/docker.sock:/var/run/docker.sock --env-file /root/woodpecker.env{{ if .Bootstrap }} --env-file /root/agent.env{{ end }} {{ .Image }}
+  {{- range .PostExec }}
+  - {{ . }}
+  {{- end }}
+
+final_message: "The system is finally up, after $UPTIME seconds"
+` // editorconfig-checker-enable
@@ -0,0 +1,120 @@
+package cloudinit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidUserData = errors.New("invalid user data")
+
+// Header marks user data as cloud-config.
+const Header = "#cloud-config"
+
+// otherFormats are the headers of the other user data formats cloud-init
+// accepts, their content is passed on as is and not validated.
+var otherFormats = []string{
+	"#!",
+	"#include",
+	"#cloud-boothook",
+	"#part-handler",
+}
+
+// Keys are the top-level cloud-config keys of the cloud-init modules and base
+// configuration.
+var Keys = []string{
+	"ansible", "apk_repos", "apt", "apt_pipelining", "apt_reboot_if_required",
+	"apt_update", "apt_upgrade", "bootcmd", "byobu_by_default", "ca_certs",
+	"ca-certs", "chef", "chpasswd", "cloud_config_modules", "cloud_final_modules",
+	"cloud_init_modules", "datasource", "datasource_list", "device_aliases",
+	"disable_ec2_metadata", "disable_root", "disable_root_opts", "disk_setup",
+	"drivers", "fan", "final_message", "fqdn", "fs_setup", "groups", "growpart",
+	"hostname", "keyboard", "landscape", "locale", "locale_configfile", "lxd",
+	"manage_etc_hosts", "manage_resolv_conf", "mcollective", "merge_how",
+	"merge_type", "mounts", "mount_default_fields", "no_ssh_fingerprints",
+	"ntp", "output", "package_reboot_if_required", "package_update",
+	"package_upgrade", "packages", "phone_home", "power_state", "prefer_fqdn_over_hostname",
+	"preserve_hostname", "puppet", "random_seed", "reporting", "resize_rootfs",
+	"resolv_conf", "rh_subscription", "rsyslog", "runcmd", "salt_minion",
+	"snap", "spacewalk", "ssh", "ssh_authorized_keys", "ssh_deletekeys",
+	"ssh_fp_console_blacklist", "ssh_genkeytypes", "ssh_import_id", "ssh_keys",
+	"ssh_key_console_blacklist", "ssh_publish_hostkeys", "ssh_pwauth", "ssh_quiet_keygen",
+	"swap", "system_info", "timezone", "ubuntu_advantage", "ubuntu_pro",
+	"updates", "user", "users", "vendor_data", "wireguard", "write_files",
+	"yum_repo_dir", "yum_repos", "zypper",
+}
+
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// Validate checks that the user data is in a format cloud-init understands.
+// Cloud-config has to be valid YAML with only known top-level keys, errors
+// name the offending line. The cloud-config parts of a multipart document
+// are validated alike.
+func Validate(userData string) error {
+	if strings.HasPrefix(userData, "Content-Type: multipart/") {
+		return validateMultipart(userData)
+	}
+	if !strings.HasPrefix(userData, Header+"\n") && userData != Header {
+		for _, format := range otherFormats {
+			if strings.HasPrefix(userData, format) {
+				return nil
+			}
+		}
+		return lineError(userData, 1, fmt.Sprintf("missing %s header", Header))
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(userData), &doc); err != nil {
+		m := yamlErrorLine.FindStringSubmatch(err.Error())
+		if m == nil {
+			return fmt.Errorf("%w: %w", ErrInvalidUserData, err)
+		}
+		line, _ := strconv.Atoi(m[1])
+		return lineError(userData, syntaxErrorLine(userData, line), m[2])
+	}
+
+	// a header without any config is valid
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return lineError(userData, root.Line, "cloud-config is not a mapping")
+	}
+	for i := 0; i < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if !slices.Contains(Keys, key.Value) {
+			return lineError(userData, key.Line, fmt.Sprintf("unknown cloud-config key %q", key.Value))
+		}
+	}
+
+	return nil
+}
+
+// syntaxErrorLine returns the first line the user data can not be parsed up
+// to. The YAML parser reports the line the enclosing block started at, which
+// is of little help with an indentation error deep inside a block.
+func syntaxErrorLine(userData string, reported int) int {
+	lines := strings.Split(userData, "\n")
+	for n := reported; n <= len(lines); n++ {
+		var doc yaml.Node
+		if yaml.Unmarshal([]byte(strings.Join(lines[:n], "\n")), &doc) != nil {
+			return n
+		}
+	}
+	return reported
+}
+
+func lineError(userData string, line int, msg string) error {
+	lines := strings.Split(userData, "\n")
+	if line < 1 || line > len(lines) {
+		return fmt.Errorf("%w: line %d: %s", ErrInvalidUserData, line, msg)
+	}
+	return fmt.Errorf("%w: line %d: %s: %q", ErrInvalidUserData, line, msg, lines[line-1])
+}
@@ -0,0 +1,55 @@
+package cloudinit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func TestValidate_BuiltinTemplates(t *testing.T) {
+	agent := &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "test-token"}
+
+	for _, family := range cloudinit.OSFamilies() {
+		t.Run(family, func(t *testing.T) {
+			userData, err := cloudinit.RenderUserDataTemplate(&config.Config{Image: "test-image"}, agent, cloudinit.RenderOption{
+				OSFamily: cloudinit.OSFamily(family),
+				PreExec:  []string{"echo pre"},
+				PostExec: []string{"echo post"},
+			})
+			assert.NoError(t, err)
+
+			assert.NoError(t, cloudinit.Validate(userData))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		userData string
+		err      string
+	}{
+		{"header only", "#cloud-config", ""},
+		{"shell script", "#!/bin/sh\necho hello", ""},
+		{"missing header", "runcmd:\n  - echo hello", `line 1: missing #cloud-config header: "runcmd:"`},
+		{"bad indentation", "#cloud-config\nruncmd:\n  - echo a\n - echo b", `line 4: did not find expected key: " - echo b"`},
+		{"unknown key", "#cloud-config\npackages:\n  - docker\nruncmds:\n  - echo a", `line 4: unknown cloud-config key "runcmds": "runcmds:"`},
+		{"not a mapping", "#cloud-config\n- docker", `line 2: cloud-config is not a mapping: "- docker"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cloudinit.Validate(tt.userData)
+
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, cloudinit.ErrInvalidUserData)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
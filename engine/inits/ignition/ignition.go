@@ -0,0 +1,194 @@
+package ignition
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Version is the Ignition config specification version rendered, supported by
+// Fedora CoreOS and Flatcar Container Linux.
+const Version = "3.4.0"
+
+const (
+	envFile       = "/etc/woodpecker/agent.env"
+	tokenFile     = "/etc/woodpecker/token.env"
+	preExecFile   = "/etc/woodpecker/pre-exec.sh"
+	postExecFile  = "/etc/woodpecker/post-exec.sh"
+	bootstrapFile = "/etc/woodpecker/bootstrap.sh"
+
+	modeConfig = 0o644
+	modeSecret = 0o600
+	modeScript = 0o700
+)
+
+// Config is the subset of an Ignition config the agent setup needs.
+type Config struct {
+	Ignition Ignition `json:"ignition"`
+	Storage  Storage  `json:"storage"`
+	Systemd  Systemd  `json:"systemd"`
+}
+
+type Ignition struct {
+	Version string `json:"version"`
+}
+
+type Storage struct {
+	Files []File `json:"files,omitempty"`
+}
+
+type File struct {
+	Path      string   `json:"path"`
+	Mode      int      `json:"mode"`
+	Overwrite bool     `json:"overwrite"`
+	Contents  Resource `json:"contents"`
+}
+
+type Resource struct {
+	Source string `json:"source"`
+}
+
+type Systemd struct {
+	Units []Unit `json:"units,omitempty"`
+}
+
+type Unit struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Contents string `json:"contents"`
+}
+
+// RenderUserData renders an Ignition config for an Agent that runs the agent
+// container as systemd unit. PreExec commands run once before the agent is
+// started, PostExec commands once after it was started. The OS family of the
+// render option is ignored, as the agent only needs docker from the image.
+func RenderUserData(config *config.Config, agent *woodpecker.Agent, r cloudinit.RenderOption) (string, error) {
+	environment, bootstrap, err := cloudinit.AgentEnvironment(config, agent, r)
+	if err != nil {
+		return "", err
+	}
+
+	ign := Config{Ignition: Ignition{Version: Version}}
+	ign.Storage.Files = append(ign.Storage.Files, file(envFile, modeSecret, envFileContent(environment)))
+
+	daemon, err := cloudinit.DockerDaemonJSON(config)
+	if err != nil {
+		return "", err
+	}
+	if daemon != nil {
+		ign.Storage.Files = append(ign.Storage.Files, file(cloudinit.DockerDaemonPath, modeConfig, string(daemon)))
+	}
+
+	var setup []string
+	if len(r.PreExec) > 0 {
+		ign.Storage.Files = append(ign.Storage.Files, file(preExecFile, modeScript, script(r.PreExec)))
+		setup = append(setup, preExecFile)
+	}
+	if bootstrap != nil {
+		ign.Storage.Files = append(ign.Storage.Files, file(bootstrapFile, modeScript, bootstrapScript(bootstrap)))
+		setup = append(setup, bootstrapFile)
+	}
+	if len(r.PostExec) > 0 {
+		ign.Storage.Files = append(ign.Storage.Files, file(postExecFile, modeScript, script(r.PostExec)))
+	}
+
+	if len(setup) > 0 {
+		ign.Systemd.Units = append(ign.Systemd.Units, Unit{
+			Name:     "woodpecker-agent-setup.service",
+			Contents: oneshotUnit("Prepare woodpecker agent", "", setup),
+		})
+	}
+	ign.Systemd.Units = append(ign.Systemd.Units, Unit{
+		Name:     "woodpecker-agent.service",
+		Enabled:  true,
+		Contents: agentUnit(config.Image, len(setup) > 0, bootstrap != nil),
+	})
+	if len(r.PostExec) > 0 {
+		ign.Systemd.Units = append(ign.Systemd.Units, Unit{
+			Name:     "woodpecker-agent-post.service",
+			Enabled:  true,
+			Contents: oneshotUnit("Finish woodpecker agent setup", "woodpecker-agent.service", []string{postExecFile}),
+		})
+	}
+
+	userData, err := json.Marshal(ign)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return string(userData), nil
+}
+
+func file(path string, mode int, content string) File {
+	return File{
+		Path:      path,
+		Mode:      mode,
+		Overwrite: true,
+		Contents: Resource{
+			Source: "data:;base64," + base64.StdEncoding.EncodeToString([]byte(content)),
+		},
+	}
+}
+
+func envFileContent(environment map[string]string) string {
+	var b strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(environment)) {
+		fmt.Fprintf(&b, "%s=%s\n", key, environment[key])
+	}
+	return b.String()
+}
+
+func script(commands []string) string {
+	return "#!/bin/sh\nset -e\n" + strings.Join(commands, "\n") + "\n"
+}
+
+// bootstrapScript fetches the agent token once; the nonce can not be spent a
+// second time, so a reboot keeps the token fetched before.
+func bootstrapScript(b *cloudinit.Bootstrap) string {
+	return fmt.Sprintf(`#!/bin/sh
+set -e
+umask 077
+[ -s %[1]s ] && exit 0
+token=$(curl -fsS --retry 10 --retry-connrefused -d agent=%[2]s -d nonce=%[3]s '%[4]s')
+echo "WOODPECKER_AGENT_SECRET=$token" > %[1]s
+`, tokenFile, b.Agent, b.Nonce, b.URL)
+}
+
+// oneshotUnit runs the scripts once per boot, after the given unit if any.
+func oneshotUnit(description, after string, scripts []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nWants=network-online.target\nAfter=network-online.target %s\n\n", description, after)
+	b.WriteString("[Service]\nType=oneshot\nRemainAfterExit=yes\n")
+	for _, s := range scripts {
+		fmt.Fprintf(&b, "ExecStart=/bin/sh %s\n", s)
+	}
+	b.WriteString("\n[Install]\nWantedBy=multi-user.target\n")
+	return b.String()
+}
+
+func agentUnit(image string, setup, bootstrap bool) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\nDescription=Woodpecker agent\nWants=network-online.target\nAfter=network-online.target docker.service\nRequires=docker.service\n")
+	if setup {
+		b.WriteString("After=woodpecker-agent-setup.service\nRequires=woodpecker-agent-setup.service\n")
+	}
+
+	b.WriteString("\n[Service]\nRestart=always\nRestartSec=5\n")
+	b.WriteString("ExecStartPre=-/usr/bin/docker rm -f woodpecker-agent\n")
+	fmt.Fprintf(&b, "ExecStart=/usr/bin/docker run --rm --name woodpecker-agent -v /var/run/docker.sock:/var/run/docker.sock --env-file %s", envFile)
+	if bootstrap {
+		fmt.Fprintf(&b, " --env-file %s", tokenFile)
+	}
+	fmt.Fprintf(&b, " %s\n", image)
+	b.WriteString("ExecStop=/usr/bin/docker stop woodpecker-agent\n")
+
+	b.WriteString("\n[Install]\nWantedBy=multi-user.target\n")
+	return b.String()
+}
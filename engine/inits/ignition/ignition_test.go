@@ -0,0 +1,131 @@
+package ignition_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/ignition"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func render(t *testing.T, config *config.Config, agent *woodpecker.Agent, r cloudinit.RenderOption) ignition.Config {
+	t.Helper()
+
+	userData, err := ignition.RenderUserData(config, agent, r)
+	require.NoError(t, err)
+
+	var ign ignition.Config
+	require.NoError(t, json.Unmarshal([]byte(userData), &ign))
+	return ign
+}
+
+func fileContent(t *testing.T, ign ignition.Config, path string) string {
+	t.Helper()
+
+	for _, f := range ign.Storage.Files {
+		if f.Path == path {
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(f.Contents.Source, "data:;base64,"))
+			require.NoError(t, err)
+			return string(data)
+		}
+	}
+	t.Fatalf("file %s not found", path)
+	return ""
+}
+
+func unit(t *testing.T, ign ignition.Config, name string) ignition.Unit {
+	t.Helper()
+
+	for _, u := range ign.Systemd.Units {
+		if u.Name == name {
+			return u
+		}
+	}
+	t.Fatalf("unit %s not found", name)
+	return ignition.Unit{}
+}
+
+func TestRenderUserData(t *testing.T) {
+	config := &config.Config{
+		Image:       "woodpeckerci/woodpecker-agent:v3",
+		GRPCAddress: "grpc.example.com:443",
+		GRPCSecure:  true,
+		Environment: map[string]string{"FOO": "bar"},
+	}
+	agent := &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "test-token"}
+
+	ign := render(t, config, agent, cloudinit.RenderOption{})
+
+	assert.Equal(t, ignition.Version, ign.Ignition.Version)
+
+	env := fileContent(t, ign, "/etc/woodpecker/agent.env")
+	assert.Contains(t, env, "FOO=bar\n")
+	assert.Contains(t, env, "WOODPECKER_AGENT_SECRET=test-token\n")
+	assert.Contains(t, env, "WOODPECKER_GRPC_SECURE=true\n")
+	assert.Contains(t, env, "WOODPECKER_SERVER=grpc.example.com:443\n")
+
+	agentUnit := unit(t, ign, "woodpecker-agent.service")
+	assert.True(t, agentUnit.Enabled)
+	assert.Contains(t, agentUnit.Contents, "--env-file /etc/woodpecker/agent.env woodpeckerci/woodpecker-agent:v3\n")
+	assert.NotContains(t, agentUnit.Contents, "woodpecker-agent-setup.service")
+	assert.Len(t, ign.Systemd.Units, 1)
+}
+
+func TestRenderUserDataPreAndPostExec(t *testing.T) {
+	ign := render(t, &config.Config{Image: "agent"}, &woodpecker.Agent{}, cloudinit.RenderOption{
+		PreExec:  []string{"ip -4 route add blackhole 169.254.169.254/32"},
+		PostExec: []string{"echo done"},
+	})
+
+	assert.Equal(t, "#!/bin/sh\nset -e\nip -4 route add blackhole 169.254.169.254/32\n", fileContent(t, ign, "/etc/woodpecker/pre-exec.sh"))
+	assert.Equal(t, "#!/bin/sh\nset -e\necho done\n", fileContent(t, ign, "/etc/woodpecker/post-exec.sh"))
+
+	assert.Contains(t, unit(t, ign, "woodpecker-agent-setup.service").Contents, "ExecStart=/bin/sh /etc/woodpecker/pre-exec.sh\n")
+	assert.Contains(t, unit(t, ign, "woodpecker-agent.service").Contents, "Requires=woodpecker-agent-setup.service\n")
+
+	post := unit(t, ign, "woodpecker-agent-post.service")
+	assert.True(t, post.Enabled)
+	assert.Contains(t, post.Contents, "After=network-online.target woodpecker-agent.service\n")
+}
+
+type testBootstrap struct{}
+
+func (testBootstrap) Issue(string, string) (string, error) {
+	return "test-nonce", nil
+}
+
+func (testBootstrap) URL() string {
+	return "https://autoscaler.example.com/bootstrap"
+}
+
+func TestRenderUserDataBootstrap(t *testing.T) {
+	userData, err := ignition.RenderUserData(&config.Config{Image: "agent", Bootstrap: testBootstrap{}},
+		&woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "test-token"}, cloudinit.RenderOption{})
+	require.NoError(t, err)
+
+	var ign ignition.Config
+	require.NoError(t, json.Unmarshal([]byte(userData), &ign))
+
+	assert.NotContains(t, fileContent(t, ign, "/etc/woodpecker/agent.env"), "WOODPECKER_AGENT_SECRET")
+	assert.Contains(t, fileContent(t, ign, "/etc/woodpecker/bootstrap.sh"),
+		"-d agent=pool-1-agent-abcd -d nonce=test-nonce 'https://autoscaler.example.com/bootstrap'")
+	assert.Contains(t, unit(t, ign, "woodpecker-agent.service").Contents, "--env-file /etc/woodpecker/token.env agent\n")
+}
+
+func TestRenderUserDataDockerDaemon(t *testing.T) {
+	config := &config.Config{
+		Image:  "woodpeckerci/woodpecker-agent:v3",
+		Docker: config.DockerDaemon{MTU: 1400},
+	}
+
+	ign := render(t, config, &woodpecker.Agent{}, cloudinit.RenderOption{})
+
+	assert.JSONEq(t, `{"mtu": 1400}`, fileContent(t, ign, cloudinit.DockerDaemonPath))
+}
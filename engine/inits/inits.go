@@ -0,0 +1,32 @@
+package inits
+
+import (
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/ignition"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Init systems the user data can be rendered for.
+const (
+	CloudInit = "cloudinit"
+	Ignition  = "ignition"
+)
+
+// Systems lists the supported init systems.
+var Systems = []string{CloudInit, Ignition}
+
+// RenderUserDataTemplate renders the user data for an Agent in the format of
+// the configured init system, cloud-init by default. The capacity the agent
+// will report is recorded in agent.Capacity, so the engine can account for it
+// before the agent connected. Providers trying several candidates render for
+// each, the one deployed last wins.
+func RenderUserDataTemplate(config *config.Config, agent *woodpecker.Agent, r cloudinit.RenderOption) (string, error) {
+	agent.Capacity = int32(cloudinit.MaxWorkflows(config, r))
+
+	if config.InitSystem == Ignition {
+		return ignition.RenderUserData(config, agent, r)
+	}
+
+	return cloudinit.RenderUserDataTemplate(config, agent, r)
+}
@@ -0,0 +1,58 @@
+package inits
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// previewBootstrap stands in for the bootstrap server while previewing, so
+// no nonce gets issued for the placeholder agent.
+type previewBootstrap struct {
+	url string
+}
+
+func (b previewBootstrap) Issue(_, _ string) (string, error) {
+	return "preview-nonce", nil
+}
+
+func (b previewBootstrap) URL() string {
+	return b.url
+}
+
+// PreviewUserData renders the user data for a placeholder agent of the pool
+// and validates it, so a broken template is noticed before agents never
+// connect. The rendered user data must not exceed limit bytes, unless limit
+// is 0.
+func PreviewUserData(cfg *config.Config, provider string, limit int) (string, error) {
+	preview := *cfg
+	if cfg.Bootstrap != nil {
+		preview.Bootstrap = previewBootstrap{url: cfg.Bootstrap.URL()}
+	}
+	agent := &woodpecker.Agent{
+		Name:  fmt.Sprintf("pool-%s-agent-preview", cfg.PoolID),
+		Token: "preview-token",
+	}
+
+	userData, err := RenderUserDataTemplate(&preview, agent, cloudinit.RenderOption{Provider: provider})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", cloudinit.ErrInvalidUserData, err)
+	}
+
+	if cfg.InitSystem == Ignition {
+		if !json.Valid([]byte(userData)) {
+			return "", fmt.Errorf("%w: ignition config is not valid JSON", cloudinit.ErrInvalidUserData)
+		}
+	} else if err := cloudinit.Validate(userData); err != nil {
+		return "", err
+	}
+
+	if limit > 0 && len(userData) > limit {
+		return "", fmt.Errorf("%w: rendered user data has %d bytes, %s accepts at most %d", cloudinit.ErrInvalidUserData, len(userData), provider, limit)
+	}
+
+	return userData, nil
+}
@@ -0,0 +1,61 @@
+package inits_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+)
+
+type fakeBootstrap struct{}
+
+func (fakeBootstrap) Issue(_, _ string) (string, error) {
+	panic("no nonce must be issued for a preview")
+}
+
+func (fakeBootstrap) URL() string {
+	return "https://autoscaler.example.com/bootstrap"
+}
+
+func TestPreviewUserData(t *testing.T) {
+	cfg := &config.Config{
+		PoolID:    "1",
+		Image:     "test-image",
+		Bootstrap: fakeBootstrap{},
+		UserData:  "#cloud-config\nfinal_message: {{ .AgentName }} {{ .Provider }} {{ .Bootstrap.URL }}",
+	}
+
+	userData, err := inits.PreviewUserData(cfg, "hetznercloud", 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, "#cloud-config\nfinal_message: pool-1-agent-preview hetznercloud https://autoscaler.example.com/bootstrap", userData)
+}
+
+func TestPreviewUserData_Invalid(t *testing.T) {
+	_, err := inits.PreviewUserData(&config.Config{UserData: "{{ .Unknown }}"}, "aws", 0)
+	assert.ErrorIs(t, err, cloudinit.ErrInvalidUserData)
+
+	_, err = inits.PreviewUserData(&config.Config{UserData: "#cloud-config\nruncmds: []"}, "aws", 0)
+	assert.ErrorIs(t, err, cloudinit.ErrInvalidUserData)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestPreviewUserData_Limit(t *testing.T) {
+	_, err := inits.PreviewUserData(&config.Config{}, "aws", 16<<10)
+	assert.NoError(t, err)
+
+	_, err = inits.PreviewUserData(&config.Config{}, "aws", 100)
+	assert.ErrorIs(t, err, cloudinit.ErrInvalidUserData)
+	assert.ErrorContains(t, err, "aws accepts at most 100")
+}
+
+func TestPreviewUserData_Ignition(t *testing.T) {
+	userData, err := inits.PreviewUserData(&config.Config{InitSystem: inits.Ignition}, "aws", 16<<10)
+
+	require.NoError(t, err)
+	assert.Contains(t, userData, `"version":"3.4.0"`)
+}
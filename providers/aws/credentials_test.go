@@ -30,7 +30,7 @@ func TestCredentialFlagsUseStandardAWSEnvironment(t *testing.T) {
 	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret-key")
 
 	cmd := &cli.Command{
-		Flags: ProviderFlags,
+		Flags: ProviderFlags(),
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			assert.Equal(t, "test-access-key", cmd.String("aws-access-key-id"))
 			assert.Equal(t, "test-secret-key", cmd.String("aws-secret-access-key"))
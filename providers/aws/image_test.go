@@ -57,7 +57,7 @@ func TestValidateImageReference(t *testing.T) {
 }
 
 func TestDefaultImageReferenceIsValid(t *testing.T) {
-	for _, flag := range ProviderFlags {
+	for _, flag := range ProviderFlags() {
 		imageFlag, ok := flag.(*cli.StringFlag)
 		if !ok || imageFlag.Name != "aws-ami-id" {
 			continue
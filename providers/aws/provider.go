@@ -19,6 +19,7 @@ import (
 
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/providers/aws/ec2api"
@@ -26,6 +27,10 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
 )
 
+// UserDataLimit is the maximum size of EC2 user data in bytes, before it is
+// base64 encoded.
+const UserDataLimit = 16 << 10
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/configuring-instance-metadata-service.html
 var blackholeMetadataAPI = []string{
@@ -173,13 +178,6 @@ func (p *provider) printResolvedConfig() {
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
-	})
-	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
-	}
-
 	// Generate base tags for instance
 	tags := []ec2_types.Tag{{
 		Key:   aws.String("Name"),
@@ -241,10 +239,14 @@ func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) err
 		runInstancesInput.KeyName = aws.String(p.sshKeyName)
 	}
 
-	runInstancesInput.UserData = aws.String(b64.StdEncoding.EncodeToString([]byte(userData)))
-
 	var result *ec2.RunInstancesOutput
 	for i, c := range p.deployCandidates {
+		userData, err := p.renderUserData(agent, c)
+		if err != nil {
+			return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+		}
+		runInstancesInput.UserData = aws.String(b64.StdEncoding.EncodeToString([]byte(userData)))
+
 		runInstancesInput.InstanceType = c.instanceType.InstanceType
 		runInstancesInput.ImageId = c.regionConfig.image.ImageId
 		runInstancesInput.SecurityGroupIds = c.regionConfig.securityGroups
@@ -310,6 +312,35 @@ func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) err
 	return fmt.Errorf("instance did not resolve in agent list: %s", *result.Instances[0].InstanceId)
 }
 
+// renderUserData renders the user data for the agent on the candidate.
+func (p *provider) renderUserData(agent *woodpecker.Agent, c deployCandidate) (string, error) {
+	return inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		OSFamily: cloudinit.DetectOSFamily(aws.ToString(c.regionConfig.image.Name)),
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: string(c.instanceType.InstanceType),
+			Region:       c.regionConfig.region,
+			Arch:         cloudinit.NormalizeArch(string(c.regionConfig.image.Architecture)),
+			Spot:         p.useSpotInstances,
+			Workflows:    p.workflows(c.instanceType),
+		},
+	})
+}
+
+// workflows returns the workflow capacity of an agent on the instance type.
+func (p *provider) workflows(t ec2_types.InstanceTypeInfo) int {
+	var cpus int
+	var memory int64
+	if t.VCpuInfo != nil {
+		cpus = int(aws.ToInt32(t.VCpuInfo.DefaultVCpus))
+	}
+	if t.MemoryInfo != nil {
+		memory = aws.ToInt64(t.MemoryInfo.SizeInMiB)
+	}
+	return p.config.Workflows(string(t.InstanceType), cpus, memory)
+}
+
 func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
 	instance, region, err := p.getAgent(ctx, agent)
 	if err != nil {
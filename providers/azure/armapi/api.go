@@ -0,0 +1,31 @@
+package armapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+)
+
+// Client is the subset of the Azure Resource Manager API the azure provider
+// uses, so it can be mocked in tests. Creating and deleting resources waits
+// until the operation has finished.
+type Client interface {
+	ListVMSizes(ctx context.Context, location string) ([]*armcompute.VirtualMachineSize, error)
+	CreateVirtualMachine(ctx context.Context, resourceGroup string, vm *armcompute.VirtualMachine) error
+	DeleteVirtualMachine(ctx context.Context, resourceGroup, name string) error
+	ListVirtualMachines(ctx context.Context, resourceGroup string) ([]*armcompute.VirtualMachine, error)
+	DeleteNetworkInterface(ctx context.Context, resourceGroup, name string) error
+	DeletePublicIPAddress(ctx context.Context, resourceGroup, name string) error
+	DeleteDisk(ctx context.Context, resourceGroup, name string) error
+}
+
+// IsError reports whether err is an error response of the API or the error
+// of a failed operation with one of the codes, e.g. ResourceNotFound or
+// AllocationFailed.
+func IsError(err error, codes ...string) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && slices.Contains(codes, respErr.ErrorCode)
+}
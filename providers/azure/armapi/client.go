@@ -0,0 +1,189 @@
+package armapi
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+const pollFrequency = 5 * time.Second
+
+// Credentials are tried before the DefaultAzureCredential chain of the
+// AZURE_ environment variables, workload identity, the managed identity of
+// the machine the autoscaler runs on and the Azure CLI: the service
+// principal if ClientSecret is set, else the user-assigned managed identity
+// ClientID if set.
+type Credentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+type client struct {
+	virtualMachines   *armcompute.VirtualMachinesClient
+	vmSizes           *armcompute.VirtualMachineSizesClient
+	disks             *armcompute.DisksClient
+	interfaces        *armnetwork.InterfacesClient
+	publicIPAddresses *armnetwork.PublicIPAddressesClient
+}
+
+// NewClient creates a client for the resources of the subscription.
+func NewClient(subscriptionID string, credentials Credentials) (Client, error) {
+	credential, err := tokenCredential(credentials)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithOptions(subscriptionID, credential, nil)
+}
+
+// tokenCredential chains the explicit credentials with the default ones.
+func tokenCredential(credentials Credentials) (azcore.TokenCredential, error) {
+	var sources []azcore.TokenCredential
+	switch {
+	case credentials.ClientSecret != "":
+		credential, err := azidentity.NewClientSecretCredential(credentials.TenantID, credentials.ClientID, credentials.ClientSecret, nil)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, credential)
+	case credentials.ClientID != "":
+		credential, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+			ID: azidentity.ClientID(credentials.ClientID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, credential)
+	}
+
+	defaultCredential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return defaultCredential, nil
+	}
+	return azidentity.NewChainedTokenCredential(append(sources, defaultCredential), nil)
+}
+
+// NewClientWithOptions creates a client authenticating with the credential
+// and the client options, e.g. of another cloud.
+func NewClientWithOptions(subscriptionID string, credential azcore.TokenCredential, options *arm.ClientOptions) (Client, error) {
+	var opts arm.ClientOptions
+	if options != nil {
+		opts = *options
+	}
+	opts.PerCallPolicies = append(slices.Clip(opts.PerCallPolicies), userAgentPolicy{})
+
+	compute, err := armcompute.NewClientFactory(subscriptionID, credential, &opts)
+	if err != nil {
+		return nil, err
+	}
+	network, err := armnetwork.NewClientFactory(subscriptionID, credential, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		virtualMachines:   compute.NewVirtualMachinesClient(),
+		vmSizes:           compute.NewVirtualMachineSizesClient(),
+		disks:             compute.NewDisksClient(),
+		interfaces:        network.NewInterfacesClient(),
+		publicIPAddresses: network.NewPublicIPAddressesClient(),
+	}, nil
+}
+
+// userAgentPolicy puts the autoscaler in front of the user agent of the sdk,
+// whose application id is cut off after 24 characters.
+type userAgentPolicy struct{}
+
+func (userAgentPolicy) Do(req *policy.Request) (*http.Response, error) {
+	header := req.Raw().Header
+	header.Set("User-Agent", "woodpecker-autoscaler/"+version.String()+" "+header.Get("User-Agent"))
+	return req.Next()
+}
+
+// wait polls the long-running operation until it has finished and returns
+// its error.
+func wait[T any](ctx context.Context, poller *runtime.Poller[T], err error) error {
+	if err != nil {
+		return err
+	}
+	_, err = poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{Frequency: pollFrequency})
+	return err
+}
+
+// deleted returns the error of a deletion, a resource that does not exist is
+// no error.
+func deleted(err error) error {
+	if IsError(err, "ResourceNotFound", "NotFound") {
+		return nil
+	}
+	return err
+}
+
+func (c *client) ListVMSizes(ctx context.Context, location string) ([]*armcompute.VirtualMachineSize, error) {
+	var sizes []*armcompute.VirtualMachineSize
+
+	pager := c.vmSizes.NewListPager(location, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, page.Value...)
+	}
+
+	return sizes, nil
+}
+
+func (c *client) CreateVirtualMachine(ctx context.Context, resourceGroup string, vm *armcompute.VirtualMachine) error {
+	poller, err := c.virtualMachines.BeginCreateOrUpdate(ctx, resourceGroup, *vm.Name, *vm, nil)
+	return wait(ctx, poller, err)
+}
+
+func (c *client) DeleteVirtualMachine(ctx context.Context, resourceGroup, name string) error {
+	poller, err := c.virtualMachines.BeginDelete(ctx, resourceGroup, name, nil)
+	return deleted(wait(ctx, poller, err))
+}
+
+func (c *client) ListVirtualMachines(ctx context.Context, resourceGroup string) ([]*armcompute.VirtualMachine, error) {
+	var vms []*armcompute.VirtualMachine
+
+	pager := c.virtualMachines.NewListPager(resourceGroup, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vms = append(vms, page.Value...)
+	}
+
+	return vms, nil
+}
+
+func (c *client) DeleteNetworkInterface(ctx context.Context, resourceGroup, name string) error {
+	poller, err := c.interfaces.BeginDelete(ctx, resourceGroup, name, nil)
+	return deleted(wait(ctx, poller, err))
+}
+
+func (c *client) DeletePublicIPAddress(ctx context.Context, resourceGroup, name string) error {
+	poller, err := c.publicIPAddresses.BeginDelete(ctx, resourceGroup, name, nil)
+	return deleted(wait(ctx, poller, err))
+}
+
+func (c *client) DeleteDisk(ctx context.Context, resourceGroup, name string) error {
+	poller, err := c.disks.BeginDelete(ctx, resourceGroup, name, nil)
+	return deleted(wait(ctx, poller, err))
+}
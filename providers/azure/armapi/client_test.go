@@ -0,0 +1,105 @@
+package armapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/providers/azure/armapi"
+)
+
+const vmPath = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/agent"
+
+func newClient(t *testing.T, server *httptest.Server) armapi.Client {
+	client, err := armapi.NewClientWithOptions("sub", &fake.TokenCredential{}, &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud: cloud.Configuration{Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {Endpoint: server.URL, Audience: "https://management.azure.com"},
+			}},
+			Transport: server.Client(),
+		},
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestCreateVirtualMachine(t *testing.T) {
+	var server *httptest.Server
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT "+vmPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("User-Agent"), "woodpecker-autoscaler/")
+		w.Header().Set("Azure-AsyncOperation", server.URL+"/operations/1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"name": "agent", "properties": {"provisioningState": "Creating"}}`))
+	})
+	mux.HandleFunc("GET /operations/1", func(w http.ResponseWriter, _ *http.Request) {
+		polls++
+		_, _ = w.Write([]byte(`{"status": "Failed", "error": {"code": "AllocationFailed", "message": "no capacity"}}`))
+	})
+	server = httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	err := newClient(t, server).CreateVirtualMachine(t.Context(), "rg", &armcompute.VirtualMachine{Name: to.Ptr("agent")})
+
+	assert.True(t, armapi.IsError(err, "AllocationFailed"))
+	assert.Equal(t, 1, polls)
+}
+
+func TestDeleteVirtualMachine(t *testing.T) {
+	var server *httptest.Server
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE "+vmPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", server.URL+"/locations/1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /locations/1", func(w http.ResponseWriter, _ *http.Request) {
+		polls++
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server = httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	assert.NoError(t, newClient(t, server).DeleteVirtualMachine(t.Context(), "rg", "agent"))
+	assert.Equal(t, 1, polls)
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": {"code": "ResourceNotFound", "message": "not found"}}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, newClient(t, server).DeleteDisk(t.Context(), "rg", "agent-osdisk"))
+}
+
+func TestListVirtualMachines(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			_, _ = w.Write([]byte(`{"value": [{"name": "a"}], "nextLink": "` + server.URL + `/next?page=2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"value": [{"name": "b", "tags": {"pool": "1"}}]}`))
+	}))
+	defer server.Close()
+
+	vms, err := newClient(t, server).ListVirtualMachines(t.Context(), "rg")
+
+	assert.NoError(t, err)
+	if assert.Len(t, vms, 2) {
+		assert.Equal(t, "a", *vms[0].Name)
+		assert.Equal(t, "1", *vms[1].Tags["pool"])
+	}
+}
@@ -0,0 +1,136 @@
+package azure
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+const category = "Azure"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "azure-subscription-id",
+			Usage:    "azure subscription the virtual machines are created in",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_SUBSCRIPTION_ID", "AZURE_SUBSCRIPTION_ID"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-tenant-id",
+			Usage:    "microsoft entra tenant of the service principal",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_TENANT_ID", "AZURE_TENANT_ID"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-client-id",
+			Usage:    "client id of the service principal or of a user-assigned managed identity",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_CLIENT_ID", "AZURE_CLIENT_ID"),
+			Category: category,
+		},
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "azure-client-secret",
+			Usage:    "client secret of the service principal, the managed identity or the default azure credentials are used without",
+			Sources:  config.SecretSources("WOODPECKER_AZURE_CLIENT_SECRET", "AZURE_CLIENT_SECRET"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "azure-resource-group",
+			Usage:    "resource group of the virtual machines",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_RESOURCE_GROUP"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-location",
+			Value:    "westeurope",
+			Usage:    "azure location of the virtual machines",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_LOCATION"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "azure-vm-size",
+			Value:    []string{"Standard_B2s"},
+			Usage:    "vm sizes, optionally with availability zone as size:zone, later entries are fallbacks if there is no capacity",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_VM_SIZE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-image",
+			Value:    "Canonical:ubuntu-24_04-lts:server:latest",
+			Usage:    "marketplace image as publisher:offer:sku:version or the resource id of an image",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_IMAGE"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "azure-disk-size",
+			Value:    30, //nolint:mnd
+			Usage:    "os disk size in GB",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_DISK_SIZE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-disk-type",
+			Value:    "StandardSSD_LRS",
+			Usage:    "storage account type of the os disk",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_DISK_TYPE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-subnet-id",
+			Usage:    "resource id of the subnet the virtual machines are attached to",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_SUBNET_ID"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-network-security-group-id",
+			Usage:    "resource id of a network security group for the network interfaces",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_NETWORK_SECURITY_GROUP_ID"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "azure-public-ip",
+			Value:    true,
+			Usage:    "assign a public ip to the virtual machines",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_PUBLIC_IP"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-scale-set-id",
+			Usage:    "resource id of a virtual machine scale set in flexible orchestration the virtual machines join",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_SCALE_SET_ID"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "azure-spot",
+			Usage:    "create spot virtual machines, which are deleted when evicted",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_SPOT"),
+			Category: category,
+		},
+		&cli.FloatFlag{
+			Name:     "azure-spot-max-price",
+			Value:    -1,
+			Usage:    "maximum hourly price of spot virtual machines in USD, -1 pays up to the on-demand price",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_SPOT_MAX_PRICE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-admin-username",
+			Value:    "woodpecker",
+			Usage:    "name of the admin user of the virtual machines",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_ADMIN_USERNAME"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "azure-ssh-public-key",
+			Usage:    "ssh public key of the admin user, a throwaway key is used without",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_SSH_PUBLIC_KEY"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "azure-tags",
+			Usage:    "virtual machine tags as key=value",
+			Sources:  cli.EnvVars("WOODPECKER_AZURE_TAGS"),
+			Category: category,
+		},
+	}
+}
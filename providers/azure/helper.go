@@ -0,0 +1,104 @@
+package azure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"golang.org/x/crypto/ssh"
+)
+
+const imageURNParts = 4
+
+// names of the resources created along with a virtual machine
+func nicName(vm string) string  { return vm + "-nic" }
+func ipName(vm string) string   { return vm + "-ip" }
+func diskName(vm string) string { return vm + "-osdisk" }
+
+// value returns what the pointer of the api points to, or the zero value.
+func value[T any](ptr *T) T {
+	if ptr == nil {
+		var zero T
+		return zero
+	}
+	return *ptr
+}
+
+func (p *provider) resolveDeployCandidates(ctx context.Context, vmSizes []string) error {
+	sizes, err := p.client.ListVMSizes(ctx, p.location)
+	if err != nil {
+		return fmt.Errorf("%s: ListVMSizes: %w", p.name, err)
+	}
+
+	for _, raw := range vmSizes {
+		name, zone, _ := strings.Cut(raw, ":")
+
+		var size *armcompute.VirtualMachineSize
+		for _, s := range sizes {
+			if strings.EqualFold(value(s.Name), name) {
+				size = s
+				break
+			}
+		}
+		if size == nil {
+			return fmt.Errorf("%s: %w: %s in %s", p.name, ErrVMSizeNotFound, name, p.location)
+		}
+
+		p.deployCandidates = append(p.deployCandidates, deployCandidate{size: size, zone: zone})
+	}
+
+	if len(p.deployCandidates) == 0 {
+		return fmt.Errorf("no deploy candidates resolved")
+	}
+
+	return nil
+}
+
+// parseImage parses a marketplace image urn or an image resource id.
+func parseImage(raw string) (*armcompute.ImageReference, error) {
+	if strings.HasPrefix(raw, "/") {
+		return &armcompute.ImageReference{ID: to.Ptr(raw)}, nil
+	}
+
+	parts := strings.Split(raw, ":")
+	if len(parts) != imageURNParts || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidImage, raw)
+	}
+	return &armcompute.ImageReference{
+		Publisher: to.Ptr(parts[0]),
+		Offer:     to.Ptr(parts[1]),
+		SKU:       to.Ptr(parts[2]),
+		Version:   to.Ptr(parts[3]),
+	}, nil
+}
+
+// imageArch returns the architecture of a marketplace image, arm64 images
+// say so in their sku. The architecture of image ids is unknown.
+func imageArch(image *armcompute.ImageReference) string {
+	if image.ID != nil {
+		return ""
+	}
+	if strings.Contains(strings.ToLower(value(image.SKU)), "arm64") {
+		return "arm64"
+	}
+	return "amd64"
+}
+
+// throwawaySSHKey returns a public key nobody has the private key of, as
+// linux virtual machines require an ssh key or a password.
+func throwawaySSHKey() (string, error) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))), nil
+}
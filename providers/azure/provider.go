@@ -0,0 +1,329 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/azure/armapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// UserDataLimit is the maximum size of custom data in bytes, azure accepts
+// 65535 bytes after base64 encoding.
+const UserDataLimit = 65535 / 4 * 3
+
+// Override because Azure does not allow "/" in tag names
+const (
+	labelPrefix = "wp.autoscaler-"
+	labelPool   = labelPrefix + "pool"
+	labelImage  = labelPrefix + "image"
+)
+
+// errors of a location or zone without capacity for the vm size
+var noCapacity = []string{
+	"SkuNotAvailable",
+	"AllocationFailed",
+	"ZonalAllocationFailed",
+	"OverconstrainedAllocationRequest",
+	"OverconstrainedZonalAllocationRequest",
+}
+
+// blackhole metadata services so running steps can not extract agent token from user-data
+// https://learn.microsoft.com/en-us/azure/virtual-machines/instance-metadata-service
+var blackholeMetadataAPI = []string{
+	"ip -4 route add blackhole 169.254.169.254/32",
+}
+
+type provider struct {
+	name                 string
+	resourceGroup        string
+	location             string
+	deployCandidates     []deployCandidate
+	image                *armcompute.ImageReference
+	diskSize             int
+	diskType             string
+	subnetID             string
+	networkSecurityGroup string
+	publicIP             bool
+	scaleSetID           string
+	spot                 bool
+	spotMaxPrice         float64
+	adminUsername        string
+	sshPublicKey         string
+	tags                 map[string]string
+	config               *config.Config
+	client               armapi.Client
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:                 "azure",
+		resourceGroup:        c.String("azure-resource-group"),
+		location:             c.String("azure-location"),
+		diskSize:             c.Int("azure-disk-size"),
+		diskType:             c.String("azure-disk-type"),
+		subnetID:             c.String("azure-subnet-id"),
+		networkSecurityGroup: c.String("azure-network-security-group-id"),
+		publicIP:             c.Bool("azure-public-ip"),
+		scaleSetID:           c.String("azure-scale-set-id"),
+		spot:                 c.Bool("azure-spot"),
+		spotMaxPrice:         c.Float("azure-spot-max-price"),
+		adminUsername:        c.String("azure-admin-username"),
+		sshPublicKey:         c.String("azure-ssh-public-key"),
+		config:               config,
+	}
+
+	subscriptionID := c.String("azure-subscription-id")
+	for _, flag := range []string{"azure-subscription-id", "azure-resource-group", "azure-subnet-id"} {
+		if c.String(flag) == "" {
+			return nil, fmt.Errorf("%s: %w: %s", p.name, ErrMissingSetting, flag)
+		}
+	}
+
+	image, err := parseImage(c.String("azure-image"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.image = image
+
+	if p.sshPublicKey == "" {
+		if p.sshPublicKey, err = throwawaySSHKey(); err != nil {
+			return nil, fmt.Errorf("%s: generating ssh key: %w", p.name, err)
+		}
+	}
+
+	p.client, err = armapi.NewClient(subscriptionID, armapi.Credentials{
+		TenantID:     c.String("azure-tenant-id"),
+		ClientID:     c.String("azure-client-id"),
+		ClientSecret: c.String("azure-client-secret"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: armapi.NewClient: %w", p.name, err)
+	}
+
+	if err := p.resolveDeployCandidates(ctx, c.StringSlice("azure-vm-size")); err != nil {
+		return nil, err
+	}
+
+	userTags := c.StringSlice("azure-tags")
+	if err := utils.CheckReservedTags(userTags, labelPrefix, ErrIllegalLabelPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	tags, err := utils.SliceToMap(userTags, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	p.tags = utils.MergeMaps(tags, map[string]string{
+		labelPool:  p.config.PoolID,
+		labelImage: c.String("azure-image"),
+	})
+
+	return p, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	for i, c := range p.deployCandidates {
+		userData, err := p.renderUserData(agent, c)
+		if err != nil {
+			return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+		}
+
+		log.Info().Msgf("create agent: location = %s zone = %s size = %s", p.location, c.zone, value(c.size.Name))
+
+		err = p.client.CreateVirtualMachine(ctx, p.resourceGroup, p.virtualMachine(agent, c, userData))
+		if err == nil {
+			return nil
+		}
+
+		// a failed virtual machine stays around with its network interface
+		if cleanupErr := p.removeResources(ctx, agent.Name); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+
+		// Continue to next fallback entry only if there is no capacity.
+		if !armapi.IsError(err, noCapacity...) {
+			return fmt.Errorf("%s: CreateVirtualMachine: %w", p.name, err)
+		}
+
+		// Only log and continue if there are more candidates left.
+		if i < len(p.deployCandidates)-1 {
+			log.Warn().Msgf("create agent failed: location = %s zone = %s size = %s: %s", p.location, c.zone, value(c.size.Name), err)
+			continue
+		}
+
+		// Last candidate failed.
+		return fmt.Errorf("%s: CreateVirtualMachine: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// virtualMachine returns the virtual machine of the agent on the candidate.
+// Its network interface, public ip and os disk are deleted along with it.
+func (p *provider) virtualMachine(agent *woodpecker.Agent, c deployCandidate, userData string) *armcompute.VirtualMachine {
+	ipConfiguration := &armcompute.VirtualMachineNetworkInterfaceIPConfigurationProperties{
+		Subnet: &armcompute.SubResource{ID: to.Ptr(p.subnetID)},
+	}
+	if p.publicIP {
+		ipConfiguration.PublicIPAddressConfiguration = &armcompute.VirtualMachinePublicIPAddressConfiguration{
+			Name: to.Ptr(ipName(agent.Name)),
+			SKU:  &armcompute.PublicIPAddressSKU{Name: to.Ptr(armcompute.PublicIPAddressSKUNameStandard)},
+			Properties: &armcompute.VirtualMachinePublicIPAddressConfigurationProperties{
+				DeleteOption:             to.Ptr(armcompute.DeleteOptionsDelete),
+				PublicIPAllocationMethod: to.Ptr(armcompute.PublicIPAllocationMethodStatic),
+			},
+		}
+	}
+
+	nic := &armcompute.VirtualMachineNetworkInterfaceConfigurationProperties{
+		Primary:      to.Ptr(true),
+		DeleteOption: to.Ptr(armcompute.DeleteOptionsDelete),
+		IPConfigurations: []*armcompute.VirtualMachineNetworkInterfaceIPConfiguration{{
+			Name:       to.Ptr("ipconfig1"),
+			Properties: ipConfiguration,
+		}},
+	}
+	if p.networkSecurityGroup != "" {
+		nic.NetworkSecurityGroup = &armcompute.SubResource{ID: to.Ptr(p.networkSecurityGroup)}
+	}
+
+	tags := make(map[string]*string, len(p.tags))
+	for key, value := range p.tags {
+		tags[key] = to.Ptr(value)
+	}
+
+	vm := &armcompute.VirtualMachine{
+		Name:     to.Ptr(agent.Name),
+		Location: to.Ptr(p.location),
+		Tags:     tags,
+		Properties: &armcompute.VirtualMachineProperties{
+			HardwareProfile: &armcompute.HardwareProfile{VMSize: to.Ptr(armcompute.VirtualMachineSizeTypes(value(c.size.Name)))},
+			StorageProfile: &armcompute.StorageProfile{
+				ImageReference: p.image,
+				OSDisk: &armcompute.OSDisk{
+					Name:         to.Ptr(diskName(agent.Name)),
+					CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesFromImage),
+					DeleteOption: to.Ptr(armcompute.DiskDeleteOptionTypesDelete),
+					DiskSizeGB:   to.Ptr(int32(p.diskSize)),
+					ManagedDisk:  &armcompute.ManagedDiskParameters{StorageAccountType: to.Ptr(armcompute.StorageAccountTypes(p.diskType))},
+				},
+			},
+			OSProfile: &armcompute.OSProfile{
+				ComputerName:  to.Ptr(agent.Name),
+				AdminUsername: to.Ptr(p.adminUsername),
+				CustomData:    to.Ptr(base64.StdEncoding.EncodeToString([]byte(userData))),
+				LinuxConfiguration: &armcompute.LinuxConfiguration{
+					DisablePasswordAuthentication: to.Ptr(true),
+					SSH: &armcompute.SSHConfiguration{PublicKeys: []*armcompute.SSHPublicKey{{
+						Path:    to.Ptr(fmt.Sprintf("/home/%s/.ssh/authorized_keys", p.adminUsername)),
+						KeyData: to.Ptr(p.sshPublicKey),
+					}}},
+				},
+			},
+			NetworkProfile: &armcompute.NetworkProfile{
+				NetworkAPIVersion: to.Ptr(armcompute.NetworkAPIVersionTwoThousandTwenty1101),
+				NetworkInterfaceConfigurations: []*armcompute.VirtualMachineNetworkInterfaceConfiguration{{
+					Name:       to.Ptr(nicName(agent.Name)),
+					Properties: nic,
+				}},
+			},
+		},
+	}
+
+	if c.zone != "" {
+		vm.Zones = []*string{to.Ptr(c.zone)}
+	}
+
+	if p.scaleSetID != "" {
+		vm.Properties.VirtualMachineScaleSet = &armcompute.SubResource{ID: to.Ptr(p.scaleSetID)}
+	}
+
+	if p.spot {
+		vm.Properties.Priority = to.Ptr(armcompute.VirtualMachinePriorityTypesSpot)
+		vm.Properties.EvictionPolicy = to.Ptr(armcompute.VirtualMachineEvictionPolicyTypesDelete)
+		vm.Properties.BillingProfile = &armcompute.BillingProfile{MaxPrice: to.Ptr(p.spotMaxPrice)}
+	}
+
+	return vm
+}
+
+// renderUserData renders the user data for the agent on the candidate.
+func (p *provider) renderUserData(agent *woodpecker.Agent, c deployCandidate) (string, error) {
+	region := p.location
+	if c.zone != "" {
+		region += "-" + c.zone
+	}
+	return inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		OSFamily: cloudinit.DetectOSFamily(value(p.image.ID) + " " + value(p.image.Offer) + " " + value(p.image.SKU)),
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: value(c.size.Name),
+			Region:       region,
+			Arch:         imageArch(p.image),
+			Spot:         p.spot,
+			Workflows:    p.config.Workflows(value(c.size.Name), int(value(c.size.NumberOfCores)), int64(value(c.size.MemoryInMB))),
+		},
+	})
+}
+
+// removeResources deletes the virtual machine and whatever it leaves behind:
+// resources of virtual machines that failed to be created or were not
+// created with deletion of their resources.
+func (p *provider) removeResources(ctx context.Context, name string) error {
+	if err := p.client.DeleteVirtualMachine(ctx, p.resourceGroup, name); err != nil {
+		return fmt.Errorf("DeleteVirtualMachine: %w", err)
+	}
+	// the network interface holds the public ip
+	if err := p.client.DeleteNetworkInterface(ctx, p.resourceGroup, nicName(name)); err != nil {
+		return fmt.Errorf("DeleteNetworkInterface: %w", err)
+	}
+	if err := p.client.DeletePublicIPAddress(ctx, p.resourceGroup, ipName(name)); err != nil {
+		return fmt.Errorf("DeletePublicIPAddress: %w", err)
+	}
+	if err := p.client.DeleteDisk(ctx, p.resourceGroup, diskName(name)); err != nil {
+		return fmt.Errorf("DeleteDisk: %w", err)
+	}
+	return nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	if err := p.removeResources(ctx, agent.Name); err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var names []string
+
+	vms, err := p.client.ListVirtualMachines(ctx, p.resourceGroup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListVirtualMachines: %w", p.name, err)
+	}
+
+	for _, vm := range vms {
+		if value(vm.Tags[labelPool]) == p.config.PoolID {
+			names = append(names, value(vm.Name))
+		}
+	}
+
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
@@ -0,0 +1,180 @@
+package azure
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/azure/armapi"
+	"go.woodpecker-ci.org/autoscaler/providers/azure/armapi/mocks"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func newTestProvider(client armapi.Client) *provider {
+	return &provider{
+		name:          "azure",
+		resourceGroup: "rg",
+		location:      "westeurope",
+		image: &armcompute.ImageReference{
+			Publisher: to.Ptr("Canonical"),
+			Offer:     to.Ptr("ubuntu-24_04-lts"),
+			SKU:       to.Ptr("server"),
+			Version:   to.Ptr("latest"),
+		},
+		subnetID:      "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/agents",
+		adminUsername: "woodpecker",
+		tags:          map[string]string{labelPool: "1"},
+		config:        &config.Config{PoolID: "1"},
+		client:        client,
+	}
+}
+
+func newVMSize(name string, cores, memoryInMB int32) *armcompute.VirtualMachineSize {
+	return &armcompute.VirtualMachineSize{Name: to.Ptr(name), NumberOfCores: to.Ptr(cores), MemoryInMB: to.Ptr(memoryInMB)}
+}
+
+func expectRemoveResources(mockClient *mocks.MockClient, name string) {
+	mockClient.On("DeleteVirtualMachine", mock.Anything, "rg", name).Return(nil).Once()
+	mockClient.On("DeleteNetworkInterface", mock.Anything, "rg", name+"-nic").Return(nil).Once()
+	mockClient.On("DeletePublicIPAddress", mock.Anything, "rg", name+"-ip").Return(nil).Once()
+	mockClient.On("DeleteDisk", mock.Anything, "rg", name+"-osdisk").Return(nil).Once()
+}
+
+func TestResolveDeployCandidates(t *testing.T) {
+	mockClient := mocks.NewMockClient(t)
+	mockClient.On("ListVMSizes", mock.Anything, "westeurope").Return([]*armcompute.VirtualMachineSize{
+		newVMSize("Standard_D2s_v5", 2, 8192),
+		newVMSize("Standard_D4s_v5", 4, 16384),
+	}, nil).Twice()
+
+	p := newTestProvider(mockClient)
+	err := p.resolveDeployCandidates(t.Context(), []string{"standard_d4s_v5:1", "Standard_D2s_v5"})
+	assert.NoError(t, err)
+	if assert.Len(t, p.deployCandidates, 2) {
+		assert.Equal(t, "Standard_D4s_v5", *p.deployCandidates[0].size.Name)
+		assert.Equal(t, "1", p.deployCandidates[0].zone)
+		assert.Empty(t, p.deployCandidates[1].zone)
+	}
+
+	p = newTestProvider(mockClient)
+	err = p.resolveDeployCandidates(t.Context(), []string{"Standard_B2s"})
+	assert.ErrorIs(t, err, ErrVMSizeNotFound)
+}
+
+func TestDeployAgent(t *testing.T) {
+	candidates := []deployCandidate{
+		{size: newVMSize("Standard_D2s_v5", 2, 8192), zone: "1"},
+		{size: newVMSize("Standard_D2as_v5", 2, 8192), zone: "2"},
+	}
+
+	t.Run("FallbackOnAllocationFailure", func(t *testing.T) {
+		mockClient := mocks.NewMockClient(t)
+		mockClient.On("CreateVirtualMachine", mock.Anything, "rg", mock.MatchedBy(func(vm *armcompute.VirtualMachine) bool {
+			return *vm.Properties.HardwareProfile.VMSize == "Standard_D2s_v5"
+		})).Return(&azcore.ResponseError{ErrorCode: "ZonalAllocationFailed"}).Once()
+		// the failed virtual machine is cleaned up before the next candidate
+		expectRemoveResources(mockClient, "pool-1-agent-abcd")
+		mockClient.On("CreateVirtualMachine", mock.Anything, "rg", mock.MatchedBy(func(vm *armcompute.VirtualMachine) bool {
+			userData, _ := base64.StdEncoding.DecodeString(*vm.Properties.OSProfile.CustomData)
+			nic := vm.Properties.NetworkProfile.NetworkInterfaceConfigurations[0]
+			return *vm.Properties.HardwareProfile.VMSize == "Standard_D2as_v5" &&
+				*vm.Zones[0] == "2" &&
+				*vm.Tags[labelPool] == "1" &&
+				len(userData) > 0 &&
+				*vm.Properties.StorageProfile.OSDisk.Name == "pool-1-agent-abcd-osdisk" &&
+				*vm.Properties.StorageProfile.OSDisk.DeleteOption == armcompute.DiskDeleteOptionTypesDelete &&
+				*nic.Name == "pool-1-agent-abcd-nic" &&
+				*nic.Properties.DeleteOption == armcompute.DeleteOptionsDelete &&
+				nic.Properties.IPConfigurations[0].Properties.PublicIPAddressConfiguration == nil &&
+				vm.Properties.Priority == nil
+		})).Return(nil).Once()
+
+		p := newTestProvider(mockClient)
+		p.deployCandidates = candidates
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("OtherErrorsDoNotFallBack", func(t *testing.T) {
+		mockClient := mocks.NewMockClient(t)
+		mockClient.On("CreateVirtualMachine", mock.Anything, "rg", mock.Anything).
+			Return(&azcore.ResponseError{StatusCode: 403, ErrorCode: "AuthorizationFailed"}).Once()
+		expectRemoveResources(mockClient, "pool-1-agent-abcd")
+
+		p := newTestProvider(mockClient)
+		p.deployCandidates = candidates
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.True(t, armapi.IsError(err, "AuthorizationFailed"))
+	})
+
+	t.Run("Spot", func(t *testing.T) {
+		mockClient := mocks.NewMockClient(t)
+		mockClient.On("CreateVirtualMachine", mock.Anything, "rg", mock.MatchedBy(func(vm *armcompute.VirtualMachine) bool {
+			ip := vm.Properties.NetworkProfile.NetworkInterfaceConfigurations[0].Properties.IPConfigurations[0].Properties.PublicIPAddressConfiguration
+			return *vm.Properties.Priority == armcompute.VirtualMachinePriorityTypesSpot &&
+				*vm.Properties.EvictionPolicy == armcompute.VirtualMachineEvictionPolicyTypesDelete &&
+				*vm.Properties.BillingProfile.MaxPrice == -1 &&
+				*vm.Properties.VirtualMachineScaleSet.ID == "vmss" &&
+				*ip.Name == "pool-1-agent-abcd-ip" &&
+				*ip.Properties.DeleteOption == armcompute.DeleteOptionsDelete
+		})).Return(nil).Once()
+
+		p := newTestProvider(mockClient)
+		p.deployCandidates = candidates[:1]
+		p.spot = true
+		p.spotMaxPrice = -1
+		p.publicIP = true
+		p.scaleSetID = "vmss"
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestRemoveAgent(t *testing.T) {
+	mockClient := mocks.NewMockClient(t)
+	expectRemoveResources(mockClient, "pool-1-agent-abcd")
+
+	err := newTestProvider(mockClient).RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+	assert.NoError(t, err)
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	mockClient := mocks.NewMockClient(t)
+	mockClient.On("ListVirtualMachines", mock.Anything, "rg").Return([]*armcompute.VirtualMachine{
+		{Name: to.Ptr("pool-1-agent-abcd"), Tags: map[string]*string{labelPool: to.Ptr("1")}},
+		{Name: to.Ptr("pool-2-agent-efgh"), Tags: map[string]*string{labelPool: to.Ptr("2")}},
+		{Name: to.Ptr("database")},
+	}, nil)
+
+	names, err := newTestProvider(mockClient).ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-abcd"}, names)
+}
+
+func TestParseImage(t *testing.T) {
+	image, err := parseImage("Canonical:ubuntu-24_04-lts:server-arm64:latest")
+	assert.NoError(t, err)
+	assert.Equal(t, &armcompute.ImageReference{
+		Publisher: to.Ptr("Canonical"),
+		Offer:     to.Ptr("ubuntu-24_04-lts"),
+		SKU:       to.Ptr("server-arm64"),
+		Version:   to.Ptr("latest"),
+	}, image)
+	assert.Equal(t, "arm64", imageArch(image))
+
+	image, err = parseImage("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/images/agent")
+	assert.NoError(t, err)
+	assert.Empty(t, imageArch(image))
+
+	_, err = parseImage("Canonical:ubuntu-24_04-lts")
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
@@ -0,0 +1,19 @@
+package azure
+
+import (
+	"errors"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+)
+
+var (
+	ErrIllegalLabelPrefix = errors.New("illegal label prefix")
+	ErrMissingSetting     = errors.New("missing setting")
+	ErrVMSizeNotFound     = errors.New("vm size not available in location")
+	ErrInvalidImage       = errors.New("image must be a publisher:offer:sku:version urn or an image id")
+)
+
+type deployCandidate struct {
+	size *armcompute.VirtualMachineSize
+	zone string
+}
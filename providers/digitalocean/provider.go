@@ -15,6 +15,7 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/utils"
@@ -46,6 +47,9 @@ const autoSSHKeyName = "random-autoscaler-key"
 
 var invalidTagPart = regexp.MustCompile(`[^a-z0-9:_-]+`)
 
+// UserDataLimit is the maximum size of droplet user data in bytes.
+const UserDataLimit = 64 << 10
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://docs.digitalocean.com/products/droplets/how-to/access-metadata/ (served over IPv4 169.254.169.254 only)
 var blackholeMetadataAPI = []string{
@@ -120,11 +124,18 @@ func newProviderWithClient(ctx context.Context, c *cli.Command, config *config.C
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		OSFamily: cloudinit.DetectOSFamily(p.image.Distribution),
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: p.size.Slug,
+			Region:       p.region.Slug,
+			Workflows:    p.config.Workflows(p.size.Slug, p.size.Vcpus, int64(p.size.Memory)),
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
 	}
 
 	req := &godo.DropletCreateRequest{
@@ -48,7 +48,7 @@ func TestNewResolvesConfigAndUsesExistingAutoSSHKey(t *testing.T) {
 		},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-region=nyc1",
 		"--digitalocean-size=s-1vcpu-1gb",
@@ -80,7 +80,7 @@ func TestNewCreatesAutoSSHKeyWhenNoneConfigured(t *testing.T) {
 		},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{"--digitalocean-api-token=token"})
+	cmd := newTestCommand(t, ProviderFlags(), []string{"--digitalocean-api-token=token"})
 
 	p, err := newWithClient(t.Context(), cmd, &config.Config{PoolID: "pool-1"}, newTestClient(t, api))
 	require.NoError(t, err)
@@ -102,7 +102,7 @@ func TestNewFailsOnUnknownConfiguredSSHKey(t *testing.T) {
 		sshKeys: []godo.Key{{Name: "build", Fingerprint: "11:22"}},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-ssh-keys=missing",
 	})
@@ -136,7 +136,7 @@ func TestNewSizeNotAvailable(t *testing.T) {
 				sizes:   tt.sizes,
 			})
 
-			cmd := newTestCommand(t, ProviderFlags, []string{"--digitalocean-api-token=token"})
+			cmd := newTestCommand(t, ProviderFlags(), []string{"--digitalocean-api-token=token"})
 
 			_, err := newWithClient(t.Context(), cmd, &config.Config{PoolID: "pool-1"}, newTestClient(t, api))
 			require.ErrorIs(t, err, ErrSizeNotAvailable)
@@ -147,7 +147,7 @@ func TestNewSizeNotAvailable(t *testing.T) {
 
 func TestNewRejectsIPv6WithoutIPv4(t *testing.T) {
 	// public IPv6 defaults to true, so disabling only IPv4 must fail fast
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-public-ipv4-enable=false",
 	})
@@ -159,7 +159,7 @@ func TestNewRejectsIPv6WithoutIPv4(t *testing.T) {
 func TestNewRejectsReservedTags(t *testing.T) {
 	// a tag in the autoscaler's own namespace could claim another pool's
 	// droplets, which listPoolDroplets would then hand to this pool
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-tags=" + poolTag("other-pool"),
 	})
@@ -179,7 +179,7 @@ func TestNewResolvesConfiguredSSHKeys(t *testing.T) {
 		},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-ssh-keys=deploy",
 		"--digitalocean-ssh-keys=11:22",
@@ -205,7 +205,7 @@ func TestDeployAgentCreatesDroplet(t *testing.T) {
 		},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-tags=team-ci",
 	})
@@ -234,6 +234,7 @@ func TestDeployAgentCreatesDroplet(t *testing.T) {
 	assert.Contains(t, created.Tags, "wp-autoscaler-pool-pool-1")
 	assert.NotEmpty(t, created.UserData)
 	assert.Contains(t, created.UserData, "ip -4 route add blackhole 169.254.169.254/32")
+	assert.Contains(t, created.UserData, "WOODPECKER_AGENT_LABELS=autoscaler.instance_type=s-1vcpu-1gb,autoscaler.market=on-demand,autoscaler.provider=digitalocean,autoscaler.region=nyc1")
 }
 
 func TestDeployAgentWithoutIPv6(t *testing.T) {
@@ -248,7 +249,7 @@ func TestDeployAgentWithoutIPv6(t *testing.T) {
 		},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-public-ipv6-enable=false",
 	})
@@ -283,7 +284,7 @@ func TestDeployAgentPrivateDroplet(t *testing.T) {
 		},
 	})
 
-	cmd := newTestCommand(t, ProviderFlags, []string{
+	cmd := newTestCommand(t, ProviderFlags(), []string{
 		"--digitalocean-api-token=token",
 		"--digitalocean-public-ipv4-enable=false",
 		"--digitalocean-public-ipv6-enable=false",
@@ -357,7 +358,7 @@ func TestNewNATGatewayValidation(t *testing.T) {
 				natGateways: tt.natGateways,
 			})
 
-			cmd := newTestCommand(t, ProviderFlags, append([]string{"--digitalocean-api-token=token"}, tt.args...))
+			cmd := newTestCommand(t, ProviderFlags(), append([]string{"--digitalocean-api-token=token"}, tt.args...))
 
 			_, err := newWithClient(t.Context(), cmd, &config.Config{PoolID: "pool-1"}, newTestClient(t, api))
 			require.ErrorIs(t, err, tt.wantErr)
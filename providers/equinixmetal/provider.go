@@ -12,6 +12,7 @@ import (
 
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/utils"
@@ -34,6 +35,9 @@ var (
 	ErrReservedTagPrefix     = errors.New("illegal tag prefix")
 )
 
+// UserDataLimit is the maximum size of device user data in bytes.
+const UserDataLimit = 64 << 10
+
 // blackhole metadata services so running steps can not extract agent token from user-data.
 // Equinix Metal serves metadata over the routable host metadata.platformequinix.com
 // (not a link-local IP), so resolve it at runtime and blackhole every resolved address
@@ -169,11 +173,18 @@ func (p *provider) validate() error {
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: p.primaryPlan(),
+			Region:       p.metro,
+			Spot:         p.spotInstance,
+			Workflows:    p.config.Workflows(p.primaryPlan(), 0, 0),
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
 	}
 
 	_, err = p.devices.Create(ctx, p.projectID, deviceCreateRequest{
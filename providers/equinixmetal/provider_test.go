@@ -56,6 +56,7 @@ func TestDeployAgentInvalidUserData(t *testing.T) {
 	p := &provider{
 		config:  &config.Config{UserData: "{{.InvalidField}}"},
 		devices: &fakeDevicesService{},
+		plans:   []string{"c3.small.x86"},
 	}
 
 	err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "agent-1"})
@@ -0,0 +1,23 @@
+package exoscaleapi
+
+import (
+	"context"
+
+	egoscale "github.com/exoscale/egoscale/v3"
+)
+
+// Client is the subset of the Exoscale API v2 of a zone the exoscale
+// provider uses. Operations running asynchronously wait until the operation
+// has finished.
+type Client interface {
+	ListZones(ctx context.Context) ([]egoscale.Zone, error)
+	ListInstanceTypes(ctx context.Context) ([]egoscale.InstanceType, error)
+	// ListTemplates lists the templates of the zone with the visibility,
+	// public or private.
+	ListTemplates(ctx context.Context, visibility egoscale.ListTemplatesVisibility) ([]egoscale.Template, error)
+	ListSSHKeys(ctx context.Context) ([]egoscale.SSHKey, error)
+	// CreateInstance creates the instance and returns its ID.
+	CreateInstance(ctx context.Context, req egoscale.CreateInstanceRequest) (egoscale.UUID, error)
+	ListInstances(ctx context.Context) ([]egoscale.ListInstancesResponseInstances, error)
+	DeleteInstance(ctx context.Context, id egoscale.UUID) error
+}
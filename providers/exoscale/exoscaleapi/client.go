@@ -0,0 +1,113 @@
+package exoscaleapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	egoscale "github.com/exoscale/egoscale/v3"
+	"github.com/exoscale/egoscale/v3/credentials"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+// requestTimeout is the timeout of a single API request.
+const requestTimeout = time.Minute
+
+type client struct {
+	api *egoscale.Client
+}
+
+// NewClient creates a client for the API of the zone, e.g. ch-gva-2,
+// authenticating with the API key and secret.
+func NewClient(zone, key, secret string) (Client, error) {
+	return NewClientWithURL(fmt.Sprintf("https://api-%s.exoscale.com/v2", zone), key, secret)
+}
+
+// NewClientWithURL creates a client for the API at baseURL.
+func NewClientWithURL(baseURL, key, secret string) (Client, error) {
+	api, err := egoscale.NewClient(credentials.NewStaticCredentials(key, secret),
+		egoscale.ClientOptWithEndpoint(egoscale.Endpoint(baseURL)),
+		egoscale.ClientOptWithHTTPClient(&http.Client{Timeout: requestTimeout}),
+		egoscale.ClientOptWithUserAgent("woodpecker-autoscaler/"+version.String()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &client{api: api}, nil
+}
+
+// wait waits until the operation has finished and returns the ID of the
+// resource it references.
+func (c *client) wait(ctx context.Context, op *egoscale.Operation) (egoscale.UUID, error) {
+	op, err := c.api.Wait(ctx, op, egoscale.OperationStateSuccess)
+	if err != nil {
+		return "", err
+	}
+	// Wait returns an operation that has already finished as is
+	if op.State != egoscale.OperationStateSuccess {
+		return "", fmt.Errorf("operation %s %s: %s: %s", op.ID, op.State, op.Reason, op.Message)
+	}
+	if op.Reference == nil {
+		return "", nil
+	}
+	return op.Reference.ID, nil
+}
+
+func (c *client) ListZones(ctx context.Context) ([]egoscale.Zone, error) {
+	resp, err := c.api.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Zones, nil
+}
+
+func (c *client) ListInstanceTypes(ctx context.Context) ([]egoscale.InstanceType, error) {
+	resp, err := c.api.ListInstanceTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.InstanceTypes, nil
+}
+
+func (c *client) ListTemplates(ctx context.Context, visibility egoscale.ListTemplatesVisibility) ([]egoscale.Template, error) {
+	resp, err := c.api.ListTemplates(ctx, egoscale.ListTemplatesWithVisibility(visibility))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Templates, nil
+}
+
+func (c *client) ListSSHKeys(ctx context.Context) ([]egoscale.SSHKey, error) {
+	resp, err := c.api.ListSSHKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.SSHKeys, nil
+}
+
+func (c *client) CreateInstance(ctx context.Context, req egoscale.CreateInstanceRequest) (egoscale.UUID, error) {
+	op, err := c.api.CreateInstance(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return c.wait(ctx, op)
+}
+
+func (c *client) ListInstances(ctx context.Context) ([]egoscale.ListInstancesResponseInstances, error) {
+	resp, err := c.api.ListInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Instances, nil
+}
+
+func (c *client) DeleteInstance(ctx context.Context, id egoscale.UUID) error {
+	op, err := c.api.DeleteInstance(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = c.wait(ctx, op)
+	return err
+}
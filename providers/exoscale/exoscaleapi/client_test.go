@@ -0,0 +1,81 @@
+package exoscaleapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	egoscale "github.com/exoscale/egoscale/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/providers/exoscale/exoscaleapi"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) exoscaleapi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "EXO2-HMAC-SHA256 credential=EXOkey,"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("User-Agent"), "woodpecker-autoscaler/"))
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := exoscaleapi.NewClientWithURL(server.URL+"/v2", "EXOkey", "secret")
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v2/template":
+			assert.Equal(t, "private", r.URL.Query().Get("visibility"))
+			_, _ = w.Write([]byte(`{"templates": [{"id": "t1", "name": "ci"}]}`))
+		case "POST /v2/instance":
+			var req map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "agent", req["name"])
+			assert.Equal(t, map[string]any{"id": "it1"}, req["instance-type"])
+			_, _ = w.Write([]byte(`{"id": "op1", "state": "success", "reference": {"id": "i1"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	templates, err := client.ListTemplates(t.Context(), egoscale.ListTemplatesVisibilityPrivate)
+	assert.NoError(t, err)
+	assert.Equal(t, []egoscale.Template{{ID: "t1", Name: "ci"}}, templates)
+
+	id, err := client.CreateInstance(t.Context(), egoscale.CreateInstanceRequest{
+		Name:         "agent",
+		InstanceType: &egoscale.InstanceType{ID: "it1"},
+		Template:     &egoscale.Template{ID: "t1"},
+		DiskSize:     50,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, egoscale.UUID("i1"), id)
+}
+
+func TestOperationFailed(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/instance/i1", r.URL.Path)
+		_, _ = w.Write([]byte(`{"id": "op1", "state": "failure", "reason": "unavailable", "message": "host maintenance"}`))
+	})
+
+	err := client.DeleteInstance(t.Context(), "i1")
+	assert.EqualError(t, err, "operation op1 failure: unavailable: host maintenance")
+}
+
+func TestError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Instance not found"}`))
+	})
+
+	err := client.DeleteInstance(t.Context(), "i1")
+	assert.ErrorIs(t, err, egoscale.ErrNotFound)
+}
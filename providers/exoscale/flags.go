@@ -0,0 +1,74 @@
+package exoscale
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+const category = "Exoscale"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		// exoscale
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "exoscale-api-key",
+			Usage:    "exoscale api key",
+			Sources:  config.SecretSources("WOODPECKER_EXOSCALE_API_KEY"),
+			Category: category,
+		}},
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "exoscale-api-secret",
+			Usage:    "exoscale api secret",
+			Sources:  config.SecretSources("WOODPECKER_EXOSCALE_API_SECRET"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "exoscale-zone",
+			Usage:    "exoscale zone",
+			Value:    "ch-gva-2",
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_ZONE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "exoscale-instance-type",
+			Usage:    "exoscale instance type as family.size",
+			Value:    "standard.medium",
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_INSTANCE_TYPE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "exoscale-template",
+			Usage:    "exoscale template name or id",
+			Value:    "Linux Ubuntu 24.04 LTS 64-bit",
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_TEMPLATE"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "exoscale-disk-size",
+			Usage:    "disk size of the instances in GB",
+			Value:    50, //nolint:mnd
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_DISK_SIZE"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "exoscale-ssh-keys",
+			Usage:    "names of exoscale ssh keys",
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_SSH_KEYS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "exoscale-labels",
+			Usage:    "exoscale instance labels",
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_LABELS"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "exoscale-public-ipv6-enable",
+			Value:    true,
+			Usage:    "enables public ipv6 network for agents",
+			Sources:  cli.EnvVars("WOODPECKER_EXOSCALE_PUBLIC_IPV6_ENABLE"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,154 @@
+package exoscale
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	egoscale "github.com/exoscale/egoscale/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// instanceTypeName returns the name of the instance type as family.size,
+// e.g. standard.medium.
+func instanceTypeName(t *egoscale.InstanceType) string {
+	return string(t.Family) + "." + string(t.Size)
+}
+
+func (p *provider) resolveZone(ctx context.Context) error {
+	zones, err := p.client.ListZones(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch zones: %w", err)
+	}
+	if !slices.ContainsFunc(zones, func(zone egoscale.Zone) bool { return string(zone.Name) == p.zone }) {
+		return fmt.Errorf("%w: %q", ErrInvalidZone, p.zone)
+	}
+	return nil
+}
+
+func (p *provider) resolveInstanceType(ctx context.Context, instanceType string) error {
+	instanceTypes, err := p.client.ListInstanceTypes(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch instance types: %w", err)
+	}
+	for _, t := range instanceTypes {
+		if instanceTypeName(&t) != instanceType && string(t.ID) != instanceType {
+			continue
+		}
+		if t.Authorized == nil || !*t.Authorized {
+			return fmt.Errorf("selected instance type %q is not authorized for the organization", instanceType)
+		}
+		if len(t.Zones) > 0 && !slices.Contains(t.Zones, egoscale.ZoneName(p.zone)) {
+			return fmt.Errorf("selected instance type exist but not for zone %q", p.zone)
+		}
+		p.instanceType = &t
+		return nil
+	}
+
+	return ErrInvalidInstanceType
+}
+
+func (p *provider) resolveTemplate(ctx context.Context, template string) error {
+	var templates []egoscale.Template
+	// private templates are listed first, so they take precedence over
+	// public templates of the same name
+	for _, visibility := range []egoscale.ListTemplatesVisibility{egoscale.ListTemplatesVisibilityPrivate, egoscale.ListTemplatesVisibilityPublic} {
+		list, err := p.client.ListTemplates(ctx, visibility)
+		if err != nil {
+			return fmt.Errorf("could not fetch templates: %w", err)
+		}
+		templates = append(templates, list...)
+	}
+
+	var matches []egoscale.Template
+	want := strings.ReplaceAll(strings.ToLower(template), " ", "")
+	for _, t := range templates {
+		got := strings.ReplaceAll(strings.ToLower(t.Name), " ", "")
+		if string(t.ID) == template || got == want {
+			// we have an exact match
+			p.template = &t
+			return nil
+		}
+		if strings.HasPrefix(got, want) {
+			matches = append(matches, t)
+			log.Trace().Msgf("resolve template got match: %q", t.Name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return ErrInvalidTemplate
+	case 1:
+		p.template = &matches[0]
+	default:
+		// we first sort matches
+		slices.SortFunc(matches, func(a, b egoscale.Template) int { return strings.Compare(a.Name, b.Name) })
+		p.template = &matches[0]
+		log.Info().Msgf("template selector had %d matches, choose %q", len(matches), matches[0].Name)
+	}
+	return nil
+}
+
+func (p *provider) printResolvedConfig() {
+	log.Info().
+		Str("zone", p.zone).
+		Msg("deploy zone")
+
+	log.Info().
+		Str("name", instanceTypeName(p.instanceType)).
+		Int64("cpu_count", p.instanceType.Cpus).
+		Int64("ram", p.instanceType.Memory>>20).
+		Int64("storage", p.diskSize).
+		Msg("deploy with instance type")
+
+	log.Info().
+		Str("name", p.template.Name).
+		Str("family", p.template.Family).
+		Str("default_user", p.template.DefaultUser).
+		Msg("deploy with template")
+}
+
+func (p *provider) setupKeyPair(ctx context.Context, names []string) error {
+	res, err := p.client.ListSSHKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := map[string]bool{}
+	for _, key := range res {
+		index[key.Name] = true
+	}
+
+	// use the configured keys if there are any
+	if len(names) > 0 {
+		for _, name := range names {
+			if !index[name] {
+				return fmt.Errorf("%w: %s", ErrSSHKeyNotFound, name)
+			}
+			p.sshKeys = append(p.sshKeys, egoscale.SSHKey{Name: name})
+		}
+		return nil
+	}
+
+	// if the account has multiple keys configured try to
+	// use an existing key based on naming convention.
+	for _, name := range []string{"woodpecker", "id_rsa_woodpecker"} {
+		if !index[name] {
+			continue
+		}
+		p.sshKeys = append(p.sshKeys, egoscale.SSHKey{Name: name})
+
+		return nil
+	}
+
+	// if there were no matches but the account has at least
+	// one key-pair already created we will select the first
+	// in the list.
+	if len(res) > 0 {
+		p.sshKeys = append(p.sshKeys, egoscale.SSHKey{Name: res[0].Name})
+		return nil
+	}
+
+	return ErrSSHKeyNotFound
+}
@@ -0,0 +1,204 @@
+package exoscale
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	egoscale "github.com/exoscale/egoscale/v3"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/exoscale/exoscaleapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+var (
+	ErrIllegalLabelPrefix  = errors.New("illegal label prefix")
+	ErrMissingSetting      = errors.New("missing setting")
+	ErrSSHKeyNotFound      = errors.New("SSH key not found")
+	ErrInvalidZone         = errors.New("no valid zone set")
+	ErrInvalidInstanceType = errors.New("no valid instance type set")
+	ErrInvalidTemplate     = errors.New("no valid template set")
+)
+
+// UserDataLimit is the maximum size of instance user data in bytes, exoscale
+// accepts 32768 bytes after base64 encoding.
+const UserDataLimit = 32 << 10 / 4 * 3
+
+// blackhole metadata services so running steps can not extract agent token from user-data
+// (the metadata server of exoscale instances is at 169.254.169.254)
+var blackholeMetadataAPI = []string{
+	"ip -4 route add blackhole 169.254.169.254/32",
+}
+
+type provider struct {
+	sshKeys    []egoscale.SSHKey
+	labels     map[string]string
+	config     *config.Config
+	enableIPv6 bool
+	diskSize   int64
+	zone       string
+	name       string
+	client     exoscaleapi.Client
+	// resolved config
+	instanceType *egoscale.InstanceType
+	template     *egoscale.Template
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:       "exoscale",
+		zone:       c.String("exoscale-zone"),
+		diskSize:   int64(c.Int("exoscale-disk-size")),
+		enableIPv6: c.Bool("exoscale-public-ipv6-enable"),
+		config:     config,
+	}
+
+	for _, flag := range []string{"exoscale-api-key", "exoscale-api-secret"} {
+		if c.String(flag) == "" {
+			return nil, fmt.Errorf("%s: %w: %s", p.name, ErrMissingSetting, flag)
+		}
+	}
+	client, err := exoscaleapi.NewClient(p.zone, c.String("exoscale-api-key"), c.String("exoscale-api-secret"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: exoscaleapi.NewClient: %w", p.name, err)
+	}
+	p.client = client
+
+	// first resolve and check config
+	if err := p.resolveZone(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if err := p.resolveInstanceType(ctx, c.String("exoscale-instance-type")); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if err := p.resolveTemplate(ctx, c.String("exoscale-template")); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	// log debug info
+	p.printResolvedConfig()
+
+	// if not done setup ssh key-pair
+	if err := p.setupKeyPair(ctx, c.StringSlice("exoscale-ssh-keys")); err != nil {
+		return nil, fmt.Errorf("%s: setupKeyPair: %w", p.name, err)
+	}
+
+	defaultLabels := make(map[string]string, 0)
+	defaultLabels[engine.LabelPool] = p.config.PoolID
+	defaultLabels[engine.LabelImage] = p.template.Name
+
+	userLabels := c.StringSlice("exoscale-labels")
+	if err := utils.CheckReservedTags(userLabels, engine.LabelPrefix, ErrIllegalLabelPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	labels, err := utils.SliceToMap(userLabels, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	p.labels = utils.MergeMaps(defaultLabels, labels)
+
+	return p, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	instanceType := instanceTypeName(p.instanceType)
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: instanceType,
+			Region:       p.zone,
+			Workflows:    p.config.Workflows(instanceType, int(p.instanceType.Cpus), p.instanceType.Memory>>20),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+	}
+
+	publicIPAssignment := egoscale.PublicIPAssignmentInet4
+	if p.enableIPv6 {
+		publicIPAssignment = egoscale.PublicIPAssignmentDual
+	}
+
+	id, err := p.client.CreateInstance(ctx, egoscale.CreateInstanceRequest{
+		Name:               agent.Name,
+		InstanceType:       &egoscale.InstanceType{ID: p.instanceType.ID},
+		Template:           &egoscale.Template{ID: p.template.ID},
+		DiskSize:           p.diskSize,
+		SSHKeys:            p.sshKeys,
+		UserData:           base64.StdEncoding.EncodeToString([]byte(userData)),
+		Labels:             p.labels,
+		PublicIPAssignment: publicIPAssignment,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: CreateInstance: %w", p.name, err)
+	}
+	log.Debug().Msgf("created instance %s", id)
+
+	return nil
+}
+
+// poolInstances returns the instances of the pool which are not destroyed.
+func (p *provider) poolInstances(ctx context.Context) ([]egoscale.ListInstancesResponseInstances, error) {
+	instances, err := p.client.ListInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListInstances: %w", err)
+	}
+
+	var pool []egoscale.ListInstancesResponseInstances
+	for _, instance := range instances {
+		if instance.Labels[engine.LabelPool] != p.config.PoolID {
+			continue
+		}
+		if instance.State == egoscale.InstanceStateDestroying || instance.State == egoscale.InstanceStateDestroyed {
+			continue
+		}
+		pool = append(pool, instance)
+	}
+	return pool, nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	instances, err := p.poolInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	for _, instance := range instances {
+		if instance.Name != agent.Name {
+			continue
+		}
+		if err := p.client.DeleteInstance(ctx, instance.ID); err != nil && !errors.Is(err, egoscale.ErrNotFound) {
+			return fmt.Errorf("%s: DeleteInstance: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	instances, err := p.poolInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	names := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		names = append(names, instance.Name)
+	}
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingHourlyRoundUp
+}
@@ -0,0 +1,241 @@
+package exoscale
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	egoscale "github.com/exoscale/egoscale/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/providers/exoscale/exoscaleapi"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// stubAPI is a minimal stateful stub of the Exoscale API v2 of a zone.
+type stubAPI struct {
+	mu        sync.Mutex
+	sshKeys   []egoscale.SSHKey
+	instances map[string]*egoscale.ListInstancesResponseInstances
+	created   []*egoscale.CreateInstanceRequest
+	nextID    int
+}
+
+func newStubAPI(t *testing.T) (*stubAPI, exoscaleapi.Client) {
+	t.Helper()
+	s := &stubAPI{
+		sshKeys:   []egoscale.SSHKey{{Name: "admin"}, {Name: "woodpecker"}},
+		instances: map[string]*egoscale.ListInstancesResponseInstances{},
+		nextID:    1,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/zone", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, map[string]any{"zones": []map[string]string{{"name": "ch-gva-2"}, {"name": "de-fra-1"}}})
+	})
+	mux.HandleFunc("GET /v2/instance-type", func(w http.ResponseWriter, _ *http.Request) {
+		authorized, unauthorized := true, false
+		s.respond(w, map[string]any{"instance-types": []egoscale.InstanceType{
+			{ID: "it-small", Family: "standard", Size: "small", Cpus: 2, Memory: 2 << 30, Authorized: &authorized, Zones: []egoscale.ZoneName{"ch-gva-2", "de-fra-1"}},
+			{ID: "it-medium", Family: "standard", Size: "medium", Cpus: 2, Memory: 4 << 30, Authorized: &authorized, Zones: []egoscale.ZoneName{"ch-gva-2", "de-fra-1"}},
+			{ID: "it-gpu", Family: "gpu", Size: "small", Cpus: 12, Memory: 56 << 30, Authorized: &unauthorized, Zones: []egoscale.ZoneName{"ch-gva-2"}},
+			{ID: "it-fra", Family: "memory", Size: "large", Cpus: 4, Memory: 32 << 30, Authorized: &authorized, Zones: []egoscale.ZoneName{"de-fra-1"}},
+		}})
+	})
+	mux.HandleFunc("GET /v2/template", func(w http.ResponseWriter, r *http.Request) {
+		templates := []egoscale.Template{}
+		switch r.URL.Query().Get("visibility") {
+		case "private":
+			templates = append(templates,
+				egoscale.Template{ID: "t-ci", Name: "Linux Ubuntu 24.04 LTS 64-bit CI"},
+				egoscale.Template{ID: "t-private-2204", Name: "Linux Ubuntu 22.04 LTS 64-bit"},
+			)
+		case "public":
+			templates = append(templates,
+				egoscale.Template{ID: "t-2204", Name: "Linux Ubuntu 22.04 LTS 64-bit", Family: "ubuntu"},
+				egoscale.Template{ID: "t-2404", Name: "Linux Ubuntu 24.04 LTS 64-bit", Family: "ubuntu", DefaultUser: "ubuntu"},
+			)
+		}
+		s.respond(w, map[string]any{"templates": templates})
+	})
+	mux.HandleFunc("GET /v2/ssh-key", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, map[string]any{"ssh-keys": s.sshKeys})
+	})
+	mux.HandleFunc("GET /v2/instance", func(w http.ResponseWriter, _ *http.Request) {
+		instances := []*egoscale.ListInstancesResponseInstances{}
+		for _, instance := range s.instances {
+			instances = append(instances, instance)
+		}
+		s.respond(w, map[string]any{"instances": instances})
+	})
+	mux.HandleFunc("POST /v2/instance", func(w http.ResponseWriter, r *http.Request) {
+		var req egoscale.CreateInstanceRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		s.created = append(s.created, &req)
+		id := fmt.Sprintf("i-%d", s.nextID)
+		s.nextID++
+		s.instances[id] = &egoscale.ListInstancesResponseInstances{ID: egoscale.UUID(id), Name: req.Name, State: egoscale.InstanceStateStarting, Labels: req.Labels}
+		s.respond(w, map[string]any{"id": "op-" + id, "state": "success", "reference": map[string]string{"id": id}})
+	})
+	mux.HandleFunc("DELETE /v2/instance/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, ok := s.instances[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			s.respond(w, map[string]string{"message": "Instance not found"})
+			return
+		}
+		delete(s.instances, id)
+		s.respond(w, map[string]any{"id": "op-" + id, "state": "success", "reference": map[string]string{"id": id}})
+	})
+	mux.HandleFunc("GET /v2/operation/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.PathValue("id"), "op-")
+		s.respond(w, map[string]any{"id": r.PathValue("id"), "state": "success", "reference": map[string]string{"id": id}})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the zones are public
+		if r.URL.Path != "/v2/zone" {
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "EXO2-HMAC-SHA256 credential=EXOkey,"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := exoscaleapi.NewClientWithURL(server.URL+"/v2", "EXOkey", "secret")
+	require.NoError(t, err)
+	return s, client
+}
+
+func (s *stubAPI) respond(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func newTestProvider(t *testing.T, client exoscaleapi.Client) *provider {
+	t.Helper()
+	p := &provider{
+		name:       "exoscale",
+		zone:       "ch-gva-2",
+		diskSize:   50,
+		enableIPv6: true,
+		labels:     map[string]string{engine.LabelPool: "1", "team": "ci"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: client,
+	}
+	assert.NoError(t, p.resolveZone(t.Context()))
+	assert.NoError(t, p.resolveInstanceType(t.Context(), "standard.medium"))
+	assert.NoError(t, p.resolveTemplate(t.Context(), "Linux Ubuntu 24.04 LTS 64-bit"))
+	return p
+}
+
+func TestResolve(t *testing.T) {
+	_, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	assert.Equal(t, egoscale.UUID("it-medium"), p.instanceType.ID)
+	assert.Equal(t, egoscale.UUID("t-2404"), p.template.ID)
+
+	assert.NoError(t, p.resolveTemplate(t.Context(), "t-2204"))
+	assert.Equal(t, "Linux Ubuntu 22.04 LTS 64-bit", p.template.Name)
+	assert.NoError(t, p.resolveTemplate(t.Context(), "linux ubuntu 24.04 lts 64-bit ci"))
+	assert.Equal(t, egoscale.UUID("t-ci"), p.template.ID)
+	// the private template of the same name is preferred
+	assert.NoError(t, p.resolveTemplate(t.Context(), "Linux Ubuntu 22.04 LTS 64-bit"))
+	assert.Equal(t, egoscale.UUID("t-private-2204"), p.template.ID)
+	assert.NoError(t, p.resolveTemplate(t.Context(), "Linux Ubuntu"))
+	assert.Equal(t, "Linux Ubuntu 22.04 LTS 64-bit", p.template.Name)
+	assert.ErrorIs(t, p.resolveTemplate(t.Context(), "Debian"), ErrInvalidTemplate)
+
+	assert.ErrorIs(t, p.resolveInstanceType(t.Context(), "standard.huge"), ErrInvalidInstanceType)
+	assert.ErrorContains(t, p.resolveInstanceType(t.Context(), "gpu.small"), "not authorized")
+	assert.ErrorContains(t, p.resolveInstanceType(t.Context(), "memory.large"), `not for zone "ch-gva-2"`)
+
+	p.zone = "at-vie-1"
+	assert.ErrorIs(t, p.resolveZone(t.Context()), ErrInvalidZone)
+}
+
+func TestSetupKeyPair(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+
+	assert.NoError(t, p.setupKeyPair(t.Context(), nil))
+	assert.Equal(t, []egoscale.SSHKey{{Name: "woodpecker"}}, p.sshKeys)
+
+	p.sshKeys = nil
+	assert.NoError(t, p.setupKeyPair(t.Context(), []string{"admin"}))
+	assert.Equal(t, []egoscale.SSHKey{{Name: "admin"}}, p.sshKeys)
+
+	p.sshKeys = nil
+	assert.ErrorIs(t, p.setupKeyPair(t.Context(), []string{"unknown"}), ErrSSHKeyNotFound)
+
+	p.sshKeys = nil
+	stub.sshKeys = nil
+	assert.ErrorIs(t, p.setupKeyPair(t.Context(), nil), ErrSSHKeyNotFound)
+}
+
+func TestDeployAgent(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	assert.NoError(t, p.setupKeyPair(t.Context(), nil))
+
+	assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+
+	if assert.Len(t, stub.created, 1) {
+		req := stub.created[0]
+		assert.Equal(t, "pool-1-agent-abcd", req.Name)
+		assert.Equal(t, egoscale.UUID("it-medium"), req.InstanceType.ID)
+		assert.Equal(t, egoscale.UUID("t-2404"), req.Template.ID)
+		assert.Equal(t, int64(50), req.DiskSize)
+		assert.Equal(t, []egoscale.SSHKey{{Name: "woodpecker"}}, req.SSHKeys)
+		assert.Equal(t, egoscale.PublicIPAssignmentDual, req.PublicIPAssignment)
+		assert.Equal(t, egoscale.Labels{engine.LabelPool: "1", "team": "ci"}, req.Labels)
+
+		userData, err := base64.StdEncoding.DecodeString(req.UserData)
+		assert.NoError(t, err)
+		assert.Contains(t, string(userData), "#cloud-config")
+		assert.Contains(t, string(userData), "ip -4 route add blackhole 169.254.169.254/32")
+		assert.Contains(t, string(userData), "autoscaler.region=ch-gva-2")
+	}
+}
+
+func TestRemoveAgent(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	stub.instances["i-1"] = &egoscale.ListInstancesResponseInstances{ID: "i-1", Name: "pool-1-agent-abcd", State: egoscale.InstanceStateRunning, Labels: egoscale.Labels{engine.LabelPool: "1"}}
+	// same name in another pool
+	stub.instances["i-2"] = &egoscale.ListInstancesResponseInstances{ID: "i-2", Name: "pool-1-agent-abcd", State: egoscale.InstanceStateRunning, Labels: egoscale.Labels{engine.LabelPool: "2"}}
+
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	assert.NotContains(t, stub.instances, "i-1")
+	assert.Contains(t, stub.instances, "i-2")
+
+	// already removed
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	stub.instances["i-1"] = &egoscale.ListInstancesResponseInstances{ID: "i-1", Name: "pool-1-agent-a", State: egoscale.InstanceStateRunning, Labels: egoscale.Labels{engine.LabelPool: "1"}}
+	stub.instances["i-2"] = &egoscale.ListInstancesResponseInstances{ID: "i-2", Name: "pool-2-agent-b", State: egoscale.InstanceStateRunning, Labels: egoscale.Labels{engine.LabelPool: "2"}}
+	stub.instances["i-3"] = &egoscale.ListInstancesResponseInstances{ID: "i-3", Name: "pool-1-agent-c", State: egoscale.InstanceStateDestroying, Labels: egoscale.Labels{engine.LabelPool: "1"}}
+	stub.instances["i-4"] = &egoscale.ListInstancesResponseInstances{ID: "i-4", Name: "web", State: egoscale.InstanceStateRunning}
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-a"}, names)
+}
@@ -0,0 +1,51 @@
+package computeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Client is the subset of the Compute Engine API the gce provider uses, so it
+// can be mocked in tests.
+type Client interface {
+	GetMachineType(ctx context.Context, project, zone, name string) (*compute.MachineType, error)
+	GetImageFromFamily(ctx context.Context, project, family string) (*compute.Image, error)
+	// InsertInstance creates the instance and waits until the operation is
+	// done, so errors like an exhausted zone are returned.
+	InsertInstance(ctx context.Context, project, zone string, instance *compute.Instance) error
+	DeleteInstance(ctx context.Context, project, zone, name string) error
+	// ListInstances lists the instances of all zones matching the filter.
+	ListInstances(ctx context.Context, project, filter string) ([]*compute.Instance, error)
+}
+
+// OperationError is the error of a failed operation.
+type OperationError struct {
+	// Code is the reason of the error, e.g. ZONE_RESOURCE_POOL_EXHAUSTED.
+	Code    string
+	Message string
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("compute: %s: %s", e.Code, e.Message)
+}
+
+// IsError reports whether err is an error response of the API with one of
+// the reasons, e.g. notFound, or an OperationError with one of the codes.
+func IsError(err error, codes ...string) bool {
+	var opErr *OperationError
+	if errors.As(err, &opErr) {
+		return slices.Contains(codes, opErr.Code)
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return slices.ContainsFunc(apiErr.Errors, func(item googleapi.ErrorItem) bool {
+			return slices.Contains(codes, item.Reason)
+		})
+	}
+	return false
+}
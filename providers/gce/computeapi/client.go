@@ -0,0 +1,109 @@
+package computeapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+const operationDone = "DONE"
+
+type client struct {
+	service *compute.Service
+}
+
+// NewClient creates a client authenticated with the service account key file,
+// or with the application default credentials if credentialsFile is empty:
+// the file of GOOGLE_APPLICATION_CREDENTIALS, e.g. a workload identity
+// federation config, the gcloud user credentials or the service account of
+// the instance the autoscaler runs on.
+func NewClient(ctx context.Context, credentialsFile string) (Client, error) {
+	var credentials *google.Credentials
+	if credentialsFile != "" {
+		data, err := os.ReadFile(credentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading credentials file: %w", err)
+		}
+		credentials, err = google.CredentialsFromJSONWithType(ctx, data, google.ServiceAccount, compute.ComputeScope)
+		if err != nil {
+			return nil, fmt.Errorf("credentials file: %w", err)
+		}
+	} else {
+		var err error
+		credentials, err = google.FindDefaultCredentials(ctx, compute.ComputeScope)
+		if err != nil {
+			return nil, fmt.Errorf("default credentials: %w", err)
+		}
+	}
+
+	return newClient(ctx, option.WithCredentials(credentials))
+}
+
+// NewClientWithHTTP creates a client sending its requests with the http
+// client to baseURL.
+func NewClientWithHTTP(ctx context.Context, httpClient *http.Client, baseURL string) (Client, error) {
+	return newClient(ctx, option.WithHTTPClient(httpClient), option.WithEndpoint(baseURL))
+}
+
+func newClient(ctx context.Context, opts ...option.ClientOption) (Client, error) {
+	service, err := compute.NewService(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	service.UserAgent = "woodpecker-autoscaler/" + version.String()
+	return &client{service: service}, nil
+}
+
+func (c *client) GetMachineType(ctx context.Context, project, zone, name string) (*compute.MachineType, error) {
+	return c.service.MachineTypes.Get(project, zone, name).Context(ctx).Do()
+}
+
+func (c *client) GetImageFromFamily(ctx context.Context, project, family string) (*compute.Image, error) {
+	return c.service.Images.GetFromFamily(project, family).Context(ctx).Do()
+}
+
+func (c *client) InsertInstance(ctx context.Context, project, zone string, instance *compute.Instance) error {
+	op, err := c.service.Instances.Insert(project, zone, instance).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+
+	// Wait returns when the operation is done or after about two minutes
+	for op.Status != operationDone {
+		op, err = c.service.ZoneOperations.Wait(project, zone, op.Name).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+	}
+
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return &OperationError{Code: op.Error.Errors[0].Code, Message: op.Error.Errors[0].Message}
+	}
+	return nil
+}
+
+func (c *client) DeleteInstance(ctx context.Context, project, zone, name string) error {
+	_, err := c.service.Instances.Delete(project, zone, name).Context(ctx).Do()
+	return err
+}
+
+func (c *client) ListInstances(ctx context.Context, project, filter string) ([]*compute.Instance, error) {
+	var instances []*compute.Instance
+	err := c.service.Instances.AggregatedList(project).Filter(filter).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+		for _, scope := range page.Items {
+			instances = append(instances, scope.Instances...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
@@ -0,0 +1,85 @@
+package computeapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/compute/v1"
+
+	"go.woodpecker-ci.org/autoscaler/providers/gce/computeapi"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) computeapi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.UserAgent(), " woodpecker-autoscaler/")
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := computeapi.NewClientWithHTTP(t.Context(), server.Client(), server.URL+"/")
+	require.NoError(t, err)
+	return client
+}
+
+func TestInsertInstance(t *testing.T) {
+	var body map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /projects/project/zones/us-central1-a/instances", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"name": "op-1", "status": "RUNNING"}`))
+	})
+	mux.HandleFunc("POST /projects/project/zones/us-central1-a/operations/op-1/wait", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "op-1", "status": "DONE", "error": {"errors": [{"code": "ZONE_RESOURCE_POOL_EXHAUSTED", "message": "no capacity"}]}}`))
+	})
+
+	client := newTestClient(t, mux)
+	err := client.InsertInstance(t.Context(), "project", "us-central1-a", &compute.Instance{
+		Name: "agent",
+		Disks: []*compute.AttachedDisk{{
+			Boot:             true,
+			InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: "image", DiskSizeGb: 20},
+		}},
+	})
+
+	assert.True(t, computeapi.IsError(err, "ZONE_RESOURCE_POOL_EXHAUSTED"))
+	assert.EqualError(t, err, "compute: ZONE_RESOURCE_POOL_EXHAUSTED: no capacity")
+	assert.Equal(t, "20", body["disks"].([]any)[0].(map[string]any)["initializeParams"].(map[string]any)["diskSizeGb"])
+}
+
+func TestAPIError(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": {"code": 404, "message": "machine type not found", "errors": [{"reason": "notFound"}]}}`))
+	}))
+
+	_, err := client.GetMachineType(t.Context(), "project", "us-central1-a", "e2-huge")
+
+	assert.True(t, computeapi.IsError(err, "notFound"))
+	assert.False(t, computeapi.IsError(err, "forbidden"))
+	assert.ErrorContains(t, err, "machine type not found")
+}
+
+func TestListInstances(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/project/aggregated/instances", r.URL.Path)
+		assert.Equal(t, `labels.pool = "1"`, r.URL.Query().Get("filter"))
+		if r.URL.Query().Get("pageToken") == "" {
+			_, _ = w.Write([]byte(`{"items": {"zones/us-central1-a": {"instances": [{"name": "a"}]}, "zones/us-central1-b": {}}, "nextPageToken": "next"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": {"zones/us-central1-b": {"instances": [{"name": "b"}]}}}`))
+	}))
+
+	instances, err := client.ListInstances(t.Context(), "project", `labels.pool = "1"`)
+
+	assert.NoError(t, err)
+	if assert.Len(t, instances, 2) {
+		assert.Equal(t, "a", instances[0].Name)
+		assert.Equal(t, "b", instances[1].Name)
+	}
+}
@@ -0,0 +1,103 @@
+package gce
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Google Compute Engine"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "gce-project",
+			Usage:    "google cloud project the instances are created in",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_PROJECT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "gce-credentials-file",
+			Usage:    "path to a service account key file, defaults to the application default credentials",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_CREDENTIALS_FILE"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "gce-machine-type",
+			Value:    []string{"e2-standard-2:us-central1-a"},
+			Usage:    "machine types with their zone as type:zone, later entries are fallbacks if a zone has no capacity",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_MACHINE_TYPE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "gce-image-family",
+			Value:    "ubuntu-os-cloud/ubuntu-2404-lts-amd64",
+			Usage:    "image family as project/family, the latest image of it is used",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_IMAGE_FAMILY"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "gce-disk-size",
+			Value:    20, //nolint:mnd
+			Usage:    "boot disk size in GB",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_DISK_SIZE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "gce-disk-type",
+			Value:    "pd-balanced",
+			Usage:    "boot disk type",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_DISK_TYPE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "gce-network",
+			Value:    "default",
+			Usage:    "vpc network of the instances",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_NETWORK"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "gce-subnetwork",
+			Usage:    "subnetwork of the instances, in the region of their zone",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_SUBNETWORK"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "gce-public-ip",
+			Value:    true,
+			Usage:    "assign an ephemeral public ip to the instances",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_PUBLIC_IP"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "gce-service-account",
+			Usage:    "email of the service account attached to the instances",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_SERVICE_ACCOUNT"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "gce-service-account-scopes",
+			Value:    []string{"https://www.googleapis.com/auth/cloud-platform"},
+			Usage:    "oauth scopes of the attached service account",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_SERVICE_ACCOUNT_SCOPES"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "gce-spot",
+			Usage:    "create spot vms, which are deleted when preempted",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_SPOT"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "gce-labels",
+			Usage:    "instance labels as key=value",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_LABELS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "gce-tags",
+			Usage:    "network tags of the instances, e.g. for firewall rules",
+			Sources:  cli.EnvVars("WOODPECKER_GCE_TAGS"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/compute/v1"
+)
+
+// invalidNameChars matches what is not allowed in instance names and label
+// values.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]`)
+
+// instanceName returns the name of the agent's instance. Instance names must
+// be lowercase, the agent name is kept in the instance metadata.
+func instanceName(agentName string) string {
+	return invalidNameChars.ReplaceAllString(strings.ToLower(agentName), "-")
+}
+
+// labelValue returns value with the characters labels do not allow replaced.
+func labelValue(value string) string {
+	return invalidNameChars.ReplaceAllString(strings.ToLower(value), "-")
+}
+
+// lastSegment returns the name at the end of a resource URL.
+func lastSegment(resource string) string {
+	return resource[strings.LastIndex(resource, "/")+1:]
+}
+
+// metadataValue returns the value of the metadata item, or an empty string.
+func metadataValue(metadata *compute.Metadata, key string) string {
+	if metadata == nil {
+		return ""
+	}
+	for _, item := range metadata.Items {
+		if item.Key == key && item.Value != nil {
+			return *item.Value
+		}
+	}
+	return ""
+}
+
+// region returns the region of the zone, e.g. us-central1 of us-central1-a.
+func region(zone string) string {
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		return zone[:i]
+	}
+	return zone
+}
+
+func (p *provider) resolveDeployCandidates(ctx context.Context, machineTypes []string, imageFamily string) error {
+	imageProject, family, ok := strings.Cut(imageFamily, "/")
+	if !ok || imageProject == "" || family == "" {
+		return fmt.Errorf("%s: %w: %q", p.name, ErrInvalidImageFamily, imageFamily)
+	}
+
+	image, err := p.client.GetImageFromFamily(ctx, imageProject, family)
+	if err != nil {
+		return fmt.Errorf("%s: GetImageFromFamily: %w", p.name, err)
+	}
+
+	for _, raw := range machineTypes {
+		name, zone, _ := strings.Cut(raw, ":")
+		if zone == "" {
+			return fmt.Errorf("%s: %w: %s", p.name, ErrZoneRequired, raw)
+		}
+
+		machineType, err := p.client.GetMachineType(ctx, p.project, zone, name)
+		if err != nil {
+			return fmt.Errorf("%s: GetMachineType: %w", p.name, err)
+		}
+		if machineType.Deprecated != nil {
+			log.Error().Msgf("machine type %q is %s", machineType.Name, strings.ToLower(machineType.Deprecated.State))
+		}
+
+		// machine types and images without an architecture fit any
+		if machineType.Architecture != "" && image.Architecture != "" && machineType.Architecture != image.Architecture {
+			return fmt.Errorf("%s: %w: image %q is %s, machine type %q is %s", p.name, ErrArchitectureMismatch,
+				image.Name, image.Architecture, machineType.Name, machineType.Architecture)
+		}
+
+		p.deployCandidates = append(p.deployCandidates, deployCandidate{
+			zone:        zone,
+			machineType: machineType,
+			image:       image,
+		})
+	}
+
+	if len(p.deployCandidates) == 0 {
+		return fmt.Errorf("no deploy candidates resolved")
+	}
+
+	return nil
+}
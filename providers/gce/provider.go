@@ -0,0 +1,264 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/api/compute/v1"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/gce/computeapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// UserDataLimit is the maximum size of a metadata value in bytes.
+const UserDataLimit = 256 << 10
+
+// Override because label keys only allow lowercase letters, digits, "_" and "-"
+const (
+	labelPrefix = "wp-autoscaler-"
+	labelPool   = labelPrefix + "pool"
+	labelImage  = labelPrefix + "image"
+)
+
+const (
+	// metadataUserData is read by cloud-init
+	metadataUserData = "user-data"
+	// metadataAgent keeps the agent name, instance names must be lowercase
+	metadataAgent = "woodpecker-agent"
+)
+
+// errors of a zone without capacity for the machine type
+var zoneExhausted = []string{
+	"ZONE_RESOURCE_POOL_EXHAUSTED",
+	"ZONE_RESOURCE_POOL_EXHAUSTED_WITH_DETAILS",
+}
+
+// blackhole metadata services so running steps can not extract agent token from user-data
+// https://cloud.google.com/compute/docs/metadata/overview
+// The metadata server is the DNS resolver of the instances as well, so only
+// its HTTP port is dropped instead of routing it into a blackhole.
+var blackholeMetadataAPI = []string{
+	"iptables -t mangle -I PREROUTING -d 169.254.169.254 -p tcp --dport 80 -j DROP",
+	"iptables -t mangle -I OUTPUT -d 169.254.169.254 -p tcp --dport 80 -j DROP",
+}
+
+type provider struct {
+	name             string
+	project          string
+	deployCandidates []deployCandidate
+	diskSize         int64
+	diskType         string
+	network          string
+	subnetwork       string
+	publicIP         bool
+	serviceAccount   string
+	scopes           []string
+	spot             bool
+	labels           map[string]string
+	tags             []string
+	config           *config.Config
+	client           computeapi.Client
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:           "gce",
+		project:        c.String("gce-project"),
+		diskSize:       int64(c.Int("gce-disk-size")),
+		diskType:       c.String("gce-disk-type"),
+		network:        c.String("gce-network"),
+		subnetwork:     c.String("gce-subnetwork"),
+		publicIP:       c.Bool("gce-public-ip"),
+		serviceAccount: c.String("gce-service-account"),
+		scopes:         c.StringSlice("gce-service-account-scopes"),
+		spot:           c.Bool("gce-spot"),
+		tags:           c.StringSlice("gce-tags"),
+		config:         config,
+	}
+
+	if p.project == "" {
+		return nil, fmt.Errorf("%s: %w", p.name, ErrProjectRequired)
+	}
+
+	client, err := computeapi.NewClient(ctx, c.String("gce-credentials-file"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.client = client
+
+	if err := p.resolveDeployCandidates(ctx, c.StringSlice("gce-machine-type"), c.String("gce-image-family")); err != nil {
+		return nil, err
+	}
+
+	userLabels := c.StringSlice("gce-labels")
+	if err := utils.CheckReservedTags(userLabels, labelPrefix, ErrIllegalLabelPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	labels, err := utils.SliceToMap(userLabels, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	p.labels = utils.MergeMaps(labels, map[string]string{
+		labelPool:  labelValue(p.config.PoolID),
+		labelImage: labelValue(p.deployCandidates[0].image.Name),
+	})
+
+	return p, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	for i, c := range p.deployCandidates {
+		userData, err := p.renderUserData(agent, c)
+		if err != nil {
+			return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+		}
+
+		log.Info().Msgf("create agent: zone = %s type = %s", c.zone, c.machineType.Name)
+
+		err = p.client.InsertInstance(ctx, p.project, c.zone, p.instance(agent, c, userData))
+		if err == nil {
+			return nil
+		}
+
+		// Continue to next fallback entry only if the zone has no capacity.
+		if !computeapi.IsError(err, zoneExhausted...) {
+			return fmt.Errorf("%s: InsertInstance: %w", p.name, err)
+		}
+
+		// Only log and continue if there are more candidates left.
+		if i < len(p.deployCandidates)-1 {
+			log.Warn().Msgf("create agent failed: zone = %s type = %s: %s", c.zone, c.machineType.Name, err)
+			continue
+		}
+
+		// Last candidate failed.
+		return fmt.Errorf("%s: InsertInstance: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// instance returns the instance of the agent on the candidate.
+func (p *provider) instance(agent *woodpecker.Agent, c deployCandidate, userData string) *compute.Instance {
+	instance := &compute.Instance{
+		Name:        instanceName(agent.Name),
+		MachineType: fmt.Sprintf("zones/%s/machineTypes/%s", c.zone, c.machineType.Name),
+		Labels:      p.labels,
+		Metadata: &compute.Metadata{Items: []*compute.MetadataItems{
+			{Key: metadataUserData, Value: &userData},
+			{Key: metadataAgent, Value: &agent.Name},
+		}},
+		Disks: []*compute.AttachedDisk{{
+			Boot:       true,
+			AutoDelete: true,
+			InitializeParams: &compute.AttachedDiskInitializeParams{
+				SourceImage: c.image.SelfLink,
+				DiskSizeGb:  p.diskSize,
+				DiskType:    fmt.Sprintf("zones/%s/diskTypes/%s", c.zone, p.diskType),
+			},
+		}},
+	}
+
+	networkInterface := &compute.NetworkInterface{Network: p.network}
+	if networkInterface.Network != "" && lastSegment(networkInterface.Network) == networkInterface.Network {
+		networkInterface.Network = "global/networks/" + p.network
+	}
+	if p.subnetwork != "" {
+		networkInterface.Subnetwork = p.subnetwork
+		if lastSegment(p.subnetwork) == p.subnetwork {
+			networkInterface.Subnetwork = fmt.Sprintf("regions/%s/subnetworks/%s", region(c.zone), p.subnetwork)
+		}
+	}
+	if p.publicIP {
+		networkInterface.AccessConfigs = []*compute.AccessConfig{{Name: "External NAT", Type: "ONE_TO_ONE_NAT"}}
+	}
+	instance.NetworkInterfaces = []*compute.NetworkInterface{networkInterface}
+
+	if p.serviceAccount != "" {
+		instance.ServiceAccounts = []*compute.ServiceAccount{{Email: p.serviceAccount, Scopes: p.scopes}}
+	}
+
+	if len(p.tags) > 0 {
+		instance.Tags = &compute.Tags{Items: p.tags}
+	}
+
+	if p.spot {
+		instance.Scheduling = &compute.Scheduling{
+			ProvisioningModel:         "SPOT",
+			InstanceTerminationAction: "DELETE",
+			AutomaticRestart:          utils.ToPtr(false),
+			OnHostMaintenance:         "TERMINATE",
+		}
+	}
+
+	return instance
+}
+
+// renderUserData renders the user data for the agent on the candidate.
+func (p *provider) renderUserData(agent *woodpecker.Agent, c deployCandidate) (string, error) {
+	arch := c.machineType.Architecture
+	if arch == "" {
+		arch = c.image.Architecture
+	}
+	return inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		OSFamily: cloudinit.DetectOSFamily(c.image.Family + " " + c.image.Name),
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: c.machineType.Name,
+			Region:       c.zone,
+			Arch:         cloudinit.NormalizeArch(arch),
+			Spot:         p.spot,
+			Workflows:    p.config.Workflows(c.machineType.Name, int(c.machineType.GuestCpus), c.machineType.MemoryMb),
+		},
+	})
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	instances, err := p.client.ListInstances(ctx, p.project,
+		fmt.Sprintf(`(name = "%s") (labels.%s = "%s")`, instanceName(agent.Name), labelPool, labelValue(p.config.PoolID)))
+	if err != nil {
+		return fmt.Errorf("%s: ListInstances: %w", p.name, err)
+	}
+
+	for _, instance := range instances {
+		if err := p.client.DeleteInstance(ctx, p.project, lastSegment(instance.Zone), instance.Name); err != nil {
+			return fmt.Errorf("%s: DeleteInstance: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var names []string
+
+	instances, err := p.client.ListInstances(ctx, p.project, fmt.Sprintf(`labels.%s = "%s"`, labelPool, labelValue(p.config.PoolID)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListInstances: %w", p.name, err)
+	}
+
+	for _, instance := range instances {
+		name := metadataValue(instance.Metadata, metadataAgent)
+		if name == "" {
+			name = instance.Name
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// Compute Engine bills per second with a one-minute minimum, which a booting
+// agent outlives anyway.
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
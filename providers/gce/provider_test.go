@@ -0,0 +1,188 @@
+package gce
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/gce/computeapi"
+	"go.woodpecker-ci.org/autoscaler/providers/gce/computeapi/mocks"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+var mockImage = &compute.Image{
+	Name:         "ubuntu-2404-noble-amd64-v20250101",
+	Family:       "ubuntu-2404-lts-amd64",
+	SelfLink:     "https://compute.googleapis.com/compute/v1/projects/ubuntu-os-cloud/global/images/ubuntu-2404-noble-amd64-v20250101",
+	Architecture: "X86_64",
+}
+
+func TestResolveDeployCandidates(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*mocks.MockClient)
+		machineTypes  []string
+		imageFamily   string
+		expectedError string
+	}{
+		{
+			name:          "InvalidImageFamily",
+			setupMocks:    func(*mocks.MockClient) {},
+			machineTypes:  []string{"e2-standard-2:us-central1-a"},
+			imageFamily:   "ubuntu-2404-lts-amd64",
+			expectedError: ErrInvalidImageFamily.Error(),
+		},
+		{
+			name: "ZoneRequired",
+			setupMocks: func(mockClient *mocks.MockClient) {
+				mockClient.On("GetImageFromFamily", mock.Anything, "ubuntu-os-cloud", "ubuntu-2404-lts-amd64").Return(mockImage, nil)
+			},
+			machineTypes:  []string{"e2-standard-2"},
+			expectedError: ErrZoneRequired.Error(),
+		},
+		{
+			name: "ArchitectureMismatch",
+			setupMocks: func(mockClient *mocks.MockClient) {
+				mockClient.On("GetImageFromFamily", mock.Anything, "ubuntu-os-cloud", "ubuntu-2404-lts-amd64").Return(mockImage, nil)
+				mockClient.On("GetMachineType", mock.Anything, "project", "us-central1-a", "t2a-standard-2").
+					Return(&compute.MachineType{Name: "t2a-standard-2", Architecture: "ARM64"}, nil)
+			},
+			machineTypes:  []string{"t2a-standard-2:us-central1-a"},
+			expectedError: ErrArchitectureMismatch.Error(),
+		},
+		{
+			name: "Candidates",
+			setupMocks: func(mockClient *mocks.MockClient) {
+				mockClient.On("GetImageFromFamily", mock.Anything, "ubuntu-os-cloud", "ubuntu-2404-lts-amd64").Return(mockImage, nil)
+				mockClient.On("GetMachineType", mock.Anything, "project", "us-central1-a", "e2-standard-2").
+					Return(&compute.MachineType{Name: "e2-standard-2"}, nil)
+				mockClient.On("GetMachineType", mock.Anything, "project", "europe-west1-b", "n2-standard-2").
+					Return(&compute.MachineType{Name: "n2-standard-2", Architecture: "X86_64"}, nil)
+			},
+			machineTypes: []string{"e2-standard-2:us-central1-a", "n2-standard-2:europe-west1-b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := mocks.NewMockClient(t)
+			tt.setupMocks(mockClient)
+
+			p := &provider{name: "gce", project: "project", client: mockClient}
+
+			imageFamily := tt.imageFamily
+			if imageFamily == "" {
+				imageFamily = "ubuntu-os-cloud/ubuntu-2404-lts-amd64"
+			}
+			err := p.resolveDeployCandidates(t.Context(), tt.machineTypes, imageFamily)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, p.deployCandidates, len(tt.machineTypes))
+		})
+	}
+}
+
+func TestDeployAgent(t *testing.T) {
+	candidates := []deployCandidate{
+		{zone: "us-central1-a", machineType: &compute.MachineType{Name: "e2-standard-2", GuestCpus: 2, MemoryMb: 8192}, image: mockImage},
+		{zone: "us-central1-b", machineType: &compute.MachineType{Name: "e2-standard-2", GuestCpus: 2, MemoryMb: 8192}, image: mockImage},
+	}
+	exhausted := &computeapi.OperationError{Code: "ZONE_RESOURCE_POOL_EXHAUSTED", Message: "no capacity"}
+
+	t.Run("FallbackOnExhaustedZone", func(t *testing.T) {
+		mockClient := mocks.NewMockClient(t)
+		mockClient.On("InsertInstance", mock.Anything, "project", "us-central1-a", mock.Anything).Return(exhausted).Once()
+		mockClient.On("InsertInstance", mock.Anything, "project", "us-central1-b", mock.MatchedBy(func(instance *compute.Instance) bool {
+			return instance.Name == "pool-1-agent-abcd" &&
+				instance.MachineType == "zones/us-central1-b/machineTypes/e2-standard-2" &&
+				metadataValue(instance.Metadata, "woodpecker-agent") == "pool-1-agent-AbCd" &&
+				metadataValue(instance.Metadata, "user-data") != "" &&
+				instance.Labels[labelPool] == "1" &&
+				instance.Disks[0].InitializeParams.SourceImage == mockImage.SelfLink &&
+				instance.NetworkInterfaces[0].Network == "global/networks/default" &&
+				instance.NetworkInterfaces[0].Subnetwork == "regions/us-central1/subnetworks/agents" &&
+				instance.Scheduling == nil
+		})).Return(nil).Once()
+
+		p := &provider{
+			name:             "gce",
+			project:          "project",
+			network:          "default",
+			subnetwork:       "agents",
+			labels:           map[string]string{labelPool: "1"},
+			deployCandidates: candidates,
+			config:           &config.Config{PoolID: "1"},
+			client:           mockClient,
+		}
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-AbCd"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("OtherErrorsDoNotFallBack", func(t *testing.T) {
+		mockClient := mocks.NewMockClient(t)
+		mockClient.On("InsertInstance", mock.Anything, "project", "us-central1-a", mock.Anything).
+			Return(&googleapi.Error{Code: 403, Message: "denied", Errors: []googleapi.ErrorItem{{Reason: "forbidden", Message: "denied"}}}).Once()
+
+		p := &provider{name: "gce", project: "project", deployCandidates: candidates, config: &config.Config{}, client: mockClient}
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "denied")
+	})
+
+	t.Run("Spot", func(t *testing.T) {
+		mockClient := mocks.NewMockClient(t)
+		mockClient.On("InsertInstance", mock.Anything, "project", "us-central1-a", mock.MatchedBy(func(instance *compute.Instance) bool {
+			return instance.Scheduling.ProvisioningModel == "SPOT" &&
+				instance.Scheduling.InstanceTerminationAction == "DELETE" &&
+				len(instance.NetworkInterfaces[0].AccessConfigs) == 1
+		})).Return(nil).Once()
+
+		p := &provider{
+			name:             "gce",
+			project:          "project",
+			spot:             true,
+			publicIP:         true,
+			deployCandidates: candidates[:1],
+			config:           &config.Config{},
+			client:           mockClient,
+		}
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestRemoveAgent(t *testing.T) {
+	mockClient := mocks.NewMockClient(t)
+	mockClient.On("ListInstances", mock.Anything, "project", `(name = "pool-1-agent-abcd") (labels.wp-autoscaler-pool = "1")`).
+		Return([]*compute.Instance{{Name: "pool-1-agent-abcd", Zone: "https://compute.googleapis.com/compute/v1/projects/project/zones/us-central1-b"}}, nil)
+	mockClient.On("DeleteInstance", mock.Anything, "project", "us-central1-b", "pool-1-agent-abcd").Return(nil)
+
+	p := &provider{name: "gce", project: "project", config: &config.Config{PoolID: "1"}, client: mockClient}
+
+	err := p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-AbCd"})
+	assert.NoError(t, err)
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	mockClient := mocks.NewMockClient(t)
+	mockClient.On("ListInstances", mock.Anything, "project", `labels.wp-autoscaler-pool = "1"`).Return([]*compute.Instance{
+		{Name: "pool-1-agent-abcd", Metadata: &compute.Metadata{Items: []*compute.MetadataItems{{Key: "woodpecker-agent", Value: utils.ToPtr("pool-1-agent-AbCd")}}}},
+		{Name: "pool-1-agent-efgh"},
+	}, nil)
+
+	p := &provider{name: "gce", project: "project", config: &config.Config{PoolID: "1"}, client: mockClient}
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-AbCd", "pool-1-agent-efgh"}, names)
+}
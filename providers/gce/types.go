@@ -0,0 +1,21 @@
+package gce
+
+import (
+	"errors"
+
+	"google.golang.org/api/compute/v1"
+)
+
+var (
+	ErrIllegalLabelPrefix   = errors.New("illegal label prefix")
+	ErrProjectRequired      = errors.New("project is required")
+	ErrZoneRequired         = errors.New("zone is required")
+	ErrInvalidImageFamily   = errors.New("image family must be given as project/family")
+	ErrArchitectureMismatch = errors.New("image architecture does not match machine type")
+)
+
+type deployCandidate struct {
+	zone        string
+	machineType *compute.MachineType
+	image       *compute.Image
+}
@@ -10,6 +10,7 @@ import (
 
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/providers/hetznercloud/hcapi"
@@ -17,6 +18,12 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
 )
 
+// UserDataLimit is the maximum size of server user data in bytes.
+const UserDataLimit = 32 << 10
+
+// server types report their memory in GB
+const mibPerGB = 1024
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://docs.hetzner.cloud/ (Server Metadata, http://169.254.169.254/hetzner/v1)
 var blackholeMetadataAPI = []string{
@@ -73,13 +80,6 @@ func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Prov
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
-	})
-	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
-	}
-
 	sshKeys := make([]*hcloud.SSHKey, 0)
 	for _, item := range p.sshKeys {
 		key, _, err := p.client.SSHKey().GetByName(ctx, item)
@@ -118,7 +118,6 @@ func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) err
 
 	serverCreateOpts := hcloud.ServerCreateOpts{
 		Name:      agent.Name,
-		UserData:  userData,
 		SSHKeys:   sshKeys,
 		Networks:  networks,
 		Firewalls: firewalls,
@@ -138,6 +137,13 @@ func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) err
 		if c.location != nil {
 			locationName = c.location.Name
 		}
+
+		userData, err := p.renderUserData(agent, c, locationName)
+		if err != nil {
+			return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+		}
+		serverCreateOpts.UserData = userData
+
 		log.Info().Msgf("create agent: location = %s type = %s", locationName, c.serverType.Name)
 
 		_, _, err = p.client.Server().Create(ctx, serverCreateOpts)
@@ -166,6 +172,21 @@ func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) err
 	return nil
 }
 
+// renderUserData renders the user data for the agent on the candidate.
+func (p *provider) renderUserData(agent *woodpecker.Agent, c deployCandidate, location string) (string, error) {
+	return inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		OSFamily: cloudinit.DetectOSFamily(c.image.OSFlavor),
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: c.serverType.Name,
+			Region:       location,
+			Arch:         cloudinit.NormalizeArch(string(c.serverType.Architecture)),
+			Workflows:    p.config.Workflows(c.serverType.Name, c.serverType.Cores, int64(c.serverType.Memory*mibPerGB)),
+		},
+	})
+}
+
 func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
 	server, err := p.getAgent(ctx, agent)
 	if err != nil {
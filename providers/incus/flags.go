@@ -0,0 +1,96 @@
+package incus
+
+import (
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Incus"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "incus-remote",
+			Value:    "unix:///var/lib/incus/unix.socket",
+			Usage:    "incus or lxd server as unix:///path of its socket or https://host:port",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_REMOTE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-client-cert",
+			Usage:    "path to the client certificate for https remotes",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_CLIENT_CERT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-client-key",
+			Usage:    "path to the client key for https remotes",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_CLIENT_KEY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-server-cert",
+			Usage:    "path to the certificate of the https remote, which is pinned; the system roots are used if empty",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_SERVER_CERT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-project",
+			Usage:    "project the instances are created in, the default project if empty",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_PROJECT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-type",
+			Value:    string(api.InstanceTypeContainer),
+			Usage:    "type of the instances, container or virtual-machine",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_TYPE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-image",
+			Value:    "ubuntu/24.04/cloud",
+			Usage:    "alias of the image the instances are created from, a cloud variant with cloud-init",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_IMAGE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "incus-image-server",
+			Value:    "https://images.linuxcontainers.org",
+			Usage:    "simplestreams server of the image, the images of the remote are used if empty",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_IMAGE_SERVER"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "incus-profiles",
+			Value:    []string{"default"},
+			Usage:    "profiles applied to the instances",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_PROFILES"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "incus-targets",
+			Usage:    "cluster members the instances are created on, in order of preference, the next is tried if one fails; the cluster chooses if empty",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_TARGETS"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "incus-cpus",
+			Usage:    "cpu limit of the instances, the one of the profiles if 0",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_CPUS"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "incus-memory",
+			Usage:    "memory limit of the instances in MiB, the one of the profiles if 0",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_MEMORY"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "incus-config",
+			Usage:    "additional config of the instances as list of key=value pairs",
+			Sources:  cli.EnvVars("WOODPECKER_INCUS_CONFIG"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,29 @@
+package incusapi
+
+import (
+	"context"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// Client is the subset of the Incus API the incus provider uses, it works
+// with LXD as well. Asynchronous operations wait until the operation has
+// finished.
+type Client interface {
+	// Server returns the server, the cluster member target if not empty.
+	Server(ctx context.Context, target string) (*api.Server, error)
+	// CreateInstance creates the instance on the cluster member target, or
+	// the one the cluster chooses if empty.
+	CreateInstance(ctx context.Context, target string, instance api.InstancesPost) error
+	StartInstance(ctx context.Context, name string) error
+	// StopInstance stops the instance forcefully.
+	StopInstance(ctx context.Context, name string) error
+	DeleteInstance(ctx context.Context, name string) error
+	ListInstances(ctx context.Context) ([]api.Instance, error)
+}
+
+// IsError reports whether err is an error response of the API with one of
+// the status codes.
+func IsError(err error, statusCodes ...int) bool {
+	return api.StatusErrorCheck(err, statusCodes...)
+}
@@ -0,0 +1,139 @@
+package incusapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+var ErrUnsupportedRemote = errors.New("unsupported remote, must be unix:// or https://")
+
+type client struct {
+	server incus.InstanceServer
+}
+
+// NewClient creates a client for the remote, given as unix:///path of the
+// socket or https://host:port. HTTPS connections are made with the TLS
+// settings of args. All requests are made in the project, the default one
+// if empty.
+func NewClient(remote, project string, args *incus.ConnectionArgs) (Client, error) {
+	u, err := url.Parse(remote)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedRemote, remote)
+	}
+
+	connArgs := incus.ConnectionArgs{}
+	if args != nil {
+		connArgs = *args
+	}
+	connArgs.UserAgent = "woodpecker-autoscaler/" + version.String()
+	// the server is only contacted by the requests, and operations are
+	// waited for without listening to the events
+	connArgs.SkipGetServer = true
+	connArgs.SkipGetEvents = true
+
+	var server incus.InstanceServer
+	switch u.Scheme {
+	case "unix":
+		server, err = incus.ConnectIncusUnix(u.Path, &connArgs)
+	case "https":
+		server, err = incus.ConnectIncus("https://"+u.Host, &connArgs)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedRemote, remote)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if project != "" {
+		server = server.UseProject(project)
+	}
+	return &client{server: server}, nil
+}
+
+// TLSArgs returns the connection args of HTTPS remotes authenticating with
+// the client certificate and key. The server certificate is pinned to
+// serverCertFile if set, as the incus CLI does, and verified against the
+// system roots otherwise.
+func TLSArgs(certFile, keyFile, serverCertFile string) (*incus.ConnectionArgs, error) {
+	cert, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	args := &incus.ConnectionArgs{TLSClientCert: string(cert), TLSClientKey: string(key)}
+
+	if serverCertFile != "" {
+		serverCert, err := os.ReadFile(serverCertFile)
+		if err != nil {
+			return nil, err
+		}
+		args.TLSServerCert = string(serverCert)
+	}
+
+	return args, nil
+}
+
+// use returns the server making its requests with ctx, on the cluster member
+// target if not empty.
+func (c *client) use(ctx context.Context, target string) incus.InstanceServer {
+	// UseTarget returns a copy, so the context is not shared with concurrent
+	// requests
+	server := c.server.UseTarget(target)
+	if s, ok := server.(*incus.ProtocolIncus); ok {
+		return s.WithContext(ctx)
+	}
+	return server
+}
+
+func (c *client) Server(ctx context.Context, target string) (*api.Server, error) {
+	server, _, err := c.use(ctx, target).GetServer()
+	return server, err
+}
+
+func (c *client) CreateInstance(ctx context.Context, target string, instance api.InstancesPost) error {
+	op, err := c.use(ctx, target).CreateInstance(instance)
+	if err != nil {
+		return err
+	}
+	return op.WaitContext(ctx)
+}
+
+func (c *client) updateState(ctx context.Context, name, action string) error {
+	state := api.InstanceStatePut{Action: action, Force: true, Timeout: -1}
+	op, err := c.use(ctx, "").UpdateInstanceState(name, state, "")
+	if err != nil {
+		return err
+	}
+	return op.WaitContext(ctx)
+}
+
+func (c *client) StartInstance(ctx context.Context, name string) error {
+	return c.updateState(ctx, name, "start")
+}
+
+func (c *client) StopInstance(ctx context.Context, name string) error {
+	return c.updateState(ctx, name, "stop")
+}
+
+func (c *client) DeleteInstance(ctx context.Context, name string) error {
+	op, err := c.use(ctx, "").DeleteInstance(name)
+	if err != nil {
+		return err
+	}
+	return op.WaitContext(ctx)
+}
+
+func (c *client) ListInstances(ctx context.Context) ([]api.Instance, error) {
+	return c.use(ctx, "").GetInstances(api.InstanceTypeAny)
+}
@@ -0,0 +1,92 @@
+package incusapi_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/providers/incus/incusapi"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) incusapi.Client {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.UserAgent(), "woodpecker-autoscaler/"))
+		assert.Equal(t, "ci", r.URL.Query().Get("project"))
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	// the server certificate is pinned
+	serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	client, err := incusapi.NewClient(server.URL, "ci", &incus.ConnectionArgs{TLSServerCert: string(serverCert)})
+	require.NoError(t, err)
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := incusapi.NewClient("ssh://incus", "", nil)
+	assert.ErrorIs(t, err, incusapi.ErrUnsupportedRemote)
+
+	for _, remote := range []string{"unix:///var/lib/incus/unix.socket", "https://incus:8443"} {
+		_, err := incusapi.NewClient(remote, "", nil)
+		assert.NoError(t, err, remote)
+	}
+}
+
+func TestCreateInstance(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1.0/instances":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "member1", r.URL.Query().Get("target"))
+			var instance api.InstancesPost
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&instance))
+			assert.Equal(t, "agent", instance.Name)
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"type": "async", "status_code": 100, "operation": "/1.0/operations/b0d1", "metadata": {"id": "b0d1", "status_code": 103}}`))
+		case "/1.0/operations/b0d1/wait":
+			_, _ = w.Write([]byte(`{"type": "sync", "status_code": 200, "metadata": {"id": "b0d1", "status_code": 400, "err": "Failed getting remote image info: Failed getting image: The requested image couldn't be found"}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	err := client.CreateInstance(t.Context(), "member1", api.InstancesPost{Name: "agent", Type: api.InstanceTypeContainer})
+	assert.ErrorContains(t, err, "couldn't be found")
+}
+
+func TestListInstances(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1.0/instances", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("recursion"))
+		_, _ = w.Write([]byte(`{"type": "sync", "status_code": 200, "metadata": [{"name": "agent", "status": "Running", "location": "member1", "config": {"user.wp-autoscaler.pool": "1"}}]}`))
+	})
+
+	instances, err := client.ListInstances(t.Context())
+	assert.NoError(t, err)
+	if assert.Len(t, instances, 1) {
+		assert.Equal(t, "agent", instances[0].Name)
+		assert.Equal(t, "1", instances[0].Config["user.wp-autoscaler.pool"])
+	}
+}
+
+func TestDeleteInstanceNotFound(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"type": "error", "error_code": 404, "error": "Instance not found"}`))
+	})
+
+	err := client.DeleteInstance(t.Context(), "agent")
+	assert.True(t, incusapi.IsError(err, http.StatusNotFound))
+	assert.ErrorContains(t, err, "Instance not found")
+}
@@ -0,0 +1,262 @@
+package incus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/incus/incusapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// configPrefix is the prefix of the config keys set by the autoscaler, user
+// keys are free-form.
+const configPrefix = "user.wp-autoscaler."
+
+// configPool identifies the instances of the pool.
+const configPool = configPrefix + "pool"
+
+type provider struct {
+	name     string
+	typ      api.InstanceType
+	source   api.InstanceSource
+	profiles []string
+	targets  []string
+	cpus     int
+	memory   int64
+	// instanceConfig is the additional config of the instances
+	instanceConfig map[string]string
+	config         *config.Config
+	client         incusapi.Client
+}
+
+func New(_ context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:     "incus",
+		typ:      api.InstanceType(c.String("incus-type")),
+		profiles: c.StringSlice("incus-profiles"),
+		targets:  c.StringSlice("incus-targets"),
+		cpus:     c.Int("incus-cpus"),
+		memory:   int64(c.Int("incus-memory")),
+		config:   config,
+	}
+
+	if p.typ != api.InstanceTypeContainer && p.typ != api.InstanceTypeVM {
+		return nil, fmt.Errorf("%s: %w: %s", p.name, ErrInvalidType, p.typ)
+	}
+	if len(p.targets) == 0 {
+		// let the cluster choose, or the standalone server
+		p.targets = []string{""}
+	}
+
+	p.source = api.InstanceSource{Type: "image", Alias: c.String("incus-image")}
+	if p.source.Alias == "" {
+		return nil, fmt.Errorf("%s: %w: incus-image", p.name, ErrMissingSetting)
+	}
+	if server := c.String("incus-image-server"); server != "" {
+		p.source.Server = server
+		p.source.Protocol = "simplestreams"
+	}
+
+	remote := c.String("incus-remote")
+	var args *incus.ConnectionArgs
+	if strings.HasPrefix(remote, "https://") {
+		var err error
+		args, err = incusapi.TLSArgs(c.String("incus-client-cert"), c.String("incus-client-key"), c.String("incus-server-cert"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+	client, err := incusapi.NewClient(remote, c.String("incus-project"), args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.client = client
+
+	userConfig := c.StringSlice("incus-config")
+	if err := utils.CheckReservedTags(userConfig, configPrefix, ErrIllegalConfigPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if p.instanceConfig, err = utils.SliceToMap(userConfig, "="); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return p, nil
+}
+
+// instance returns the instance of the agent with the user data.
+func (p *provider) instance(agent *woodpecker.Agent, userData string) api.InstancesPost {
+	config := map[string]string{
+		configPool:             p.config.PoolID,
+		"cloud-init.user-data": userData,
+	}
+	if p.typ == api.InstanceTypeContainer {
+		// the agent runs workflows with docker inside the container
+		config["security.nesting"] = "true"
+	}
+	if p.cpus > 0 {
+		config["limits.cpu"] = strconv.Itoa(p.cpus)
+	}
+	if p.memory > 0 {
+		config["limits.memory"] = fmt.Sprintf("%dMiB", p.memory)
+	}
+
+	return api.InstancesPost{
+		Name:   agent.Name,
+		Type:   p.typ,
+		Source: p.source,
+		InstancePut: api.InstancePut{
+			Profiles: p.profiles,
+			Config:   utils.MergeMaps(p.instanceConfig, config),
+		},
+	}
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	for i, target := range p.targets {
+		err := p.deployAgent(ctx, agent, target)
+		if err == nil {
+			return nil
+		}
+
+		// Continue to next fallback entry only if the target is offline or
+		// out of capacity.
+		if !unavailable(err) {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+
+		// Only log and continue if there are more candidates left.
+		if i < len(p.targets)-1 && ctx.Err() == nil {
+			log.Warn().Msgf("create agent failed: target = %s: %s", target, err)
+			continue
+		}
+
+		// Last candidate failed.
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// unavailable reports whether the error is caused by a cluster member that
+// is offline or out of disk space or memory.
+func unavailable(err error) bool {
+	if incusapi.IsError(err, http.StatusServiceUnavailable) {
+		return true
+	}
+	// failed operations only carry the message
+	msg := err.Error()
+	return strings.Contains(msg, "no space left on device") || strings.Contains(msg, "cannot allocate memory")
+}
+
+// deployAgent creates and starts the instance of the agent on the target,
+// a failed instance is deleted again.
+func (p *provider) deployAgent(ctx context.Context, agent *woodpecker.Agent, target string) error {
+	server, err := p.client.Server(ctx, target)
+	if err != nil {
+		return fmt.Errorf("Server: %w", err)
+	}
+	arch := ""
+	if len(server.Environment.Architectures) > 0 {
+		arch = cloudinit.NormalizeArch(server.Environment.Architectures[0])
+	}
+
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Region:    target,
+			Arch:      arch,
+			Workflows: p.config.Workflows("", p.cpus, p.memory),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("inits.RenderUserDataTemplate: %w", err)
+	}
+
+	log.Info().Msgf("create agent: target = %s type = %s image = %s", target, p.typ, p.source.Alias)
+
+	if err := p.client.CreateInstance(ctx, target, p.instance(agent, userData)); err != nil {
+		return fmt.Errorf("CreateInstance: %w", err)
+	}
+
+	if err := p.client.StartInstance(ctx, agent.Name); err != nil {
+		err = fmt.Errorf("StartInstance: %w", err)
+		if deleteErr := p.client.DeleteInstance(ctx, agent.Name); deleteErr != nil && !incusapi.IsError(deleteErr, http.StatusNotFound) {
+			err = errors.Join(err, fmt.Errorf("DeleteInstance: %w", deleteErr))
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	instances, err := p.poolInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: ListInstances: %w", p.name, err)
+	}
+
+	for _, instance := range instances {
+		if instance.Name != agent.Name {
+			continue
+		}
+
+		if instance.Status == "Running" {
+			if err := p.client.StopInstance(ctx, instance.Name); err != nil {
+				return fmt.Errorf("%s: StopInstance: %w", p.name, err)
+			}
+		}
+		err := p.client.DeleteInstance(ctx, instance.Name)
+		if err != nil && !incusapi.IsError(err, http.StatusNotFound) {
+			return fmt.Errorf("%s: DeleteInstance: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+// poolInstances returns the instances of the pool.
+func (p *provider) poolInstances(ctx context.Context) ([]api.Instance, error) {
+	instances, err := p.client.ListInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pool []api.Instance
+	for _, instance := range instances {
+		if instance.Config[configPool] == p.config.PoolID {
+			pool = append(pool, instance)
+		}
+	}
+	return pool, nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	instances, err := p.poolInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListInstances: %w", p.name, err)
+	}
+
+	var names []string
+	for _, instance := range instances {
+		names = append(names, instance.Name)
+	}
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
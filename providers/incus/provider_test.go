@@ -0,0 +1,129 @@
+package incus
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/incus/incusapi"
+	"go.woodpecker-ci.org/autoscaler/providers/incus/incusapi/mocks"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func newTestProvider(client incusapi.Client, targets ...string) *provider {
+	return &provider{
+		name:           "incus",
+		typ:            api.InstanceTypeContainer,
+		source:         api.InstanceSource{Type: "image", Alias: "ubuntu/24.04/cloud", Server: "https://images.linuxcontainers.org", Protocol: "simplestreams"},
+		profiles:       []string{"default", "ci"},
+		targets:        targets,
+		cpus:           4,
+		memory:         8192,
+		instanceConfig: map[string]string{"limits.cpu": "2", "boot.autostart": "false"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: client,
+	}
+}
+
+// newInstance returns an instance of the pool.
+func newInstance(name, status, poolID string) api.Instance {
+	instance := api.Instance{Name: name, Status: status}
+	if poolID != "" {
+		instance.Config = map[string]string{configPool: poolID}
+	}
+	return instance
+}
+
+func TestDeployAgent(t *testing.T) {
+	t.Run("FallbackTarget", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Server", mock.Anything, "member1").Return(nil, api.StatusErrorf(http.StatusServiceUnavailable, "Missing event connection with target cluster member")).Once()
+		client.On("Server", mock.Anything, "member2").Return(&api.Server{Environment: api.ServerEnvironment{Architectures: []string{"x86_64", "i686"}}}, nil).Once()
+		client.On("CreateInstance", mock.Anything, "member2", mock.Anything).Return(errors.New("Failed creating instance from image: no space left on device")).Once()
+		client.On("Server", mock.Anything, "member3").Return(&api.Server{Environment: api.ServerEnvironment{Architectures: []string{"aarch64"}}}, nil).Once()
+		client.On("CreateInstance", mock.Anything, "member3", mock.MatchedBy(func(i api.InstancesPost) bool {
+			return i.Name == "pool-1-agent-abcd" &&
+				i.Type == api.InstanceTypeContainer &&
+				i.Source.Alias == "ubuntu/24.04/cloud" &&
+				i.Profiles[1] == "ci" &&
+				i.Config[configPool] == "1" &&
+				i.Config["security.nesting"] == "true" &&
+				i.Config["limits.cpu"] == "4" &&
+				i.Config["limits.memory"] == "8192MiB" &&
+				i.Config["boot.autostart"] == "false" &&
+				strings.HasPrefix(i.Config["cloud-init.user-data"], "#cloud-config") &&
+				strings.Contains(i.Config["cloud-init.user-data"], "autoscaler.arch=arm64") &&
+				strings.Contains(i.Config["cloud-init.user-data"], "autoscaler.region=member3")
+		})).Return(nil).Once()
+		client.On("StartInstance", mock.Anything, "pool-1-agent-abcd").Return(nil).Once()
+
+		p := newTestProvider(client, "member1", "member2", "member3")
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+	})
+
+	t.Run("NoFallbackOnOtherErrors", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Server", mock.Anything, "member1").Return(&api.Server{}, nil).Once()
+		client.On("CreateInstance", mock.Anything, "member1", mock.Anything).Return(errors.New("Failed getting remote image info: Failed getting image: The requested image couldn't be found")).Once()
+
+		p := newTestProvider(client, "member1", "member2")
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "incus: CreateInstance")
+		assert.ErrorContains(t, err, "couldn't be found")
+	})
+
+	t.Run("DeletedIfNotStarted", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Server", mock.Anything, "").Return(&api.Server{}, nil).Once()
+		client.On("CreateInstance", mock.Anything, "", mock.Anything).Return(nil).Once()
+		client.On("StartInstance", mock.Anything, "pool-1-agent-abcd").Return(api.StatusErrorf(http.StatusBadRequest, "Failed to start device \"eth0\"")).Once()
+		client.On("DeleteInstance", mock.Anything, "pool-1-agent-abcd").Return(nil).Once()
+
+		p := newTestProvider(client, "")
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "incus: StartInstance")
+		assert.ErrorContains(t, err, "eth0")
+	})
+}
+
+func TestRemoveAgent(t *testing.T) {
+	client := mocks.NewMockClient(t)
+	client.On("ListInstances", mock.Anything).Return([]api.Instance{
+		newInstance("pool-1-agent-abcd", "Running", "1"),
+		newInstance("pool-1-agent-efgh", "Stopped", "1"),
+		// same name in another pool
+		newInstance("pool-1-agent-abcd", "Running", "2"),
+	}, nil)
+	client.On("StopInstance", mock.Anything, "pool-1-agent-abcd").Return(nil).Once()
+	client.On("DeleteInstance", mock.Anything, "pool-1-agent-abcd").Return(nil).Once()
+	client.On("DeleteInstance", mock.Anything, "pool-1-agent-efgh").Return(nil).Once()
+
+	p := newTestProvider(client)
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-efgh"}))
+	// already removed
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-ijkl"}))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	client := mocks.NewMockClient(t)
+	client.On("ListInstances", mock.Anything).Return([]api.Instance{
+		newInstance("pool-1-agent-a", "", "1"),
+		newInstance("pool-2-agent-b", "", "2"),
+		newInstance("web", "", ""),
+	}, nil)
+
+	names, err := newTestProvider(client).ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-a"}, names)
+}
@@ -0,0 +1,11 @@
+package incus
+
+import (
+	"errors"
+)
+
+var (
+	ErrIllegalConfigPrefix = errors.New("illegal config prefix")
+	ErrInvalidType         = errors.New("type must be container or virtual-machine")
+	ErrMissingSetting      = errors.New("missing setting")
+)
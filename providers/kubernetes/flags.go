@@ -0,0 +1,100 @@
+package kubernetes
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Kubernetes"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "kubernetes-kubeconfig",
+			Usage:    "path to a kubeconfig file, defaults to the service account of the pod the autoscaler runs in",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_KUBECONFIG", "KUBECONFIG"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-context",
+			Usage:    "kubeconfig context to use, defaults to the current context",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_CONTEXT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-namespace",
+			Value:    "default",
+			Usage:    "namespace the agents are created in",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_NAMESPACE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-workload",
+			Value:    WorkloadPod,
+			Usage:    "run each agent as pod or as deployment of one replica, which is rescheduled if its node goes away",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_WORKLOAD"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-backend",
+			Value:    BackendDocker,
+			Usage:    "backend the agents run workflows with: docker, in a privileged docker-in-docker sidecar, or kubernetes, as pods of the cluster",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_BACKEND"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-dind-image",
+			Value:    "docker:dind",
+			Usage:    "image of the docker-in-docker sidecar",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_DIND_IMAGE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-service-account",
+			Usage:    "service account of the agents, with the kubernetes backend it needs to manage the pods of workflows",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_SERVICE_ACCOUNT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-cpu-request",
+			Usage:    "cpu request of the container running the workflows, e.g. 2 or 500m",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_CPU_REQUEST"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-cpu-limit",
+			Usage:    "cpu limit of the container running the workflows",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_CPU_LIMIT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-memory-request",
+			Usage:    "memory request of the container running the workflows, e.g. 4Gi",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_MEMORY_REQUEST"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "kubernetes-memory-limit",
+			Usage:    "memory limit of the container running the workflows",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_MEMORY_LIMIT"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "kubernetes-node-selector",
+			Usage:    "node labels as list of key=value pairs the agents are scheduled on",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_NODE_SELECTOR"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "kubernetes-tolerations",
+			Usage:    "taints the agents tolerate as list of key[=value]:effect, without value any value is tolerated",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_TOLERATIONS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "kubernetes-labels",
+			Usage:    "labels of the agent pods as list of key=value pairs",
+			Sources:  cli.EnvVars("WOODPECKER_KUBERNETES_LABELS"),
+			Category: category,
+		},
+	}
+}
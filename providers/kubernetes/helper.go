@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// invalidNameChars matches what is not allowed in object names.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]`)
+
+// objectName returns the name of the agent's objects. Object names must be
+// lowercase, the agent name is kept in an annotation.
+func objectName(agentName string) string {
+	return invalidNameChars.ReplaceAllString(strings.ToLower(agentName), "-")
+}
+
+const (
+	bytesPerMiB  = 1 << 20
+	milliPerCore = 1000
+)
+
+// parseQuantity parses the quantity of the resource, nil if empty.
+func parseQuantity(name corev1.ResourceName, quantity string) (*resource.Quantity, error) {
+	if quantity == "" {
+		return nil, nil
+	}
+	q, err := resource.ParseQuantity(quantity)
+	if err != nil || q.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %s %q", ErrInvalidQuantity, name, quantity)
+	}
+	return &q, nil
+}
+
+// parseCPU returns the whole cores of a cpu quantity, e.g. 1 of 1500m, and 0
+// if empty.
+func parseCPU(quantity string) (int, error) {
+	q, err := parseQuantity(corev1.ResourceCPU, quantity)
+	if q == nil {
+		return 0, err
+	}
+	return int(q.MilliValue() / milliPerCore), nil
+}
+
+// parseMemory returns a memory quantity in MiB, e.g. 4096 of 4Gi, and 0 if
+// empty.
+func parseMemory(quantity string) (int64, error) {
+	q, err := parseQuantity(corev1.ResourceMemory, quantity)
+	if q == nil {
+		return 0, err
+	}
+	return q.Value() / bytesPerMiB, nil
+}
+
+// parseTolerations parses tolerations given as key[=value]:effect.
+func parseTolerations(raw []string) ([]corev1.Toleration, error) {
+	tolerations := make([]corev1.Toleration, 0, len(raw))
+	for _, item := range raw {
+		taint, effect, ok := strings.Cut(item, ":")
+		if !ok || taint == "" || effect == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidToleration, item)
+		}
+		toleration := corev1.Toleration{Key: taint, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffect(effect)}
+		if k, v, ok := strings.Cut(taint, "="); ok {
+			toleration.Key, toleration.Operator, toleration.Value = k, corev1.TolerationOpEqual, v
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	return tolerations, nil
+}
+
+// quantities returns the cpu and memory that are set, nil if none.
+func quantities(cpu, memory string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+	for name, quantity := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory} {
+		q, err := parseQuantity(name, quantity)
+		if err != nil {
+			return nil, err
+		}
+		if q != nil {
+			list[name] = *q
+		}
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return list, nil
+}
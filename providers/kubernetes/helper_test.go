@@ -0,0 +1,68 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseCPU(t *testing.T) {
+	tests := []struct {
+		quantity string
+		cpus     int
+		err      bool
+	}{
+		{"", 0, false},
+		{"2", 2, false},
+		{"1.5", 1, false},
+		{"2500m", 2, false},
+		{"500m", 0, false},
+		{"two", 0, true},
+	}
+	for _, tt := range tests {
+		cpus, err := parseCPU(tt.quantity)
+		if tt.err {
+			assert.ErrorIs(t, err, ErrInvalidQuantity, tt.quantity)
+			continue
+		}
+		assert.NoError(t, err, tt.quantity)
+		assert.Equal(t, tt.cpus, cpus, tt.quantity)
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		quantity string
+		mib      int64
+		err      bool
+	}{
+		{"", 0, false},
+		{"4Gi", 4096, false},
+		{"512Mi", 512, false},
+		{"1G", 953, false},
+		{"1073741824", 1024, false},
+		{"4GB", 0, true},
+	}
+	for _, tt := range tests {
+		mib, err := parseMemory(tt.quantity)
+		if tt.err {
+			assert.ErrorIs(t, err, ErrInvalidQuantity, tt.quantity)
+			continue
+		}
+		assert.NoError(t, err, tt.quantity)
+		assert.Equal(t, tt.mib, mib, tt.quantity)
+	}
+}
+
+func TestParseTolerations(t *testing.T) {
+	tolerations, err := parseTolerations([]string{"dedicated=ci:NoSchedule", "spot:NoExecute"})
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ci", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	}, tolerations)
+
+	_, err = parseTolerations([]string{"dedicated=ci"})
+	assert.ErrorIs(t, err, ErrInvalidToleration)
+}
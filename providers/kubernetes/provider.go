@@ -0,0 +1,376 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+var (
+	// labelAgent selects the pod of a deployment.
+	labelAgent = engine.LabelPrefix + "agent"
+	// annotationAgent keeps the agent name, object names are lowercase.
+	annotationAgent = engine.LabelPrefix + "agent-name"
+)
+
+const (
+	// secretKey is the key of the agent token in the agent's secret.
+	secretKey = "token"
+	// dockerHost is where the agent reaches the docker-in-docker sidecar.
+	dockerHost = "tcp://localhost:2375"
+)
+
+type provider struct {
+	name           string
+	namespace      string
+	workload       string
+	backend        string
+	dindImage      string
+	serviceAccount string
+	resources      corev1.ResourceRequirements
+	cpus           int
+	memory         int64
+	nodeSelector   map[string]string
+	tolerations    []corev1.Toleration
+	labels         map[string]string
+	config         *config.Config
+	client         kubernetes.Interface
+}
+
+func New(_ context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:           "kubernetes",
+		namespace:      c.String("kubernetes-namespace"),
+		workload:       c.String("kubernetes-workload"),
+		backend:        c.String("kubernetes-backend"),
+		dindImage:      c.String("kubernetes-dind-image"),
+		serviceAccount: c.String("kubernetes-service-account"),
+		config:         config,
+	}
+
+	if p.workload != WorkloadPod && p.workload != WorkloadDeployment {
+		return nil, fmt.Errorf("%s: %w: %q", p.name, ErrInvalidWorkload, p.workload)
+	}
+	if p.backend != BackendDocker && p.backend != BackendKubernetes {
+		return nil, fmt.Errorf("%s: %w: %q", p.name, ErrInvalidBackend, p.backend)
+	}
+
+	if err := p.setResources(
+		c.String("kubernetes-cpu-request"), c.String("kubernetes-memory-request"),
+		c.String("kubernetes-cpu-limit"), c.String("kubernetes-memory-limit"),
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	nodeSelector, err := utils.SliceToMap(c.StringSlice("kubernetes-node-selector"), "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.nodeSelector = nodeSelector
+
+	if p.tolerations, err = parseTolerations(c.StringSlice("kubernetes-tolerations")); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	userLabels := c.StringSlice("kubernetes-labels")
+	if err := utils.CheckReservedTags(userLabels, engine.LabelPrefix, ErrIllegalLabelPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	labels, err := utils.SliceToMap(userLabels, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.labels = utils.MergeMaps(labels, map[string]string{
+		engine.LabelPool: p.config.PoolID,
+	})
+
+	restConfig, err := restConfig(c.String("kubernetes-kubeconfig"), c.String("kubernetes-context"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if p.client, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return nil, fmt.Errorf("%s: kubernetes.NewForConfig: %w", p.name, err)
+	}
+
+	return p, nil
+}
+
+// restConfig returns the config of the context in the kubeconfig file, the
+// current context if empty, or without a file the in-cluster config.
+func restConfig(kubeconfig, kubeContext string) (*rest.Config, error) {
+	if kubeconfig == "" {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("rest.InClusterConfig: %w", err)
+		}
+		return restConfig, nil
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	return restConfig, nil
+}
+
+// setResources sets the resources of the container running the workflows,
+// their capacity is derived from the limits, else the requests.
+func (p *provider) setResources(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) error {
+	var err error
+	if p.resources.Requests, err = quantities(cpuRequest, memoryRequest); err != nil {
+		return err
+	}
+	if p.resources.Limits, err = quantities(cpuLimit, memoryLimit); err != nil {
+		return err
+	}
+
+	cpu, memory := cpuLimit, memoryLimit
+	if cpu == "" {
+		cpu = cpuRequest
+	}
+	if memory == "" {
+		memory = memoryRequest
+	}
+
+	if p.cpus, err = parseCPU(cpu); err != nil {
+		return err
+	}
+	p.memory, err = parseMemory(memory)
+	return err
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	name := objectName(agent.Name)
+
+	spec, err := p.podSpec(agent, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	log.Info().Msgf("create agent: namespace = %s %s = %s", p.namespace, p.workload, name)
+
+	_, err = p.client.CoreV1().Secrets(p.namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: p.objectMeta(agent, name),
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{secretKey: agent.Token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: CreateSecret: %w", p.name, err)
+	}
+
+	if p.workload == WorkloadDeployment {
+		template := p.objectMeta(agent, name)
+		// the pool label stays on the deployment, so its pod is not listed
+		// as agent of its own
+		delete(template.Labels, engine.LabelPool)
+		replicas := int32(1)
+		_, err = p.client.AppsV1().Deployments(p.namespace).Create(ctx, &appsv1.Deployment{
+			ObjectMeta: p.objectMeta(agent, name),
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{labelAgent: name}},
+				Template: corev1.PodTemplateSpec{ObjectMeta: template, Spec: *spec},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			err = fmt.Errorf("CreateDeployment: %w", err)
+		}
+	} else {
+		_, err = p.client.CoreV1().Pods(p.namespace).Create(ctx, &corev1.Pod{
+			ObjectMeta: p.objectMeta(agent, name),
+			Spec:       *spec,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			err = fmt.Errorf("CreatePod: %w", err)
+		}
+	}
+
+	if err != nil {
+		if cleanupErr := p.client.CoreV1().Secrets(p.namespace).Delete(ctx, name, metav1.DeleteOptions{}); cleanupErr != nil {
+			err = errors.Join(err, fmt.Errorf("DeleteSecret: %w", cleanupErr))
+		}
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return nil
+}
+
+func (p *provider) objectMeta(agent *woodpecker.Agent, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Labels:      utils.MergeMaps(p.labels, map[string]string{labelAgent: name}),
+		Annotations: map[string]string{annotationAgent: agent.Name},
+	}
+}
+
+// podSpec returns the pod running the agent, and with the docker backend its
+// docker-in-docker sidecar.
+func (p *provider) podSpec(agent *woodpecker.Agent, name string) (*corev1.PodSpec, error) {
+	env, err := p.agentEnv(agent, name)
+	if err != nil {
+		return nil, err
+	}
+
+	agentContainer := corev1.Container{
+		Name:  "agent",
+		Image: p.config.Image,
+		Env:   env,
+	}
+	spec := &corev1.PodSpec{
+		RestartPolicy:      corev1.RestartPolicyAlways,
+		ServiceAccountName: p.serviceAccount,
+		NodeSelector:       p.nodeSelector,
+		Tolerations:        p.tolerations,
+	}
+
+	if p.backend == BackendKubernetes {
+		agentContainer.Resources = p.resources
+		spec.Containers = []corev1.Container{agentContainer}
+		return spec, nil
+	}
+
+	// steps must not reach the cluster with the token of the service account
+	automount := false
+	privileged := true
+	spec.AutomountServiceAccountToken = &automount
+	spec.Containers = []corev1.Container{agentContainer, {
+		Name:  "dind",
+		Image: p.dindImage,
+		// listen without tls, the daemon is only reachable inside the pod
+		Env:             []corev1.EnvVar{{Name: "DOCKER_TLS_CERTDIR", Value: ""}},
+		Resources:       p.resources,
+		SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+		VolumeMounts:    []corev1.VolumeMount{{Name: "docker", MountPath: "/var/lib/docker"}},
+	}}
+	spec.Volumes = []corev1.Volume{{
+		Name:         "docker",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}}
+
+	return spec, nil
+}
+
+// agentEnv returns the environment of the agent container, sorted by name.
+// The token is read from the agent's secret instead of the bootstrap server.
+func (p *provider) agentEnv(agent *woodpecker.Agent, name string) ([]corev1.EnvVar, error) {
+	withoutBootstrap := *p.config
+	withoutBootstrap.Bootstrap = nil
+
+	r := cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Region: p.namespace,
+			Arch:   cloudinit.NormalizeArch(p.nodeSelector["kubernetes.io/arch"]),
+		},
+	}
+	if p.backend == BackendDocker {
+		r.Candidate.Workflows = p.config.Workflows("", p.cpus, p.memory)
+	}
+	agent.Capacity = int32(cloudinit.MaxWorkflows(p.config, r))
+
+	environment, _, err := cloudinit.AgentEnvironment(&withoutBootstrap, agent, r)
+	if err != nil {
+		return nil, err
+	}
+	delete(environment, "WOODPECKER_AGENT_SECRET")
+
+	defaults := map[string]string{"WOODPECKER_BACKEND": p.backend}
+	if p.backend == BackendDocker {
+		defaults["DOCKER_HOST"] = dockerHost
+	} else {
+		defaults["WOODPECKER_BACKEND_K8S_NAMESPACE"] = p.namespace
+	}
+	environment = utils.MergeMaps(defaults, environment)
+
+	env := []corev1.EnvVar{{
+		Name: "WOODPECKER_AGENT_SECRET",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				Key:                  secretKey,
+			},
+		},
+	}}
+	for _, key := range slices.Sorted(maps.Keys(environment)) {
+		env = append(env, corev1.EnvVar{Name: key, Value: environment[key]})
+	}
+
+	return env, nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	name := objectName(agent.Name)
+
+	// both workloads are removed, in case the workload setting was changed
+	err := p.client.AppsV1().Deployments(p.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s: DeleteDeployment: %w", p.name, err)
+	}
+	err = p.client.CoreV1().Pods(p.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s: DeletePod: %w", p.name, err)
+	}
+	err = p.client.CoreV1().Secrets(p.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s: DeleteSecret: %w", p.name, err)
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var names []string
+	options := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", engine.LabelPool, p.config.PoolID)}
+
+	deployments, err := p.client.AppsV1().Deployments(p.namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListDeployments: %w", p.name, err)
+	}
+	for _, deployment := range deployments.Items {
+		names = append(names, agentName(deployment.ObjectMeta))
+	}
+
+	pods, err := p.client.CoreV1().Pods(p.namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListPods: %w", p.name, err)
+	}
+	for _, pod := range pods.Items {
+		names = append(names, agentName(pod.ObjectMeta))
+	}
+
+	return names, nil
+}
+
+// agentName returns the name of the agent the object was created for.
+func agentName(meta metav1.ObjectMeta) string {
+	if name, ok := meta.Annotations[annotationAgent]; ok {
+		return name
+	}
+	return meta.Name
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
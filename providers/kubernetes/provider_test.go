@@ -0,0 +1,212 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func newTestProvider(client kubernetes.Interface) *provider {
+	return &provider{
+		name:      "kubernetes",
+		namespace: "ci",
+		workload:  WorkloadPod,
+		backend:   BackendDocker,
+		dindImage: "docker:dind",
+		labels:    map[string]string{engine.LabelPool: "1", "team": "ci"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			GRPCAddress:       "grpc.example.com:443",
+			WorkflowsPerAgent: 1,
+			WorkflowCPUs:      2,
+			Environment:       map[string]string{"WOODPECKER_LOG_LEVEL": "debug"},
+		},
+		client: client,
+	}
+}
+
+func env(container corev1.Container) map[string]corev1.EnvVar {
+	vars := make(map[string]corev1.EnvVar)
+	for _, e := range container.Env {
+		vars[e.Name] = e
+	}
+	return vars
+}
+
+func getSecret(t *testing.T, client kubernetes.Interface, namespace, name string) *corev1.Secret {
+	t.Helper()
+	secret, err := client.CoreV1().Secrets(namespace).Get(t.Context(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return secret
+}
+
+func getPod(t *testing.T, client kubernetes.Interface, namespace, name string) *corev1.Pod {
+	t.Helper()
+	pod, err := client.CoreV1().Pods(namespace).Get(t.Context(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return pod
+}
+
+func getDeployment(t *testing.T, client kubernetes.Interface, namespace, name string) *appsv1.Deployment {
+	t.Helper()
+	deployment, err := client.AppsV1().Deployments(namespace).Get(t.Context(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return deployment
+}
+
+func resourceList(cpu, memory string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	if cpu != "" {
+		list[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		list[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return list
+}
+
+func TestDeployAgent(t *testing.T) {
+	t.Run("Pod", func(t *testing.T) {
+		client := fake.NewClientset()
+		p := newTestProvider(client)
+		p.nodeSelector = map[string]string{"kubernetes.io/arch": "arm64"}
+		p.tolerations = []corev1.Toleration{{Key: "ci", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}
+		assert.NoError(t, p.setResources("2", "4Gi", "4", ""))
+
+		agent := &woodpecker.Agent{Name: "pool-1-agent-AbCd", Token: "secret-token"}
+		assert.NoError(t, p.DeployAgent(t.Context(), agent))
+
+		secret := getSecret(t, client, "ci", "pool-1-agent-abcd")
+		if assert.NotNil(t, secret) {
+			assert.Equal(t, "secret-token", secret.StringData["token"])
+		}
+
+		pod := getPod(t, client, "ci", "pool-1-agent-abcd")
+		if !assert.NotNil(t, pod) {
+			return
+		}
+		assert.Equal(t, "pool-1-agent-AbCd", pod.Annotations[annotationAgent])
+		assert.Equal(t, "1", pod.Labels[engine.LabelPool])
+		assert.Equal(t, "ci", pod.Labels["team"])
+		assert.Equal(t, p.nodeSelector, pod.Spec.NodeSelector)
+		assert.Equal(t, p.tolerations, pod.Spec.Tolerations)
+		assert.False(t, *pod.Spec.AutomountServiceAccountToken)
+
+		if assert.Len(t, pod.Spec.Containers, 2) {
+			agentContainer, dind := pod.Spec.Containers[0], pod.Spec.Containers[1]
+			assert.Equal(t, "woodpeckerci/woodpecker-agent:next", agentContainer.Image)
+			vars := env(agentContainer)
+			// the token is only in the secret
+			assert.Empty(t, vars["WOODPECKER_AGENT_SECRET"].Value)
+			assert.Equal(t, &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "pool-1-agent-abcd"},
+				Key:                  "token",
+			}, vars["WOODPECKER_AGENT_SECRET"].ValueFrom.SecretKeyRef)
+			assert.Equal(t, "docker", vars["WOODPECKER_BACKEND"].Value)
+			assert.Equal(t, dockerHost, vars["DOCKER_HOST"].Value)
+			assert.Equal(t, "debug", vars["WOODPECKER_LOG_LEVEL"].Value)
+			// capacity derived from the cpu limit
+			assert.Equal(t, "2", vars["WOODPECKER_MAX_WORKFLOWS"].Value)
+			assert.Contains(t, vars["WOODPECKER_AGENT_LABELS"].Value, "autoscaler.arch=arm64")
+			assert.Empty(t, agentContainer.Resources)
+
+			assert.Equal(t, "docker:dind", dind.Image)
+			assert.True(t, *dind.SecurityContext.Privileged)
+			assert.Equal(t, resourceList("2", "4Gi"), dind.Resources.Requests)
+			assert.Equal(t, resourceList("4", ""), dind.Resources.Limits)
+		}
+		assert.Equal(t, int32(2), agent.Capacity)
+	})
+
+	t.Run("Deployment", func(t *testing.T) {
+		client := fake.NewClientset()
+		p := newTestProvider(client)
+		p.workload = WorkloadDeployment
+		p.backend = BackendKubernetes
+		p.serviceAccount = "woodpecker-agent"
+		assert.NoError(t, p.setResources("", "", "2", "8Gi"))
+
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "secret-token"}))
+
+		assert.Nil(t, getPod(t, client, "ci", "pool-1-agent-abcd"))
+		deployment := getDeployment(t, client, "ci", "pool-1-agent-abcd")
+		if !assert.NotNil(t, deployment) {
+			return
+		}
+		assert.Equal(t, int32(1), *deployment.Spec.Replicas)
+		assert.Equal(t, map[string]string{labelAgent: "pool-1-agent-abcd"}, deployment.Spec.Selector.MatchLabels)
+		assert.Equal(t, "pool-1-agent-abcd", deployment.Spec.Template.Labels[labelAgent])
+		assert.NotContains(t, deployment.Spec.Template.Labels, engine.LabelPool)
+
+		spec := deployment.Spec.Template.Spec
+		assert.Equal(t, "woodpecker-agent", spec.ServiceAccountName)
+		assert.Nil(t, spec.AutomountServiceAccountToken)
+		if assert.Len(t, spec.Containers, 1) {
+			vars := env(spec.Containers[0])
+			assert.Equal(t, "kubernetes", vars["WOODPECKER_BACKEND"].Value)
+			assert.Equal(t, "ci", vars["WOODPECKER_BACKEND_K8S_NAMESPACE"].Value)
+			assert.Equal(t, "1", vars["WOODPECKER_MAX_WORKFLOWS"].Value)
+			assert.Equal(t, resourceList("2", "8Gi"), spec.Containers[0].Resources.Limits)
+		}
+	})
+
+	t.Run("SecretRemovedOnFailure", func(t *testing.T) {
+		client := fake.NewClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pool-1-agent-abcd", Namespace: "ci"}})
+		p := newTestProvider(client)
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.True(t, apierrors.IsAlreadyExists(err))
+		assert.Nil(t, getSecret(t, client, "ci", "pool-1-agent-abcd"))
+	})
+}
+
+func TestRemoveAgent(t *testing.T) {
+	client := fake.NewClientset()
+	p := newTestProvider(client)
+	agent := &woodpecker.Agent{Name: "pool-1-agent-AbCd"}
+	assert.NoError(t, p.DeployAgent(t.Context(), agent))
+
+	assert.NoError(t, p.RemoveAgent(t.Context(), agent))
+	assert.Nil(t, getPod(t, client, "ci", "pool-1-agent-abcd"))
+	assert.Nil(t, getSecret(t, client, "ci", "pool-1-agent-abcd"))
+
+	// removing it again is no error
+	assert.NoError(t, p.RemoveAgent(t.Context(), agent))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	client := fake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-pool", Namespace: "ci", Labels: map[string]string{engine.LabelPool: "2"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-namespace", Namespace: "default", Labels: map[string]string{engine.LabelPool: "1"}}},
+	)
+	p := newTestProvider(client)
+	assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-AbCd"}))
+	p.workload = WorkloadDeployment
+	assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-EfGh"}))
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pool-1-agent-AbCd", "pool-1-agent-EfGh"}, names)
+}
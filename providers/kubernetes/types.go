@@ -0,0 +1,25 @@
+package kubernetes
+
+import "errors"
+
+var (
+	ErrIllegalLabelPrefix = errors.New("illegal label prefix")
+	ErrInvalidWorkload    = errors.New("invalid workload, must be pod or deployment")
+	ErrInvalidBackend     = errors.New("invalid backend, must be docker or kubernetes")
+	ErrInvalidQuantity    = errors.New("invalid quantity")
+	ErrInvalidToleration  = errors.New("invalid toleration, must be key[=value]:effect")
+)
+
+// Workloads the agents can run as.
+const (
+	WorkloadPod        = "pod"
+	WorkloadDeployment = "deployment"
+)
+
+// Backends the agents run workflows with.
+const (
+	// BackendDocker runs workflows in a docker-in-docker sidecar.
+	BackendDocker = "docker"
+	// BackendKubernetes runs workflows as pods of the cluster.
+	BackendKubernetes = "kubernetes"
+)
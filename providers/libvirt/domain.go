@@ -0,0 +1,151 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// metadataNamespace is the namespace of the domain metadata of the
+// autoscaler.
+const metadataNamespace = "https://woodpecker-ci.org/autoscaler"
+
+// metadata is the domain metadata element identifying agents of a pool.
+type metadata struct {
+	XMLName xml.Name `xml:"https://woodpecker-ci.org/autoscaler autoscaler"`
+	Pool    string   `xml:"https://woodpecker-ci.org/autoscaler pool"`
+}
+
+type domain struct {
+	XMLName  xml.Name       `xml:"domain"`
+	Type     string         `xml:"type,attr"`
+	Name     string         `xml:"name"`
+	Metadata domainMetadata `xml:"metadata"`
+	Memory   domainMemory   `xml:"memory"`
+	VCPU     int            `xml:"vcpu"`
+	OS       domainOS       `xml:"os"`
+	Features domainFeatures `xml:"features"`
+	CPU      domainCPU      `xml:"cpu"`
+	Devices  domainDevices  `xml:"devices"`
+}
+
+type domainMetadata struct {
+	// Inner is written verbatim, encoding/xml can not set a namespace
+	// prefix which libvirt requires for metadata elements
+	Inner string `xml:",innerxml"`
+}
+
+type domainMemory struct {
+	Unit  string `xml:"unit,attr"`
+	Value int64  `xml:",chardata"`
+}
+
+type domainOS struct {
+	Type domainOSType `xml:"type"`
+	Boot domainBoot   `xml:"boot"`
+}
+
+type domainOSType struct {
+	Value string `xml:",chardata"`
+}
+
+type domainBoot struct {
+	Dev string `xml:"dev,attr"`
+}
+
+type domainFeatures struct {
+	ACPI *struct{} `xml:"acpi"`
+	APIC *struct{} `xml:"apic"`
+}
+
+type domainCPU struct {
+	Mode string `xml:"mode,attr"`
+}
+
+type domainDevices struct {
+	Disks      []domainDisk      `xml:"disk"`
+	Interfaces []domainInterface `xml:"interface"`
+	Serial     domainConsole     `xml:"serial"`
+	Console    domainConsole     `xml:"console"`
+}
+
+type domainDisk struct {
+	Type     string           `xml:"type,attr"`
+	Device   string           `xml:"device,attr"`
+	Driver   domainDiskDriver `xml:"driver"`
+	Source   domainDiskSource `xml:"source"`
+	Target   domainDiskTarget `xml:"target"`
+	ReadOnly *struct{}        `xml:"readonly"`
+}
+
+type domainDiskDriver struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type domainDiskSource struct {
+	Pool   string `xml:"pool,attr"`
+	Volume string `xml:"volume,attr"`
+}
+
+type domainDiskTarget struct {
+	Dev string `xml:"dev,attr"`
+	Bus string `xml:"bus,attr"`
+}
+
+type domainInterface struct {
+	Type   string                `xml:"type,attr"`
+	Source domainInterfaceSource `xml:"source"`
+	Model  domainInterfaceModel  `xml:"model"`
+}
+
+type domainInterfaceSource struct {
+	Network string `xml:"network,attr,omitempty"`
+	Bridge  string `xml:"bridge,attr,omitempty"`
+}
+
+type domainInterfaceModel struct {
+	Type string `xml:"type,attr"`
+}
+
+type domainConsole struct {
+	Type string `xml:"type,attr"`
+}
+
+// metadataXML returns the metadata element of the pool.
+func metadataXML(poolID string) string {
+	var pool strings.Builder
+	_ = xml.EscapeText(&pool, []byte(poolID))
+	return `<wp:autoscaler xmlns:wp="` + metadataNamespace + `"><wp:pool>` + pool.String() + `</wp:pool></wp:autoscaler>`
+}
+
+// poolOf returns the pool of the domain metadata, empty if it has none.
+func poolOf(metadataXML string) string {
+	var m metadata
+	if xml.Unmarshal([]byte(metadataXML), &m) != nil {
+		return ""
+	}
+	return m.Pool
+}
+
+// volume is a storage volume.
+type volume struct {
+	XMLName      xml.Name       `xml:"volume"`
+	Name         string         `xml:"name"`
+	Capacity     volumeCapacity `xml:"capacity"`
+	Target       volumeTarget   `xml:"target"`
+	BackingStore *volumeTarget  `xml:"backingStore"`
+}
+
+type volumeCapacity struct {
+	Unit  string `xml:"unit,attr"`
+	Value uint64 `xml:",chardata"`
+}
+
+type volumeTarget struct {
+	Path   string       `xml:"path,omitempty"`
+	Format volumeFormat `xml:"format"`
+}
+
+type volumeFormat struct {
+	Type string `xml:"type,attr"`
+}
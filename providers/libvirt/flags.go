@@ -0,0 +1,72 @@
+package libvirt
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+const category = "libvirt"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "libvirt-uri",
+			Value:    "qemu:///system",
+			Usage:    "libvirt connection URI, e.g. qemu+ssh://user@host/system",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_URI"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "libvirt-storage-pool",
+			Value:    "default",
+			Usage:    "storage pool of the base image and the disks of the agents",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_STORAGE_POOL"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "libvirt-base-image",
+			Usage:    "qcow2 volume in the storage pool the disks of the agents are copy-on-write overlays of, a cloud image with cloud-init",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_BASE_IMAGE"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "libvirt-disk-size",
+			Usage:    "disk size of the agents in GiB, the size of the base image if 0",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_DISK_SIZE"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "libvirt-cpus",
+			Value:    2, //nolint:mnd
+			Usage:    "virtual cpus of the agents",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_CPUS"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "libvirt-memory",
+			Value:    4096, //nolint:mnd
+			Usage:    "memory of the agents in MiB",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_MEMORY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "libvirt-network",
+			Value:    "default",
+			Usage:    "virtual network the agents are attached to",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_NETWORK"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "libvirt-bridge",
+			Usage:    "host bridge the agents are attached to instead of the network",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_BRIDGE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "libvirt-domain-type",
+			Value:    "kvm",
+			Usage:    "domain type, kvm or qemu for emulation without kvm",
+			Sources:  cli.EnvVars("WOODPECKER_LIBVIRT_DOMAIN_TYPE"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,247 @@
+package libvirt
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/libvirt/virtapi"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// disk sizes are given in GiB, capacities in bytes
+const bytesPerGiB = 1 << 30
+
+type provider struct {
+	name        string
+	storagePool string
+	baseImage   string
+	diskSize    uint64
+	cpus        int
+	memory      int64
+	network     string
+	bridge      string
+	domainType  string
+	config      *config.Config
+	client      virtapi.Client
+}
+
+func New(_ context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:        "libvirt",
+		storagePool: c.String("libvirt-storage-pool"),
+		baseImage:   c.String("libvirt-base-image"),
+		diskSize:    uint64(c.Int("libvirt-disk-size")) * bytesPerGiB,
+		cpus:        c.Int("libvirt-cpus"),
+		memory:      int64(c.Int("libvirt-memory")),
+		network:     c.String("libvirt-network"),
+		bridge:      c.String("libvirt-bridge"),
+		domainType:  c.String("libvirt-domain-type"),
+		config:      config,
+	}
+
+	if p.baseImage == "" {
+		return nil, fmt.Errorf("%s: %w: libvirt-base-image", p.name, ErrMissingSetting)
+	}
+
+	client, err := virtapi.NewClient(c.String("libvirt-uri"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.client = client
+
+	return p, nil
+}
+
+func diskName(agentName string) string {
+	return agentName + ".qcow2"
+}
+
+func seedName(agentName string) string {
+	return agentName + "-seed.iso"
+}
+
+func (p *provider) DeployAgent(_ context.Context, agent *woodpecker.Agent) error {
+	arch, err := p.client.Arch()
+	if err != nil {
+		return fmt.Errorf("%s: Arch: %w", p.name, err)
+	}
+
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Arch:      cloudinit.NormalizeArch(arch),
+			Workflows: p.config.Workflows("", p.cpus, p.memory),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+	}
+
+	log.Info().Msgf("create agent: storage pool = %s base image = %s", p.storagePool, p.baseImage)
+
+	if err := p.createDomain(agent, userData); err != nil {
+		if cleanupErr := p.removeDomain(agent.Name); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// createDomain creates the disk and seed of the agent and defines and
+// starts its domain.
+func (p *provider) createDomain(agent *woodpecker.Agent, userData string) error {
+	base, err := p.client.Volume(p.storagePool, p.baseImage)
+	if err != nil {
+		return fmt.Errorf("Volume: %w", err)
+	}
+
+	disk, err := xml.Marshal(&volume{
+		Name:         diskName(agent.Name),
+		Capacity:     volumeCapacity{Unit: "bytes", Value: max(p.diskSize, base.Capacity)},
+		Target:       volumeTarget{Format: volumeFormat{Type: "qcow2"}},
+		BackingStore: &volumeTarget{Path: base.Path, Format: volumeFormat{Type: "qcow2"}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.client.CreateVolume(p.storagePool, string(disk)); err != nil {
+		return fmt.Errorf("CreateVolume: %w", err)
+	}
+
+	metaData := fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", agent.Name, agent.Name)
+	seed, err := seedISO(userData, metaData)
+	if err != nil {
+		return fmt.Errorf("seedISO: %w", err)
+	}
+	if err := p.client.UploadVolume(p.storagePool, seedName(agent.Name), seed); err != nil {
+		return fmt.Errorf("UploadVolume: %w", err)
+	}
+
+	dom, err := xml.Marshal(p.domain(agent.Name))
+	if err != nil {
+		return err
+	}
+	if err := p.client.DefineDomain(string(dom)); err != nil {
+		return fmt.Errorf("DefineDomain: %w", err)
+	}
+
+	if err := p.client.StartDomain(agent.Name); err != nil {
+		return fmt.Errorf("StartDomain: %w", err)
+	}
+
+	return nil
+}
+
+// domain returns the domain of the agent booting from its disk with its seed
+// attached.
+func (p *provider) domain(agentName string) *domain {
+	iface := domainInterface{
+		Type:   "network",
+		Source: domainInterfaceSource{Network: p.network},
+		Model:  domainInterfaceModel{Type: "virtio"},
+	}
+	if p.bridge != "" {
+		iface.Type = "bridge"
+		iface.Source = domainInterfaceSource{Bridge: p.bridge}
+	}
+
+	return &domain{
+		Type:     p.domainType,
+		Name:     agentName,
+		Metadata: domainMetadata{Inner: metadataXML(p.config.PoolID)},
+		Memory:   domainMemory{Unit: "MiB", Value: p.memory},
+		VCPU:     p.cpus,
+		OS: domainOS{
+			Type: domainOSType{Value: "hvm"},
+			Boot: domainBoot{Dev: "hd"},
+		},
+		Features: domainFeatures{ACPI: &struct{}{}, APIC: &struct{}{}},
+		CPU:      domainCPU{Mode: "host-passthrough"},
+		Devices: domainDevices{
+			Disks: []domainDisk{
+				{
+					Type:   "volume",
+					Device: "disk",
+					Driver: domainDiskDriver{Name: "qemu", Type: "qcow2"},
+					Source: domainDiskSource{Pool: p.storagePool, Volume: diskName(agentName)},
+					Target: domainDiskTarget{Dev: "vda", Bus: "virtio"},
+				},
+				{
+					Type:     "volume",
+					Device:   "cdrom",
+					Driver:   domainDiskDriver{Name: "qemu", Type: "raw"},
+					Source:   domainDiskSource{Pool: p.storagePool, Volume: seedName(agentName)},
+					Target:   domainDiskTarget{Dev: "sda", Bus: "sata"},
+					ReadOnly: &struct{}{},
+				},
+			},
+			Interfaces: []domainInterface{iface},
+			Serial:     domainConsole{Type: "pty"},
+			Console:    domainConsole{Type: "pty"},
+		},
+	}
+}
+
+// removeDomain stops and undefines the domain of the agent and deletes its
+// disk and seed, if they exist.
+func (p *provider) removeDomain(agentName string) error {
+	if err := p.client.RemoveDomain(agentName); err != nil {
+		return fmt.Errorf("RemoveDomain: %w", err)
+	}
+
+	var errs []error
+	for _, name := range []string{diskName(agentName), seedName(agentName)} {
+		if err := p.client.DeleteVolume(p.storagePool, name); err != nil {
+			errs = append(errs, fmt.Errorf("DeleteVolume: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *provider) RemoveAgent(_ context.Context, agent *woodpecker.Agent) error {
+	domains, err := p.client.ListDomains(metadataNamespace)
+	if err != nil {
+		return fmt.Errorf("%s: ListDomains: %w", p.name, err)
+	}
+
+	for _, d := range domains {
+		if d.Name != agent.Name || poolOf(d.Metadata) != p.config.PoolID {
+			continue
+		}
+		if err := p.removeDomain(agent.Name); err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(_ context.Context) ([]string, error) {
+	domains, err := p.client.ListDomains(metadataNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListDomains: %w", p.name, err)
+	}
+
+	var names []string
+	for _, d := range domains {
+		if poolOf(d.Metadata) == p.config.PoolID {
+			names = append(names, d.Name)
+		}
+	}
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
@@ -0,0 +1,130 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/libvirt/virtapi"
+	"go.woodpecker-ci.org/autoscaler/providers/libvirt/virtapi/mocks"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func newTestProvider(client virtapi.Client) *provider {
+	return &provider{
+		name:        "libvirt",
+		storagePool: "default",
+		baseImage:   "noble-server-cloudimg-amd64.img",
+		diskSize:    20 * bytesPerGiB,
+		cpus:        4,
+		memory:      8192,
+		network:     "default",
+		domainType:  "kvm",
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: client,
+	}
+}
+
+func TestDeployAgent(t *testing.T) {
+	t.Run("Created", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Arch").Return("x86_64", nil).Once()
+		client.On("Volume", "default", "noble-server-cloudimg-amd64.img").Return(&virtapi.Volume{Path: "/var/lib/libvirt/images/noble-server-cloudimg-amd64.img", Capacity: 3 * bytesPerGiB}, nil).Once()
+		client.On("CreateVolume", "default", mock.MatchedBy(func(x string) bool {
+			var v volume
+			return xml.Unmarshal([]byte(x), &v) == nil &&
+				v.Name == "pool-1-agent-abcd.qcow2" &&
+				v.Capacity.Value == 20*bytesPerGiB &&
+				v.Target.Format.Type == "qcow2" &&
+				v.BackingStore.Path == "/var/lib/libvirt/images/noble-server-cloudimg-amd64.img"
+		})).Return(nil).Once()
+		client.On("UploadVolume", "default", "pool-1-agent-abcd-seed.iso", mock.MatchedBy(func(iso []byte) bool {
+			return strings.Contains(string(iso), "autoscaler.arch=amd64") &&
+				strings.Contains(string(iso), "instance-id: pool-1-agent-abcd")
+		})).Return(nil).Once()
+		client.On("DefineDomain", mock.MatchedBy(func(x string) bool {
+			var d domain
+			return xml.Unmarshal([]byte(x), &d) == nil &&
+				d.Name == "pool-1-agent-abcd" &&
+				d.Type == "kvm" &&
+				d.VCPU == 4 &&
+				d.Memory.Value == 8192 &&
+				d.Devices.Disks[0].Source.Volume == "pool-1-agent-abcd.qcow2" &&
+				d.Devices.Disks[1].Source.Volume == "pool-1-agent-abcd-seed.iso" &&
+				d.Devices.Interfaces[0].Source.Network == "default" &&
+				strings.Contains(x, `<wp:autoscaler xmlns:wp="https://woodpecker-ci.org/autoscaler"><wp:pool>1</wp:pool></wp:autoscaler>`)
+		})).Return(nil).Once()
+		client.On("StartDomain", "pool-1-agent-abcd").Return(nil).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+	})
+
+	t.Run("RemovedIfNotStarted", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Arch").Return("aarch64", nil).Once()
+		client.On("Volume", "default", mock.Anything).Return(&virtapi.Volume{Path: "/base.img", Capacity: 30 * bytesPerGiB}, nil).Once()
+		client.On("CreateVolume", "default", mock.MatchedBy(func(x string) bool {
+			return strings.Contains(x, `<capacity unit="bytes">32212254720</capacity>`)
+		})).Return(nil).Once()
+		client.On("UploadVolume", "default", "pool-1-agent-abcd-seed.iso", mock.Anything).Return(nil).Once()
+		client.On("DefineDomain", mock.Anything).Return(nil).Once()
+		client.On("StartDomain", "pool-1-agent-abcd").Return(errors.New("Cannot access storage file")).Once()
+		client.On("RemoveDomain", "pool-1-agent-abcd").Return(nil).Once()
+		client.On("DeleteVolume", "default", "pool-1-agent-abcd.qcow2").Return(nil).Once()
+		client.On("DeleteVolume", "default", "pool-1-agent-abcd-seed.iso").Return(nil).Once()
+
+		p := newTestProvider(client)
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "libvirt: StartDomain: Cannot access storage file")
+	})
+}
+
+func TestBridge(t *testing.T) {
+	p := newTestProvider(nil)
+	p.bridge = "br0"
+	iface := p.domain("pool-1-agent-abcd").Devices.Interfaces[0]
+	assert.Equal(t, "bridge", iface.Type)
+	assert.Equal(t, domainInterfaceSource{Bridge: "br0"}, iface.Source)
+}
+
+func TestRemoveAgent(t *testing.T) {
+	client := mocks.NewMockClient(t)
+	client.On("ListDomains", metadataNamespace).Return([]*virtapi.Domain{
+		{Name: "pool-1-agent-abcd", Metadata: metadataXML("1")},
+		// same name in another pool
+		{Name: "pool-1-agent-efgh", Metadata: metadataXML("2")},
+	}, nil)
+	client.On("RemoveDomain", "pool-1-agent-abcd").Return(nil).Once()
+	client.On("DeleteVolume", "default", "pool-1-agent-abcd.qcow2").Return(nil).Once()
+	client.On("DeleteVolume", "default", "pool-1-agent-abcd-seed.iso").Return(nil).Once()
+
+	p := newTestProvider(client)
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-efgh"}))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	client := mocks.NewMockClient(t)
+	client.On("ListDomains", metadataNamespace).Return([]*virtapi.Domain{
+		// as returned by libvirt
+		{Name: "pool-1-agent-a", Metadata: `<wp:autoscaler xmlns:wp="https://woodpecker-ci.org/autoscaler">
+  <wp:pool>1</wp:pool>
+</wp:autoscaler>`},
+		{Name: "pool-2-agent-b", Metadata: metadataXML("2")},
+		{Name: "windows"},
+	}, nil)
+
+	names, err := newTestProvider(client).ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-a"}, names)
+}
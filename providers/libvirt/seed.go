@@ -0,0 +1,33 @@
+package libvirt
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kdomanski/iso9660"
+)
+
+// seedISO returns a NoCloud seed, an ISO 9660 image labeled cidata holding
+// the user-data and meta-data files.
+func seedISO(userData, metaData string) ([]byte, error) {
+	writer, err := iso9660.NewWriter()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = writer.Cleanup() }()
+
+	for _, file := range []struct{ name, data string }{
+		{"meta-data", metaData},
+		{"user-data", userData},
+	} {
+		if err := writer.AddFile(strings.NewReader(file.data), file.name); err != nil {
+			return nil, err
+		}
+	}
+
+	var image bytes.Buffer
+	if err := writer.WriteTo(&image, "cidata"); err != nil {
+		return nil, err
+	}
+	return image.Bytes(), nil
+}
@@ -0,0 +1,40 @@
+package libvirt
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kdomanski/iso9660"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedISO(t *testing.T) {
+	userData := "#cloud-config\n" + strings.Repeat("# padding\n", 300)
+	data, err := seedISO(userData, "instance-id: agent\n")
+	require.NoError(t, err)
+
+	image, err := iso9660.OpenImage(bytes.NewReader(data))
+	require.NoError(t, err)
+	label, err := image.Label()
+	require.NoError(t, err)
+	assert.Equal(t, "cidata", label)
+
+	root, err := image.RootDir()
+	require.NoError(t, err)
+	children, err := root.GetChildren()
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, child := range children {
+		content, err := io.ReadAll(child.Reader())
+		require.NoError(t, err)
+		files[child.Name()] = string(content)
+	}
+
+	assert.Equal(t, map[string]string{
+		"meta-data": "instance-id: agent\n",
+		"user-data": userData,
+	}, files)
+}
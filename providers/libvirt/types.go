@@ -0,0 +1,7 @@
+package libvirt
+
+import (
+	"errors"
+)
+
+var ErrMissingSetting = errors.New("missing setting")
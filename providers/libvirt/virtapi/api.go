@@ -0,0 +1,34 @@
+package virtapi
+
+// Client is the subset of the libvirt API the libvirt provider uses. Domains
+// and volumes are given as libvirt XML.
+type Client interface {
+	// Arch returns the CPU architecture of the host, e.g. x86_64.
+	Arch() (string, error)
+	// Volume returns the volume of the storage pool.
+	Volume(pool, name string) (*Volume, error)
+	CreateVolume(pool, xml string) error
+	// UploadVolume creates a raw volume holding the data.
+	UploadVolume(pool, name string, data []byte) error
+	// DeleteVolume deletes the volume, if it exists.
+	DeleteVolume(pool, name string) error
+	DefineDomain(xml string) error
+	StartDomain(name string) error
+	// RemoveDomain stops the domain, if running, and undefines it, if it
+	// exists.
+	RemoveDomain(name string) error
+	// ListDomains lists all domains with their metadata element of the
+	// namespace, empty if they have none.
+	ListDomains(namespace string) ([]*Domain, error)
+}
+
+type Volume struct {
+	Path string
+	// Capacity in bytes.
+	Capacity uint64
+}
+
+type Domain struct {
+	Name     string
+	Metadata string
+}
@@ -0,0 +1,211 @@
+package virtapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+type client struct {
+	uri *url.URL
+
+	mu   sync.Mutex
+	virt *libvirt.Libvirt
+}
+
+// NewClient creates a client for the libvirt URI, e.g. qemu:///system or
+// qemu+ssh://user@host/system. It connects on first use and reconnects if
+// the connection was lost.
+func NewClient(uri string) (Client, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &client{uri: u}, nil
+}
+
+func (c *client) conn() (*libvirt.Libvirt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.virt != nil && c.virt.IsConnected() {
+		return c.virt, nil
+	}
+
+	virt, err := libvirt.ConnectToURI(c.uri)
+	if err != nil {
+		return nil, err
+	}
+	c.virt = virt
+	return virt, nil
+}
+
+// isError reports whether err is a libvirt error with the code.
+func isError(err error, code libvirt.ErrorNumber) bool {
+	var virtErr libvirt.Error
+	return errors.As(err, &virtErr) && virtErr.Code == uint32(code)
+}
+
+func (c *client) Arch() (string, error) {
+	virt, err := c.conn()
+	if err != nil {
+		return "", err
+	}
+
+	model, _, _, _, _, _, _, _, err := virt.NodeGetInfo()
+	if err != nil {
+		return "", err
+	}
+
+	var arch strings.Builder
+	for _, b := range model {
+		if b == 0 {
+			break
+		}
+		arch.WriteByte(byte(b))
+	}
+	return arch.String(), nil
+}
+
+func (c *client) volume(pool, name string) (*libvirt.Libvirt, libvirt.StorageVol, error) {
+	virt, err := c.conn()
+	if err != nil {
+		return nil, libvirt.StorageVol{}, err
+	}
+	p, err := virt.StoragePoolLookupByName(pool)
+	if err != nil {
+		return nil, libvirt.StorageVol{}, err
+	}
+	vol, err := virt.StorageVolLookupByName(p, name)
+	return virt, vol, err
+}
+
+func (c *client) Volume(pool, name string) (*Volume, error) {
+	virt, vol, err := c.volume(pool, name)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := virt.StorageVolGetPath(vol)
+	if err != nil {
+		return nil, err
+	}
+	_, capacity, _, err := virt.StorageVolGetInfo(vol)
+	if err != nil {
+		return nil, err
+	}
+	return &Volume{Path: path, Capacity: capacity}, nil
+}
+
+func (c *client) CreateVolume(pool, xml string) error {
+	virt, err := c.conn()
+	if err != nil {
+		return err
+	}
+	p, err := virt.StoragePoolLookupByName(pool)
+	if err != nil {
+		return err
+	}
+	_, err = virt.StorageVolCreateXML(p, xml, 0)
+	return err
+}
+
+func (c *client) UploadVolume(pool, name string, data []byte) error {
+	xml := fmt.Sprintf(`<volume><name>%s</name><capacity unit="bytes">%d</capacity><target><format type="raw"/></target></volume>`, name, len(data))
+	if err := c.CreateVolume(pool, xml); err != nil {
+		return err
+	}
+
+	virt, vol, err := c.volume(pool, name)
+	if err != nil {
+		return err
+	}
+	return virt.StorageVolUpload(vol, bytes.NewReader(data), 0, uint64(len(data)), 0)
+}
+
+func (c *client) DeleteVolume(pool, name string) error {
+	virt, vol, err := c.volume(pool, name)
+	if isError(err, libvirt.ErrNoStorageVol) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return virt.StorageVolDelete(vol, libvirt.StorageVolDeleteNormal)
+}
+
+func (c *client) DefineDomain(xml string) error {
+	virt, err := c.conn()
+	if err != nil {
+		return err
+	}
+	_, err = virt.DomainDefineXML(xml)
+	return err
+}
+
+func (c *client) StartDomain(name string) error {
+	virt, err := c.conn()
+	if err != nil {
+		return err
+	}
+	dom, err := virt.DomainLookupByName(name)
+	if err != nil {
+		return err
+	}
+	return virt.DomainCreate(dom)
+}
+
+func (c *client) RemoveDomain(name string) error {
+	virt, err := c.conn()
+	if err != nil {
+		return err
+	}
+	dom, err := virt.DomainLookupByName(name)
+	if libvirt.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	state, _, err := virt.DomainGetState(dom, 0)
+	if err != nil {
+		return err
+	}
+	if libvirt.DomainState(state) != libvirt.DomainShutoff {
+		if err := virt.DomainDestroy(dom); err != nil {
+			return err
+		}
+	}
+	return virt.DomainUndefineFlags(dom, libvirt.DomainUndefineNvram)
+}
+
+func (c *client) ListDomains(namespace string) ([]*Domain, error) {
+	virt, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	doms, _, err := virt.ConnectListAllDomains(1, libvirt.ConnectListDomainsActive|libvirt.ConnectListDomainsInactive)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]*Domain, 0, len(doms))
+	for _, dom := range doms {
+		metadata, err := virt.DomainGetMetadata(dom, int32(libvirt.DomainMetadataElement), libvirt.OptString{namespace}, libvirt.DomainAffectCurrent)
+		switch {
+		case isError(err, libvirt.ErrNoDomainMetadata):
+			metadata = ""
+		case libvirt.IsNotFound(err):
+			// undefined in the meantime
+			continue
+		case err != nil:
+			return nil, err
+		}
+		domains = append(domains, &Domain{Name: dom.Name, Metadata: metadata})
+	}
+	return domains, nil
+}
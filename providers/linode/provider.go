@@ -14,6 +14,7 @@ import (
 
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/utils"
@@ -30,6 +31,9 @@ var (
 	ErrReservedTagPrefix = errors.New("reserved tag prefix")
 )
 
+// UserDataLimit is the maximum size of instance user data in bytes.
+const UserDataLimit = 16 << 10
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://techdocs.akamai.com/cloud-computing/docs/metadata-service-api#api-endpoints
 var blackholeMetadataAPI = []string{
@@ -106,11 +110,17 @@ func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Prov
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: p.instanceType.ID,
+			Region:       p.region.ID,
+			Workflows:    p.config.Workflows(p.instanceType.ID, p.instanceType.VCPUs, int64(p.instanceType.Memory)),
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
 	}
 	userDataString := b64.StdEncoding.EncodeToString([]byte(userData))
 
@@ -0,0 +1,30 @@
+package dockerapi
+
+import (
+	"context"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+)
+
+// Client is the subset of the Docker Engine API the local provider uses, so
+// it can be mocked in tests. Podman serves the same API.
+type Client interface {
+	Info(ctx context.Context) (system.Info, error)
+	ImagePull(ctx context.Context, image string) error
+	ContainerCreate(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig) (string, error)
+	ContainerStart(ctx context.Context, id string) error
+	// ContainerRemove stops and removes the container with its anonymous
+	// volumes.
+	ContainerRemove(ctx context.Context, id string) error
+	// ContainerList lists all containers, also stopped ones, having the
+	// labels, given as key or key=value.
+	ContainerList(ctx context.Context, labels []string) ([]container.Summary, error)
+}
+
+// IsNotFound reports whether err is an error response of the API for a
+// missing object, e.g. an image or a container.
+func IsNotFound(err error) bool {
+	return cerrdefs.IsNotFound(err)
+}
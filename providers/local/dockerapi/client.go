@@ -0,0 +1,115 @@
+package dockerapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/system"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+// apiVersion is the API version requested, supported by Docker since 20.10
+// and by the compat API of Podman.
+const apiVersion = "1.41"
+
+var ErrUnsupportedHost = errors.New("unsupported docker host, must be unix://, tcp:// or ssh://")
+
+// TLS are the files of the TLS settings of tcp hosts, the unset ones are
+// not used.
+type TLS struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+type client struct {
+	api *dockerclient.Client
+}
+
+// NewClient creates a client for the docker host, given like DOCKER_HOST as
+// unix:///path, tcp://host:port or ssh://[user@]host[:port]. TCP connections
+// use TLS if tls is not nil. SSH connections run `docker system dial-stdio`
+// on the host with the ssh client, like the docker CLI does.
+func NewClient(host string, tls *TLS) (Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedHost, host)
+	}
+
+	opts := []dockerclient.Opt{
+		dockerclient.WithVersion(apiVersion),
+		dockerclient.WithUserAgent("woodpecker-autoscaler/" + version.String()),
+	}
+	switch u.Scheme {
+	case "unix":
+		opts = append(opts, dockerclient.WithHost(host))
+	case "tcp":
+		opts = append(opts, dockerclient.WithHost(host))
+		if tls != nil {
+			opts = append(opts, dockerclient.WithTLSClientConfig(tls.CAFile, tls.CertFile, tls.KeyFile))
+		}
+	case "ssh":
+		helper, err := connhelper.GetConnectionHelper(host)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, dockerclient.WithHost(helper.Host), dockerclient.WithDialContext(helper.Dialer))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedHost, host)
+	}
+
+	api, err := dockerclient.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &client{api: api}, nil
+}
+
+func (c *client) Info(ctx context.Context) (system.Info, error) {
+	return c.api.Info(ctx)
+}
+
+func (c *client) ImagePull(ctx context.Context, ref string) error {
+	progress, err := c.api.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+
+	// the progress is streamed, a failure is reported as its last message
+	return jsonmessage.DisplayJSONMessagesStream(progress, io.Discard, 0, false, nil)
+}
+
+func (c *client) ContainerCreate(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig) (string, error) {
+	created, err := c.api.ContainerCreate(ctx, config, hostConfig, nil, nil, name)
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (c *client) ContainerStart(ctx context.Context, id string) error {
+	return c.api.ContainerStart(ctx, id, container.StartOptions{})
+}
+
+func (c *client) ContainerRemove(ctx context.Context, id string) error {
+	return c.api.ContainerRemove(ctx, id, container.RemoveOptions{Force: true, RemoveVolumes: true})
+}
+
+func (c *client) ContainerList(ctx context.Context, labels []string) ([]container.Summary, error) {
+	args := filters.NewArgs()
+	for _, label := range labels {
+		args.Add("label", label)
+	}
+	return c.api.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+}
@@ -0,0 +1,92 @@
+package dockerapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/providers/local/dockerapi"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) dockerapi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.UserAgent(), "woodpecker-autoscaler/"))
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := dockerapi.NewClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), nil)
+	assert.NoError(t, err)
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := dockerapi.NewClient("npipe:////./pipe/docker_engine", nil)
+	assert.ErrorIs(t, err, dockerapi.ErrUnsupportedHost)
+
+	for _, host := range []string{"unix:///var/run/docker.sock", "tcp://docker:2376", "ssh://ci@docker:2222"} {
+		_, err := dockerapi.NewClient(host, nil)
+		assert.NoError(t, err, host)
+	}
+}
+
+func TestContainerCreate(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1.41/containers/create", r.URL.Path)
+		assert.Equal(t, "pool-1-agent-abcd", r.URL.Query().Get("name"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id": "4711", "Warnings": []}`))
+	})
+
+	id, err := client.ContainerCreate(t.Context(), "pool-1-agent-abcd", &container.Config{Image: "agent"}, &container.HostConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, "4711", id)
+}
+
+func TestContainerList(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1.41/containers/json", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("all"))
+		assert.JSONEq(t, `{"label": {"wp.autoscaler/pool=1": true}}`, r.URL.Query().Get("filters"))
+		_, _ = w.Write([]byte(`[{"Id": "4711", "Labels": {"wp.autoscaler/pool": "1"}}]`))
+	})
+
+	containers, err := client.ContainerList(t.Context(), []string{"wp.autoscaler/pool=1"})
+	assert.NoError(t, err)
+	if assert.Len(t, containers, 1) {
+		assert.Equal(t, "4711", containers[0].ID)
+	}
+}
+
+func TestImagePull(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "docker.io/woodpeckerci/woodpecker-agent", r.URL.Query().Get("fromImage"))
+		assert.Equal(t, "next", r.URL.Query().Get("tag"))
+		_, _ = w.Write([]byte(`{"status": "Pulling from woodpeckerci/woodpecker-agent"}
+{"errorDetail": {"message": "toomanyrequests"}, "error": "toomanyrequests"}
+`))
+	})
+
+	err := client.ImagePull(t.Context(), "woodpeckerci/woodpecker-agent:next")
+	assert.ErrorContains(t, err, "toomanyrequests")
+}
+
+func TestContainerRemoveNotFound(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "1", r.URL.Query().Get("force"))
+		assert.Equal(t, "1", r.URL.Query().Get("v"))
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "No such container: 4711"}`))
+	})
+
+	err := client.ContainerRemove(t.Context(), "4711")
+	assert.True(t, dockerapi.IsNotFound(err))
+	assert.ErrorContains(t, err, "No such container")
+}
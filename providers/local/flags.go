@@ -0,0 +1,62 @@
+package local
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Local Docker/Podman hosts"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "local-hosts",
+			Value:    []string{"unix:///var/run/docker.sock"},
+			Usage:    "docker or podman hosts the agent containers are started on, as unix:///path, tcp://host:port or ssh://[user@]host[:port]",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_HOSTS"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "local-tls-ca-cert",
+			Usage:    "path to the ca certificate tcp hosts are verified with; tls is used if any of the tls settings is set",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_TLS_CA_CERT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "local-tls-cert",
+			Usage:    "path to the client certificate for tcp hosts",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_TLS_CERT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "local-tls-key",
+			Usage:    "path to the client key for tcp hosts",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_TLS_KEY"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "local-max-workflows",
+			Usage:    "workflows each host runs at most, 0 derives it from the cpus and memory of the host if workflow-cpus or workflow-memory is set and is unlimited otherwise",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_MAX_WORKFLOWS"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "local-socket",
+			Value:    "/var/run/docker.sock",
+			Usage:    "path of the docker or podman socket on the hosts, mounted into the agent containers to run workflows",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_SOCKET"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "local-network",
+			Usage:    "network the agent containers are attached to, defaults to the bridge network",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_NETWORK"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "local-labels",
+			Usage:    "labels of the agent containers as list of key=value pairs",
+			Sources:  cli.EnvVars("WOODPECKER_LOCAL_LABELS"),
+			Category: category,
+		},
+	}
+}
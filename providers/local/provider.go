@@ -0,0 +1,290 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/local/dockerapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+var (
+	// labelAgent is the name of the agent running in the container.
+	labelAgent = engine.LabelPrefix + "agent"
+	// labelWorkflows is how many workflows the agent runs, counted against
+	// the capacity of the host.
+	labelWorkflows = engine.LabelPrefix + "workflows"
+)
+
+// memory is reported in bytes
+const bytesPerMiB = 1 << 20
+
+type provider struct {
+	name         string
+	hosts        []*host
+	maxWorkflows int
+	socket       string
+	network      string
+	labels       map[string]string
+	config       *config.Config
+}
+
+func New(_ context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:         "local",
+		maxWorkflows: c.Int("local-max-workflows"),
+		socket:       c.String("local-socket"),
+		network:      c.String("local-network"),
+		config:       config,
+	}
+
+	addresses := c.StringSlice("local-hosts")
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("%s: %w", p.name, ErrNoHosts)
+	}
+
+	tls := p.tls(c.String("local-tls-ca-cert"), c.String("local-tls-cert"), c.String("local-tls-key"))
+
+	for _, address := range addresses {
+		client, err := dockerapi.NewClient(address, tls)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		p.hosts = append(p.hosts, &host{address: address, client: client})
+	}
+
+	userLabels := c.StringSlice("local-labels")
+	if err := utils.CheckReservedTags(userLabels, engine.LabelPrefix, ErrIllegalLabelPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	labels, err := utils.SliceToMap(userLabels, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.labels = utils.MergeMaps(labels, map[string]string{
+		engine.LabelPool:  p.config.PoolID,
+		engine.LabelImage: p.config.Image,
+	})
+
+	return p, nil
+}
+
+// tls returns the tls settings of tcp hosts, nil if no tls setting is set.
+func (p *provider) tls(caFile, certFile, keyFile string) *dockerapi.TLS {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil
+	}
+	return &dockerapi.TLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	workflows := p.config.WorkflowsPerAgent
+
+	h, info, err := p.selectHost(ctx, workflows)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	containerConfig, hostConfig, err := p.containerConfig(agent, info, workflows)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	log.Info().Msgf("create agent: host = %s", h.address)
+
+	id, err := h.client.ContainerCreate(ctx, agent.Name, containerConfig, hostConfig)
+	if dockerapi.IsNotFound(err) {
+		// the image is pulled once per host
+		if err := h.client.ImagePull(ctx, p.config.Image); err != nil {
+			return fmt.Errorf("%s: ImagePull: %w", p.name, err)
+		}
+		id, err = h.client.ContainerCreate(ctx, agent.Name, containerConfig, hostConfig)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: ContainerCreate: %w", p.name, err)
+	}
+
+	if err := h.client.ContainerStart(ctx, id); err != nil {
+		if removeErr := h.client.ContainerRemove(ctx, id); removeErr != nil {
+			err = errors.Join(err, fmt.Errorf("ContainerRemove: %w", removeErr))
+		}
+		return fmt.Errorf("%s: ContainerStart: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// selectHost returns the reachable host with the most free capacity that
+// fits the workflows of an agent, and its info.
+func (p *provider) selectHost(ctx context.Context, workflows int) (*host, *system.Info, error) {
+	var selected *host
+	var selectedInfo *system.Info
+	mostFree := 0
+
+	for _, h := range p.hosts {
+		info, free, err := p.freeCapacity(ctx, h)
+		if err != nil {
+			log.Warn().Err(err).Msgf("skipping unreachable docker host %s", h.address)
+			continue
+		}
+		if free >= workflows && free > mostFree {
+			selected, selectedInfo, mostFree = h, info, free
+		}
+	}
+
+	if selected == nil {
+		return nil, nil, ErrNoCapacity
+	}
+	return selected, selectedInfo, nil
+}
+
+// freeCapacity returns how many more workflows the host can run besides the
+// agents of all pools on it.
+func (p *provider) freeCapacity(ctx context.Context, h *host) (*system.Info, int, error) {
+	info, err := h.client.Info(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Info: %w", err)
+	}
+
+	containers, err := h.client.ContainerList(ctx, []string{engine.LabelPool})
+	if err != nil {
+		return nil, 0, fmt.Errorf("ContainerList: %w", err)
+	}
+
+	capacity := p.capacity(&info)
+	if capacity == math.MaxInt {
+		// unlimited hosts are filled evenly
+		return &info, capacity - len(containers), nil
+	}
+
+	used := 0
+	for _, c := range containers {
+		workflows, err := strconv.Atoi(c.Labels[labelWorkflows])
+		if err != nil {
+			workflows = p.config.WorkflowsPerAgent
+		}
+		used += workflows
+	}
+
+	return &info, capacity - used, nil
+}
+
+// capacity returns how many workflows the host runs at most.
+func (p *provider) capacity(info *system.Info) int {
+	if p.maxWorkflows > 0 {
+		return p.maxWorkflows
+	}
+	if p.config.WorkflowCPUs > 0 || p.config.WorkflowMemory > 0 {
+		return p.config.Workflows("", info.NCPU, info.MemTotal/bytesPerMiB)
+	}
+	return math.MaxInt
+}
+
+// containerConfig returns the container running the agent on the host with
+// the socket of the host mounted to run workflows.
+func (p *provider) containerConfig(agent *woodpecker.Agent, info *system.Info, workflows int) (*container.Config, *container.HostConfig, error) {
+	// the token is passed directly, only those with access to the host can
+	// read it
+	withoutBootstrap := *p.config
+	withoutBootstrap.Bootstrap = nil
+
+	r := cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Region:    info.Name,
+			Arch:      cloudinit.NormalizeArch(info.Architecture),
+			Workflows: workflows,
+		},
+	}
+	agent.Capacity = int32(cloudinit.MaxWorkflows(p.config, r))
+
+	environment, _, err := cloudinit.AgentEnvironment(&withoutBootstrap, agent, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	environment = utils.MergeMaps(map[string]string{"WOODPECKER_BACKEND": "docker"}, environment)
+
+	env := make([]string, 0, len(environment))
+	for _, key := range slices.Sorted(maps.Keys(environment)) {
+		env = append(env, key+"="+environment[key])
+	}
+
+	config := &container.Config{
+		Image: p.config.Image,
+		Env:   env,
+		Labels: utils.MergeMaps(p.labels, map[string]string{
+			labelAgent:     agent.Name,
+			labelWorkflows: strconv.Itoa(workflows),
+		}),
+	}
+	hostConfig := &container.HostConfig{
+		Binds:         []string{p.socket + ":/var/run/docker.sock"},
+		NetworkMode:   container.NetworkMode(p.network),
+		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyUnlessStopped},
+	}
+	return config, hostConfig, nil
+}
+
+// poolFilter returns the label filter of the containers of the pool.
+func (p *provider) poolFilter() string {
+	return fmt.Sprintf("%s=%s", engine.LabelPool, p.config.PoolID)
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	// only containers created for the agent are removed, never one that
+	// happens to have its name
+	filters := []string{p.poolFilter(), fmt.Sprintf("%s=%s", labelAgent, agent.Name)}
+
+	for _, h := range p.hosts {
+		containers, err := h.client.ContainerList(ctx, filters)
+		if err != nil {
+			return fmt.Errorf("%s: %s: ContainerList: %w", p.name, h.address, err)
+		}
+		for _, c := range containers {
+			if err := h.client.ContainerRemove(ctx, c.ID); err != nil && !dockerapi.IsNotFound(err) {
+				return fmt.Errorf("%s: %s: ContainerRemove: %w", p.name, h.address, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var names []string
+
+	for _, h := range p.hosts {
+		// an unreachable host fails the listing, its agents must not be
+		// taken for gone
+		containers, err := h.client.ContainerList(ctx, []string{p.poolFilter()})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: ContainerList: %w", p.name, h.address, err)
+		}
+		for _, c := range containers {
+			if name, ok := c.Labels[labelAgent]; ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
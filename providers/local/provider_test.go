@@ -0,0 +1,166 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/providers/local/dockerapi/mocks"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func newTestProvider(hosts ...*host) *provider {
+	return &provider{
+		name:   "local",
+		hosts:  hosts,
+		socket: "/run/podman/podman.sock",
+		labels: map[string]string{engine.LabelPool: "1"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+			WorkflowCPUs:      2,
+		},
+	}
+}
+
+func agentContainer(pool, name string, workflows string) container.Summary {
+	return container.Summary{
+		ID:     "id-" + name,
+		Labels: map[string]string{engine.LabelPool: pool, labelAgent: name, labelWorkflows: workflows},
+	}
+}
+
+func TestDeployAgent(t *testing.T) {
+	t.Run("MostFreeHost", func(t *testing.T) {
+		busy := mocks.NewMockClient(t)
+		busy.On("Info", mock.Anything).Return(system.Info{Name: "busy", NCPU: 8}, nil)
+		// 4 of 4 workflows used by agents of this and another pool
+		busy.On("ContainerList", mock.Anything, []string{engine.LabelPool}).Return([]container.Summary{
+			agentContainer("1", "a", "2"), agentContainer("2", "b", "2"),
+		}, nil)
+
+		down := mocks.NewMockClient(t)
+		down.On("Info", mock.Anything).Return(system.Info{}, errors.New("connection refused"))
+
+		free := mocks.NewMockClient(t)
+		free.On("Info", mock.Anything).Return(system.Info{Name: "free", NCPU: 8, Architecture: "aarch64"}, nil)
+		free.On("ContainerList", mock.Anything, []string{engine.LabelPool}).Return([]container.Summary{
+			agentContainer("1", "c", "2"),
+		}, nil)
+		free.On("ContainerCreate", mock.Anything, "pool-1-agent-abcd", mock.MatchedBy(func(c *container.Config) bool {
+			return c.Image == "woodpeckerci/woodpecker-agent:next" &&
+				c.Labels[engine.LabelPool] == "1" &&
+				c.Labels[labelAgent] == "pool-1-agent-abcd" &&
+				c.Labels[labelWorkflows] == "2" &&
+				slices.Contains(c.Env, "WOODPECKER_AGENT_SECRET=token") &&
+				slices.Contains(c.Env, "WOODPECKER_BACKEND=docker") &&
+				slices.Contains(c.Env, "WOODPECKER_MAX_WORKFLOWS=2") &&
+				slices.ContainsFunc(c.Env, func(e string) bool {
+					return e == "WOODPECKER_AGENT_LABELS=autoscaler.arch=arm64,autoscaler.market=on-demand,autoscaler.provider=local,autoscaler.region=free"
+				})
+		}), mock.MatchedBy(func(c *container.HostConfig) bool {
+			return c.Binds[0] == "/run/podman/podman.sock:/var/run/docker.sock" &&
+				c.RestartPolicy.Name == container.RestartPolicyUnlessStopped
+		})).Return("", fmt.Errorf("No such image: %w", cerrdefs.ErrNotFound)).Once()
+		free.On("ImagePull", mock.Anything, "woodpeckerci/woodpecker-agent:next").Return(nil).Once()
+		free.On("ContainerCreate", mock.Anything, "pool-1-agent-abcd", mock.Anything, mock.Anything).Return("id", nil).Once()
+		free.On("ContainerStart", mock.Anything, "id").Return(nil).Once()
+
+		p := newTestProvider(
+			&host{address: "ssh://busy", client: busy},
+			&host{address: "ssh://down", client: down},
+			&host{address: "ssh://free", client: free},
+		)
+		agent := &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}
+		assert.NoError(t, p.DeployAgent(t.Context(), agent))
+		assert.Equal(t, int32(2), agent.Capacity)
+	})
+
+	t.Run("NoCapacity", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Info", mock.Anything).Return(system.Info{NCPU: 4}, nil)
+		client.On("ContainerList", mock.Anything, []string{engine.LabelPool}).Return([]container.Summary{
+			agentContainer("1", "a", "1"),
+		}, nil)
+
+		p := newTestProvider(&host{address: "unix:///var/run/docker.sock", client: client})
+		// one workflow left on the host, an agent runs two
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorIs(t, err, ErrNoCapacity)
+	})
+
+	t.Run("RemovedIfNotStarted", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("Info", mock.Anything).Return(system.Info{NCPU: 4}, nil)
+		client.On("ContainerList", mock.Anything, []string{engine.LabelPool}).Return(nil, nil)
+		client.On("ContainerCreate", mock.Anything, "pool-1-agent-abcd", mock.Anything, mock.Anything).Return("id", nil).Once()
+		client.On("ContainerStart", mock.Anything, "id").Return(errors.New("bind mount failed")).Once()
+		client.On("ContainerRemove", mock.Anything, "id").Return(nil).Once()
+
+		p := newTestProvider(&host{address: "unix:///var/run/docker.sock", client: client})
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "bind mount failed")
+	})
+}
+
+func TestCapacity(t *testing.T) {
+	p := newTestProvider()
+	info := &system.Info{NCPU: 16, MemTotal: 16 << 30}
+
+	assert.Equal(t, 8, p.capacity(info))
+
+	p.config.WorkflowMemory = 4
+	assert.Equal(t, 4, p.capacity(info))
+
+	p.maxWorkflows = 10
+	assert.Equal(t, 10, p.capacity(info))
+
+	p.maxWorkflows = 0
+	p.config.WorkflowCPUs, p.config.WorkflowMemory = 0, 0
+	assert.Greater(t, p.capacity(info), 1<<30)
+}
+
+func TestRemoveAgent(t *testing.T) {
+	filters := []string{engine.LabelPool + "=1", labelAgent + "=pool-1-agent-abcd"}
+
+	first := mocks.NewMockClient(t)
+	first.On("ContainerList", mock.Anything, filters).Return(nil, nil)
+	second := mocks.NewMockClient(t)
+	second.On("ContainerList", mock.Anything, filters).Return([]container.Summary{agentContainer("1", "pool-1-agent-abcd", "2")}, nil)
+	second.On("ContainerRemove", mock.Anything, "id-pool-1-agent-abcd").Return(nil).Once()
+
+	p := newTestProvider(&host{address: "tcp://first:2376", client: first}, &host{address: "tcp://second:2376", client: second})
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	first := mocks.NewMockClient(t)
+	first.On("ContainerList", mock.Anything, []string{engine.LabelPool + "=1"}).Return([]container.Summary{
+		agentContainer("1", "pool-1-agent-abcd", "2"),
+	}, nil)
+	second := mocks.NewMockClient(t)
+	second.On("ContainerList", mock.Anything, []string{engine.LabelPool + "=1"}).Return([]container.Summary{
+		agentContainer("1", "pool-1-agent-efgh", "2"),
+	}, nil)
+
+	p := newTestProvider(&host{address: "tcp://first:2376", client: first}, &host{address: "tcp://second:2376", client: second})
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-abcd", "pool-1-agent-efgh"}, names)
+
+	down := mocks.NewMockClient(t)
+	down.On("ContainerList", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+	p = newTestProvider(&host{address: "tcp://down:2376", client: down})
+	_, err = p.ListDeployedAgentNames(t.Context())
+	assert.ErrorContains(t, err, "connection refused")
+}
@@ -0,0 +1,20 @@
+package local
+
+import (
+	"errors"
+
+	"go.woodpecker-ci.org/autoscaler/providers/local/dockerapi"
+)
+
+var (
+	ErrIllegalLabelPrefix = errors.New("illegal label prefix")
+	ErrNoHosts            = errors.New("no docker hosts given")
+	ErrNoCapacity         = errors.New("no docker host has capacity for another agent")
+)
+
+// host is a docker host agents are deployed on.
+type host struct {
+	// address is the docker host as given, e.g. unix:///var/run/docker.sock
+	address string
+	client  dockerapi.Client
+}
@@ -0,0 +1,18 @@
+package multi
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Multi"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "multi-providers",
+			Usage:    "providers agents are deployed to in order of priority as name[:max-agents], configured by their own settings",
+			Sources:  cli.EnvVars("WOODPECKER_MULTI_PROVIDERS"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Member is a provider of the multi provider.
+type Member struct {
+	Name string
+	// MaxAgents is the maximum number of agents deployed to the provider, 0
+	// for no limit.
+	MaxAgents int
+}
+
+// ParseMembers parses the members given as name[:max-agents].
+func ParseMembers(list []string) ([]Member, error) {
+	members := make([]Member, 0, len(list))
+	for _, entry := range list {
+		name, maxAgents, found := strings.Cut(strings.TrimSpace(entry), ":")
+		m := Member{Name: name}
+		if found {
+			var err error
+			if m.MaxAgents, err = strconv.Atoi(maxAgents); err != nil || m.MaxAgents < 0 {
+				return nil, fmt.Errorf("%w: %s: invalid max agents", ErrInvalidMember, entry)
+			}
+		}
+		if m.Name == "" || m.Name == "multi" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMember, entry)
+		}
+		if slices.ContainsFunc(members, func(other Member) bool { return other.Name == m.Name }) {
+			return nil, fmt.Errorf("%w: %s: given twice", ErrInvalidMember, m.Name)
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// Factory creates the provider of the name with its own settings.
+type Factory func(ctx context.Context, name string) (types.Provider, error)
+
+type member struct {
+	Member
+	provider types.Provider
+}
+
+type provider struct {
+	name    string
+	members []*member
+	config  *config.Config
+
+	// owners maps the agents to the member they were deployed to or listed
+	// by, so they are removed there.
+	ownersMu sync.Mutex
+	owners   map[string]*member
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config, factory Factory) (types.Provider, error) {
+	p := &provider{
+		name:   "multi",
+		config: config,
+		owners: map[string]*member{},
+	}
+
+	members, err := ParseMembers(c.StringSlice("multi-providers"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%s: %w: multi-providers", p.name, ErrMissingSetting)
+	}
+
+	for _, m := range members {
+		provider, err := factory(ctx, m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		p.members = append(p.members, &member{Member: m, provider: provider})
+	}
+
+	return p, nil
+}
+
+// full reports whether the member has reached its max agents.
+func (p *provider) full(ctx context.Context, m *member) (bool, error) {
+	if m.MaxAgents == 0 {
+		return false, nil
+	}
+
+	names, err := m.provider.ListDeployedAgentNames(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(names) >= m.MaxAgents, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	var errs []error
+	for _, m := range p.members {
+		full, err := p.full(ctx, m)
+		if err == nil && full {
+			log.Debug().Msgf("skip provider at its max agents: provider = %s max agents = %d", m.Name, m.MaxAgents)
+			continue
+		}
+		if err == nil {
+			err = m.provider.DeployAgent(ctx, agent)
+		}
+		if err == nil {
+			p.setOwner(agent.Name, m)
+			return nil
+		}
+
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+		// spill over to the next provider, e.g. on capacity errors or outages
+		log.Warn().Msgf("create agent failed: provider = %s: %s", m.Name, err)
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("%s: %w", p.name, ErrNoCapacity)
+	}
+	return fmt.Errorf("%s: %w", p.name, errors.Join(errs...))
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	m := p.owner(agent.Name)
+	if m == nil {
+		// not deployed or listed by this process, e.g. after a restart
+		if _, err := p.ListDeployedAgentNames(ctx); err != nil {
+			return err
+		}
+		if m = p.owner(agent.Name); m == nil {
+			return fmt.Errorf("%s: %w: %s", p.name, ErrUnknownAgent, agent.Name)
+		}
+	}
+
+	if err := m.provider.RemoveAgent(ctx, agent); err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	p.ownersMu.Lock()
+	delete(p.owners, agent.Name)
+	p.ownersMu.Unlock()
+
+	return nil
+}
+
+// ListDeployedAgentNames merges the agents of all members. It fails if any
+// member fails, so the agents of an unreachable provider are not mistaken
+// for gone.
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var names []string
+	var errs []error
+	for _, m := range p.members {
+		memberNames, err := m.provider.ListDeployedAgentNames(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p.setOwners(m, memberNames)
+		names = append(names, memberNames...)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %w", p.name, errors.Join(errs...))
+	}
+	return names, nil
+}
+
+// setOwners records the member as owner of the agents it listed and forgets
+// the agents it owned but did not list anymore.
+func (p *provider) setOwners(m *member, agentNames []string) {
+	p.ownersMu.Lock()
+	defer p.ownersMu.Unlock()
+	for name, owner := range p.owners {
+		if owner == m && !slices.Contains(agentNames, name) {
+			delete(p.owners, name)
+		}
+	}
+	for _, name := range agentNames {
+		p.owners[name] = m
+	}
+}
+
+func (p *provider) setOwner(agentName string, m *member) {
+	p.ownersMu.Lock()
+	defer p.ownersMu.Unlock()
+	p.owners[agentName] = m
+}
+
+func (p *provider) owner(agentName string) *member {
+	p.ownersMu.Lock()
+	defer p.ownersMu.Unlock()
+	return p.owners[agentName]
+}
+
+// BillingModel is hourly-round-up only if all members bill so, keeping idle
+// agents until the hour boundary would waste money on the others.
+func (p *provider) BillingModel() types.BillingModel {
+	for _, m := range p.members {
+		if m.provider.BillingModel() != types.BillingHourlyRoundUp {
+			return types.BillingPerSecond
+		}
+	}
+	return types.BillingHourlyRoundUp
+}
@@ -0,0 +1,199 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/engine/types/mocks"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func newTestProvider(members ...*member) *provider {
+	return &provider{
+		name:    "multi",
+		members: members,
+		config:  &config.Config{PoolID: "1"},
+		owners:  map[string]*member{},
+	}
+}
+
+func TestParseMembers(t *testing.T) {
+	members, err := ParseMembers([]string{"hetznercloud:10", " aws "})
+	require.NoError(t, err)
+	assert.Equal(t, []Member{{Name: "hetznercloud", MaxAgents: 10}, {Name: "aws"}}, members)
+
+	for _, list := range [][]string{
+		{"hetznercloud:ten"},
+		{"hetznercloud:-1"},
+		{":10"},
+		{"multi"},
+		{"aws", "aws:2"},
+	} {
+		_, err := ParseMembers(list)
+		assert.ErrorIs(t, err, ErrInvalidMember, list)
+	}
+}
+
+func TestDeployAgent(t *testing.T) {
+	agent := &woodpecker.Agent{Name: "pool-1-agent-abcd"}
+
+	t.Run("FirstProvider", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-efgh"}, nil).Once()
+		hetzner.On("DeployAgent", mock.Anything, agent).Return(nil).Once()
+		hetzner.On("RemoveAgent", mock.Anything, agent).Return(nil).Once()
+		aws := mocks.NewMockProvider(t)
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud", MaxAgents: 2}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		require.NoError(t, p.DeployAgent(t.Context(), agent))
+		// removed from the provider it was deployed to
+		assert.NoError(t, p.RemoveAgent(t.Context(), agent))
+	})
+
+	t.Run("SpillOverAtMaxAgents", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-efgh", "pool-1-agent-ijkl"}, nil).Once()
+		aws := mocks.NewMockProvider(t)
+		aws.On("DeployAgent", mock.Anything, agent).Return(nil).Once()
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud", MaxAgents: 2}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		require.NoError(t, p.DeployAgent(t.Context(), agent))
+		assert.Same(t, aws, p.owner(agent.Name).provider)
+	})
+
+	t.Run("SpillOverOnError", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("DeployAgent", mock.Anything, agent).Return(errors.New("resource_unavailable")).Once()
+		aws := mocks.NewMockProvider(t)
+		aws.On("DeployAgent", mock.Anything, agent).Return(nil).Once()
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud"}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		require.NoError(t, p.DeployAgent(t.Context(), agent))
+		assert.Same(t, aws, p.owner(agent.Name).provider)
+	})
+
+	t.Run("AllFailed", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("DeployAgent", mock.Anything, agent).Return(errors.New("resource_unavailable")).Once()
+		aws := mocks.NewMockProvider(t)
+		aws.On("DeployAgent", mock.Anything, agent).Return(errors.New("InsufficientInstanceCapacity")).Once()
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud"}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		err := p.DeployAgent(t.Context(), agent)
+		assert.ErrorContains(t, err, "resource_unavailable")
+		assert.ErrorContains(t, err, "InsufficientInstanceCapacity")
+	})
+
+	t.Run("AllFull", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-efgh"}, nil).Once()
+
+		p := newTestProvider(&member{Member: Member{Name: "hetznercloud", MaxAgents: 1}, provider: hetzner})
+		assert.ErrorIs(t, p.DeployAgent(t.Context(), agent), ErrNoCapacity)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(t.Context())
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("DeployAgent", mock.Anything, agent).Run(func(mock.Arguments) { cancel(nil) }).Return(context.Canceled).Once()
+		aws := mocks.NewMockProvider(t)
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud"}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		assert.ErrorIs(t, p.DeployAgent(ctx, agent), context.Canceled)
+	})
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	t.Run("Merged", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-abcd"}, nil).Once()
+		aws := mocks.NewMockProvider(t)
+		aws.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-efgh"}, nil).Once()
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud"}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		names, err := p.ListDeployedAgentNames(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pool-1-agent-abcd", "pool-1-agent-efgh"}, names)
+	})
+
+	t.Run("MemberFailed", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return(nil, errors.New("service unavailable")).Once()
+		aws := mocks.NewMockProvider(t)
+		aws.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-ijkl"}, nil).Once()
+
+		hetznerMember := &member{Member: Member{Name: "hetznercloud"}, provider: hetzner}
+		awsMember := &member{Member: Member{Name: "aws"}, provider: aws}
+		p := newTestProvider(hetznerMember, awsMember)
+		p.owners["pool-1-agent-abcd"] = hetznerMember
+		p.owners["pool-1-agent-efgh"] = awsMember
+
+		_, err := p.ListDeployedAgentNames(t.Context())
+		assert.ErrorContains(t, err, "service unavailable")
+		// only the owners of the member that listed are updated
+		assert.Equal(t, map[string]*member{
+			"pool-1-agent-abcd": hetznerMember,
+			"pool-1-agent-ijkl": awsMember,
+		}, p.owners)
+	})
+}
+
+func TestRemoveAgent(t *testing.T) {
+	t.Run("OwnerListed", func(t *testing.T) {
+		agent := &woodpecker.Agent{Name: "pool-1-agent-efgh"}
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-abcd"}, nil).Once()
+		aws := mocks.NewMockProvider(t)
+		aws.On("ListDeployedAgentNames", mock.Anything).Return([]string{"pool-1-agent-efgh"}, nil).Once()
+		aws.On("RemoveAgent", mock.Anything, agent).Return(nil).Once()
+
+		p := newTestProvider(
+			&member{Member: Member{Name: "hetznercloud"}, provider: hetzner},
+			&member{Member: Member{Name: "aws"}, provider: aws},
+		)
+		assert.NoError(t, p.RemoveAgent(t.Context(), agent))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		hetzner := mocks.NewMockProvider(t)
+		hetzner.On("ListDeployedAgentNames", mock.Anything).Return([]string{}, nil).Once()
+
+		p := newTestProvider(&member{Member: Member{Name: "hetznercloud"}, provider: hetzner})
+		assert.ErrorIs(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}), ErrUnknownAgent)
+	})
+}
+
+func TestBillingModel(t *testing.T) {
+	hetzner := mocks.NewMockProvider(t)
+	hetzner.On("BillingModel").Return(types.BillingHourlyRoundUp)
+	aws := mocks.NewMockProvider(t)
+	aws.On("BillingModel").Return(types.BillingPerSecond)
+
+	assert.Equal(t, types.BillingHourlyRoundUp, newTestProvider(&member{provider: hetzner}).BillingModel())
+	assert.Equal(t, types.BillingPerSecond, newTestProvider(&member{provider: hetzner}, &member{provider: aws}).BillingModel())
+}
@@ -0,0 +1,12 @@
+package multi
+
+import (
+	"errors"
+)
+
+var (
+	ErrMissingSetting = errors.New("missing setting")
+	ErrInvalidMember  = errors.New("invalid member")
+	ErrNoCapacity     = errors.New("all providers are at their max agents")
+	ErrUnknownAgent   = errors.New("agent not deployed to any provider")
+)
@@ -0,0 +1,117 @@
+package oci
+
+import (
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Oracle Cloud Infrastructure"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "oci-auth",
+			Value:    authAPIKey,
+			Usage:    "authentication, api-key or instance-principal",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_AUTH"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-tenancy",
+			Usage:    "OCID of the tenancy of the API key",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_TENANCY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-user",
+			Usage:    "OCID of the user of the API key",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_USER"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-key-file",
+			Usage:    "path to the unencrypted private key of the API key in PEM format",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_KEY_FILE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-fingerprint",
+			Usage:    "fingerprint of the API key, computed from the key if empty",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_FINGERPRINT"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-region",
+			Usage:    "region, e.g. eu-frankfurt-1; the region of the instance with instance-principal if empty",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_REGION"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-compartment",
+			Usage:    "OCID of the compartment the instances are launched in",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_COMPARTMENT"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "oci-availability-domains",
+			Usage:    "availability domains tried in order when launching an instance, all of the region if empty",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_AVAILABILITY_DOMAINS"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-shape",
+			Value:    "VM.Standard.A1.Flex",
+			Usage:    "shape of the instances",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_SHAPE"),
+			Category: category,
+		},
+		&cli.FloatFlag{
+			Name:     "oci-ocpus",
+			Usage:    "OCPUs of flexible shapes, the default of the shape if 0",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_OCPUS"),
+			Category: category,
+		},
+		&cli.FloatFlag{
+			Name:     "oci-memory",
+			Usage:    "memory of flexible shapes in GB, the default of the shape if 0",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_MEMORY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-image",
+			Usage:    "OCID of the image the instances are launched from, it needs to match the architecture of the shape",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_IMAGE"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "oci-boot-volume-size",
+			Usage:    "size of the boot volume in GB, the size of the image if 0",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_BOOT_VOLUME_SIZE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "oci-subnet",
+			Usage:    "OCID of the subnet the instances are attached to",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_SUBNET"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "oci-assign-public-ip",
+			Value:    true,
+			Usage:    "assign public IPs, set to false in private subnets",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_ASSIGN_PUBLIC_IP"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "oci-ssh-keys",
+			Usage:    "public SSH keys authorized on the instances",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_SSH_KEYS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "oci-tags",
+			Usage:    "freeform tags of the instances as key=value",
+			Sources:  cli.EnvVars("WOODPECKER_OCI_TAGS"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package ociapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Client is the subset of the OCI compute and identity SDK clients the oci
+// provider uses.
+type Client interface {
+	// ListAvailabilityDomains returns the names of the availability domains
+	// of the region.
+	ListAvailabilityDomains(ctx context.Context, compartmentID string) ([]string, error)
+	LaunchInstance(ctx context.Context, details core.LaunchInstanceDetails) (*core.Instance, error)
+	// ListInstances returns the instances of the compartment in all
+	// lifecycle states.
+	ListInstances(ctx context.Context, compartmentID string) ([]core.Instance, error)
+	// TerminateInstance terminates the instance and, unless preserved, its
+	// boot volume.
+	TerminateInstance(ctx context.Context, instanceID string, preserveBootVolume bool) error
+}
+
+// IsError reports whether err is a service error with one of the status
+// codes.
+func IsError(err error, statusCodes ...int) bool {
+	var serviceErr common.ServiceError
+	return errors.As(err, &serviceErr) && slices.Contains(statusCodes, serviceErr.GetHTTPStatusCode())
+}
@@ -0,0 +1,122 @@
+package ociapi
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+// pageLimit is the number of items requested per page.
+const pageLimit = 100
+
+type client struct {
+	compute  core.ComputeClient
+	identity identity.IdentityClient
+}
+
+// NewClient creates a client for the APIs of the region of the configuration
+// provider.
+func NewClient(config common.ConfigurationProvider) (Client, error) {
+	c, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClientWithURLs creates a client for the compute and identity APIs at
+// the URLs.
+func NewClientWithURLs(config common.ConfigurationProvider, computeURL, identityURL string) (Client, error) {
+	c, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	c.compute.Host, c.identity.Host = computeURL, identityURL
+	return c, nil
+}
+
+func newClient(config common.ConfigurationProvider) (*client, error) {
+	computeClient, err := core.NewComputeClientWithConfigurationProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	identityClient, err := identity.NewIdentityClientWithConfigurationProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := " woodpecker-autoscaler/" + version.String()
+	computeClient.UserAgent += userAgent
+	identityClient.UserAgent += userAgent
+	return &client{compute: computeClient, identity: identityClient}, nil
+}
+
+// Fingerprint returns the fingerprint of the public key of an API key as
+// shown by the console.
+func Fingerprint(key *rsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(der)
+	return strings.ReplaceAll(fmt.Sprintf("% x", sum), " ", ":"), nil
+}
+
+func (c *client) ListAvailabilityDomains(ctx context.Context, compartmentID string) ([]string, error) {
+	resp, err := c.identity.ListAvailabilityDomains(ctx, identity.ListAvailabilityDomainsRequest{
+		CompartmentId: &compartmentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(resp.Items))
+	for _, domain := range resp.Items {
+		if domain.Name != nil {
+			names = append(names, *domain.Name)
+		}
+	}
+	return names, nil
+}
+
+func (c *client) LaunchInstance(ctx context.Context, details core.LaunchInstanceDetails) (*core.Instance, error) {
+	resp, err := c.compute.LaunchInstance(ctx, core.LaunchInstanceRequest{LaunchInstanceDetails: details})
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Instance, nil
+}
+
+func (c *client) ListInstances(ctx context.Context, compartmentID string) ([]core.Instance, error) {
+	var instances []core.Instance
+	req := core.ListInstancesRequest{CompartmentId: &compartmentID, Limit: common.Int(pageLimit)}
+	for {
+		resp, err := c.compute.ListInstances(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.Items...)
+
+		if resp.OpcNextPage == nil {
+			return instances, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+func (c *client) TerminateInstance(ctx context.Context, instanceID string, preserveBootVolume bool) error {
+	_, err := c.compute.TerminateInstance(ctx, core.TerminateInstanceRequest{
+		InstanceId:         &instanceID,
+		PreserveBootVolume: &preserveBootVolume,
+	})
+	return err
+}
@@ -0,0 +1,107 @@
+package ociapi_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/providers/oci/ociapi"
+)
+
+// newClient creates a client signing with a new API key for the handler.
+func newClient(t *testing.T, handler http.HandlerFunc) ociapi.Client {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	fingerprint, err := ociapi.Fingerprint(key)
+	require.NoError(t, err)
+	assert.Regexp(t, `^([0-9a-f]{2}:){15}[0-9a-f]{2}$`, fingerprint)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Authorization"), `keyId="ocid1.tenancy.oc1..t/ocid1.user.oc1..u/`+fingerprint+`"`)
+		assert.Contains(t, r.Header.Get("User-Agent"), "woodpecker-autoscaler/")
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	config := common.NewRawConfigurationProvider("ocid1.tenancy.oc1..t", "ocid1.user.oc1..u", "eu-frankfurt-1", fingerprint, string(keyPEM), nil)
+	client, err := ociapi.NewClientWithURLs(config, server.URL, server.URL)
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient(t *testing.T) {
+	var pages int
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /20160918/instances":
+			pages++
+			assert.Equal(t, "ocid1.compartment.oc1..c", r.URL.Query().Get("compartmentId"))
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Opc-Next-Page", "2")
+				_, _ = w.Write([]byte(`[{"id": "ocid1.instance.oc1..a", "displayName": "agent-a", "lifecycleState": "RUNNING"}]`))
+				return
+			}
+			assert.Equal(t, "2", r.URL.Query().Get("page"))
+			_, _ = w.Write([]byte(`[{"id": "ocid1.instance.oc1..b", "displayName": "agent-b", "lifecycleState": "TERMINATED"}]`))
+		case "POST /20160918/instances":
+			var details map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+			assert.Equal(t, "agent-a", details["displayName"])
+			assert.Equal(t, map[string]any{"sourceType": "image", "imageId": "ocid1.image.oc1..i"}, details["sourceDetails"])
+			_, _ = w.Write([]byte(`{"id": "ocid1.instance.oc1..a", "displayName": "agent-a", "lifecycleState": "PROVISIONING"}`))
+		case "GET /20160918/availabilityDomains":
+			_, _ = w.Write([]byte(`[{"name": "Uocm:EU-FRANKFURT-1-AD-1"}, {"name": "Uocm:EU-FRANKFURT-1-AD-2"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	instances, err := client.ListInstances(t.Context(), "ocid1.compartment.oc1..c")
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "agent-b", *instances[1].DisplayName)
+	assert.Equal(t, core.InstanceLifecycleStateTerminated, instances[1].LifecycleState)
+
+	instance, err := client.LaunchInstance(t.Context(), core.LaunchInstanceDetails{
+		AvailabilityDomain: common.String("Uocm:EU-FRANKFURT-1-AD-1"),
+		CompartmentId:      common.String("ocid1.compartment.oc1..c"),
+		DisplayName:        common.String("agent-a"),
+		Shape:              common.String("VM.Standard.A1.Flex"),
+		SourceDetails:      core.InstanceSourceViaImageDetails{ImageId: common.String("ocid1.image.oc1..i")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ocid1.instance.oc1..a", *instance.Id)
+
+	domains, err := client.ListAvailabilityDomains(t.Context(), "ocid1.tenancy.oc1..t")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Uocm:EU-FRANKFURT-1-AD-1", "Uocm:EU-FRANKFURT-1-AD-2"}, domains)
+}
+
+func TestError(t *testing.T) {
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/20160918/instances/ocid1.instance.oc1..a", r.URL.Path)
+		assert.Equal(t, "false", r.URL.Query().Get("preserveBootVolume"))
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "NotAuthorizedOrNotFound", "message": "Authorization failed or requested resource not found."})
+	})
+
+	err := client.TerminateInstance(t.Context(), "ocid1.instance.oc1..a", false)
+	assert.True(t, ociapi.IsError(err, http.StatusNotFound))
+	assert.False(t, ociapi.IsError(err, http.StatusInternalServerError))
+	assert.ErrorContains(t, err, "NotAuthorizedOrNotFound")
+}
@@ -0,0 +1,346 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/oci/ociapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// UserDataLimit is the maximum size of user data in bytes, all metadata
+// values together may have 32000 bytes and the user data is base64 encoded.
+const UserDataLimit = 32000 / 4 * 3
+
+// blackhole metadata services so running steps can not extract agent token from user-data
+// https://docs.oracle.com/en-us/iaas/Content/Compute/Tasks/gettingmetadata.htm
+// The metadata server is the DNS resolver of the instances as well, so only
+// its HTTP port is dropped instead of routing it into a blackhole.
+var blackholeMetadataAPI = []string{
+	"iptables -t mangle -I PREROUTING -d 169.254.169.254 -p tcp --dport 80 -j DROP",
+	"iptables -t mangle -I OUTPUT -d 169.254.169.254 -p tcp --dport 80 -j DROP",
+}
+
+const (
+	authAPIKey            = "api-key"
+	authInstancePrincipal = "instance-principal"
+)
+
+// tagPrefix is the prefix of the freeform tags set by the autoscaler, OCI tag
+// keys can not contain the periods of engine.LabelPrefix.
+const tagPrefix = "wp-autoscaler-"
+
+// tagPool identifies the instances of the pool.
+const tagPool = tagPrefix + "pool"
+
+// ampereShape matches the shapes of Ampere arm64 processors, e.g.
+// VM.Standard.A1.Flex.
+var ampereShape = regexp.MustCompile(`\.A\d+\.`)
+
+type provider struct {
+	name                string
+	compartment         string
+	availabilityDomains []string
+	shape               string
+	ocpus               float32
+	memory              float32
+	image               string
+	bootVolumeSize      int64
+	subnet              string
+	assignPublicIP      bool
+	sshKeys             []string
+	tags                map[string]string
+	config              *config.Config
+	client              ociapi.Client
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:                "oci",
+		compartment:         c.String("oci-compartment"),
+		availabilityDomains: c.StringSlice("oci-availability-domains"),
+		shape:               c.String("oci-shape"),
+		ocpus:               float32(c.Float("oci-ocpus")),
+		memory:              float32(c.Float("oci-memory")),
+		image:               c.String("oci-image"),
+		bootVolumeSize:      int64(c.Int("oci-boot-volume-size")),
+		subnet:              c.String("oci-subnet"),
+		assignPublicIP:      c.Bool("oci-assign-public-ip"),
+		sshKeys:             c.StringSlice("oci-ssh-keys"),
+		config:              config,
+	}
+
+	for _, setting := range []struct{ name, value string }{
+		{"oci-compartment", p.compartment},
+		{"oci-shape", p.shape},
+		{"oci-image", p.image},
+		{"oci-subnet", p.subnet},
+	} {
+		if setting.value == "" {
+			return nil, fmt.Errorf("%s: %w: %s", p.name, ErrMissingSetting, setting.name)
+		}
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.client = client
+
+	if len(p.availabilityDomains) == 0 {
+		if p.availabilityDomains, err = p.client.ListAvailabilityDomains(ctx, p.compartment); err != nil {
+			return nil, fmt.Errorf("%s: ListAvailabilityDomains: %w", p.name, err)
+		}
+	}
+	log.Debug().Msgf("oci: availability domains = %s shape = %s", strings.Join(p.availabilityDomains, ","), p.shape)
+
+	userTags := c.StringSlice("oci-tags")
+	if err := utils.CheckReservedTags(userTags, tagPrefix, ErrIllegalTagPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	tags, err := utils.SliceToMap(userTags, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.tags = utils.MergeMaps(tags, map[string]string{tagPool: p.config.PoolID})
+
+	return p, nil
+}
+
+// newClient creates the client authenticating as configured.
+func newClient(c *cli.Command) (ociapi.Client, error) {
+	region := c.String("oci-region")
+
+	switch c.String("oci-auth") {
+	case authAPIKey:
+		tenancy, user, fingerprint := c.String("oci-tenancy"), c.String("oci-user"), c.String("oci-fingerprint")
+		for _, setting := range []struct{ name, value string }{
+			{"oci-tenancy", tenancy},
+			{"oci-user", user},
+			{"oci-key-file", c.String("oci-key-file")},
+			{"oci-region", region},
+		} {
+			if setting.value == "" {
+				return nil, fmt.Errorf("%w: %s", ErrMissingSetting, setting.name)
+			}
+		}
+
+		data, err := os.ReadFile(c.String("oci-key-file"))
+		if err != nil {
+			return nil, err
+		}
+		if fingerprint == "" {
+			key, err := common.PrivateKeyFromBytes(data, nil)
+			if err != nil {
+				return nil, err
+			}
+			if fingerprint, err = ociapi.Fingerprint(key); err != nil {
+				return nil, err
+			}
+		}
+
+		return ociapi.NewClient(common.NewRawConfigurationProvider(tenancy, user, region, fingerprint, string(data), nil))
+
+	case authInstancePrincipal:
+		var configProvider common.ConfigurationProvider
+		var err error
+		if region == "" {
+			configProvider, err = auth.InstancePrincipalConfigurationProvider()
+		} else {
+			configProvider, err = auth.InstancePrincipalConfigurationProviderForRegion(common.StringToRegion(region))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("InstancePrincipalConfigurationProvider: %w", err)
+		}
+
+		return ociapi.NewClient(configProvider)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidAuth, c.String("oci-auth"))
+}
+
+// arch returns the architecture of the shape.
+func arch(shape string) string {
+	if ampereShape.MatchString(shape) {
+		return "arm64"
+	}
+	return "amd64"
+}
+
+// vcpus returns the vCPUs of the OCPUs of the shape, an x86 OCPU has two.
+func vcpus(shape string, ocpus float32) int {
+	if arch(shape) == "arm64" {
+		return int(ocpus)
+	}
+	return int(ocpus * 2) //nolint:mnd
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	for i, availabilityDomain := range p.availabilityDomains {
+		userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+			PreExec:  blackholeMetadataAPI,
+			Provider: p.name,
+			Candidate: cloudinit.Candidate{
+				InstanceType: p.shape,
+				Region:       availabilityDomain,
+				Arch:         arch(p.shape),
+				Workflows:    p.config.Workflows(p.shape, vcpus(p.shape, p.ocpus), int64(p.memory*1024)), //nolint:mnd
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+		}
+
+		err = p.launchInstance(ctx, agent, availabilityDomain, userData)
+		if err == nil {
+			return nil
+		}
+
+		// Continue to next fallback entry only if the availability domain
+		// has no capacity.
+		if !outOfCapacity(err) {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+
+		// Only log and continue if there are more candidates left.
+		if i < len(p.availabilityDomains)-1 {
+			log.Warn().Msgf("create agent failed: availability domain = %s: %s", availabilityDomain, err)
+			continue
+		}
+
+		// Last candidate failed.
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// outOfCapacity reports whether the error is caused by an availability domain
+// out of host capacity for the shape or a limit of the availability domain.
+func outOfCapacity(err error) bool {
+	var serviceErr common.ServiceError
+	if !errors.As(err, &serviceErr) {
+		return false
+	}
+	switch serviceErr.GetCode() {
+	case "InternalError":
+		return strings.HasPrefix(serviceErr.GetMessage(), "Out of host capacity")
+	case "LimitExceeded":
+		return true
+	}
+	return false
+}
+
+func (p *provider) launchInstance(ctx context.Context, agent *woodpecker.Agent, availabilityDomain, userData string) error {
+	source := core.InstanceSourceViaImageDetails{ImageId: &p.image}
+	if p.bootVolumeSize > 0 {
+		source.BootVolumeSizeInGBs = &p.bootVolumeSize
+	}
+	details := core.LaunchInstanceDetails{
+		AvailabilityDomain: &availabilityDomain,
+		CompartmentId:      &p.compartment,
+		DisplayName:        &agent.Name,
+		Shape:              &p.shape,
+		SourceDetails:      source,
+		CreateVnicDetails: &core.CreateVnicDetails{
+			SubnetId:       &p.subnet,
+			AssignPublicIp: &p.assignPublicIP,
+		},
+		Metadata: map[string]string{
+			"user_data": base64.StdEncoding.EncodeToString([]byte(userData)),
+		},
+		FreeformTags: p.tags,
+	}
+	if p.ocpus > 0 || p.memory > 0 {
+		details.ShapeConfig = &core.LaunchInstanceShapeConfigDetails{}
+		if p.ocpus > 0 {
+			details.ShapeConfig.Ocpus = &p.ocpus
+		}
+		if p.memory > 0 {
+			details.ShapeConfig.MemoryInGBs = &p.memory
+		}
+	}
+	if len(p.sshKeys) > 0 {
+		details.Metadata["ssh_authorized_keys"] = strings.Join(p.sshKeys, "\n")
+	}
+
+	instance, err := p.client.LaunchInstance(ctx, details)
+	if err != nil {
+		return fmt.Errorf("LaunchInstance: %w", err)
+	}
+	log.Debug().Msgf("launched instance %s in %s", *instance.Id, availabilityDomain)
+
+	return nil
+}
+
+// poolInstances returns the instances of the pool which are not terminated.
+func (p *provider) poolInstances(ctx context.Context) ([]core.Instance, error) {
+	instances, err := p.client.ListInstances(ctx, p.compartment)
+	if err != nil {
+		return nil, fmt.Errorf("ListInstances: %w", err)
+	}
+
+	var pool []core.Instance
+	for _, instance := range instances {
+		if instance.FreeformTags[tagPool] != p.config.PoolID || instance.DisplayName == nil {
+			continue
+		}
+		if instance.LifecycleState == core.InstanceLifecycleStateTerminating || instance.LifecycleState == core.InstanceLifecycleStateTerminated {
+			continue
+		}
+		pool = append(pool, instance)
+	}
+	return pool, nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	instances, err := p.poolInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	for _, instance := range instances {
+		if *instance.DisplayName != agent.Name {
+			continue
+		}
+		if err := p.client.TerminateInstance(ctx, *instance.Id, false); err != nil && !ociapi.IsError(err, http.StatusNotFound) {
+			return fmt.Errorf("%s: TerminateInstance: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	instances, err := p.poolInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	names := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		names = append(names, *instance.DisplayName)
+	}
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
@@ -0,0 +1,204 @@
+package oci
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/oci/ociapi"
+	"go.woodpecker-ci.org/autoscaler/providers/oci/ociapi/mocks"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// serviceError is an error response of the API as returned by the SDK.
+type serviceError struct {
+	statusCode    int
+	code, message string
+}
+
+func (e *serviceError) Error() string {
+	return fmt.Sprintf("Error returned by service. Http Status Code: %d. Error Code: %s. Message: %s", e.statusCode, e.code, e.message)
+}
+
+func (e *serviceError) GetHTTPStatusCode() int  { return e.statusCode }
+func (e *serviceError) GetMessage() string      { return e.message }
+func (e *serviceError) GetCode() string         { return e.code }
+func (e *serviceError) GetOpcRequestID() string { return "" }
+
+func newTestProvider(client ociapi.Client) *provider {
+	return &provider{
+		name:                "oci",
+		compartment:         "ocid1.compartment.oc1..c",
+		availabilityDomains: []string{"Uocm:EU-FRANKFURT-1-AD-1", "Uocm:EU-FRANKFURT-1-AD-2"},
+		shape:               "VM.Standard.A1.Flex",
+		ocpus:               4,
+		memory:              24,
+		image:               "ocid1.image.oc1..i",
+		subnet:              "ocid1.subnet.oc1..s",
+		assignPublicIP:      true,
+		sshKeys:             []string{"ssh-ed25519 AAAA"},
+		tags:                map[string]string{tagPool: "1", "team": "ci"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: client,
+	}
+}
+
+func TestDeployAgent(t *testing.T) {
+	t.Run("Launched", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("LaunchInstance", mock.Anything, mock.MatchedBy(func(d core.LaunchInstanceDetails) bool {
+			userData, _ := base64.StdEncoding.DecodeString(d.Metadata["user_data"])
+			source, ok := d.SourceDetails.(core.InstanceSourceViaImageDetails)
+			return *d.AvailabilityDomain == "Uocm:EU-FRANKFURT-1-AD-1" &&
+				*d.CompartmentId == "ocid1.compartment.oc1..c" &&
+				*d.DisplayName == "pool-1-agent-abcd" &&
+				*d.ShapeConfig.Ocpus == 4 &&
+				*d.ShapeConfig.MemoryInGBs == 24 &&
+				ok && *source.ImageId == "ocid1.image.oc1..i" && source.BootVolumeSizeInGBs == nil &&
+				*d.CreateVnicDetails.SubnetId == "ocid1.subnet.oc1..s" &&
+				*d.CreateVnicDetails.AssignPublicIp &&
+				d.Metadata["ssh_authorized_keys"] == "ssh-ed25519 AAAA" &&
+				d.FreeformTags[tagPool] == "1" &&
+				strings.Contains(string(userData), "autoscaler.arch=arm64") &&
+				strings.Contains(string(userData), "autoscaler.region=Uocm:EU-FRANKFURT-1-AD-1") &&
+				strings.Contains(string(userData), "--dport 80 -j DROP")
+		})).Return(&core.Instance{Id: common.String("ocid1.instance.oc1..a")}, nil).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+	})
+
+	t.Run("FallbackToNextAvailabilityDomain", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("LaunchInstance", mock.Anything, mock.MatchedBy(func(d core.LaunchInstanceDetails) bool {
+			return *d.AvailabilityDomain == "Uocm:EU-FRANKFURT-1-AD-1"
+		})).Return(nil, &serviceError{statusCode: http.StatusInternalServerError, code: "InternalError", message: "Out of host capacity."}).Once()
+		client.On("LaunchInstance", mock.Anything, mock.MatchedBy(func(d core.LaunchInstanceDetails) bool {
+			userData, _ := base64.StdEncoding.DecodeString(d.Metadata["user_data"])
+			return *d.AvailabilityDomain == "Uocm:EU-FRANKFURT-1-AD-2" &&
+				strings.Contains(string(userData), "autoscaler.region=Uocm:EU-FRANKFURT-1-AD-2")
+		})).Return(&core.Instance{Id: common.String("ocid1.instance.oc1..a")}, nil).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	})
+
+	t.Run("FallbackOnLimitExceeded", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("LaunchInstance", mock.Anything, mock.MatchedBy(func(d core.LaunchInstanceDetails) bool {
+			return *d.AvailabilityDomain == "Uocm:EU-FRANKFURT-1-AD-1"
+		})).Return(nil, &serviceError{statusCode: http.StatusBadRequest, code: "LimitExceeded", message: "The following service limits were exceeded: standard-a1-core-count."}).Once()
+		client.On("LaunchInstance", mock.Anything, mock.MatchedBy(func(d core.LaunchInstanceDetails) bool {
+			return *d.AvailabilityDomain == "Uocm:EU-FRANKFURT-1-AD-2"
+		})).Return(&core.Instance{Id: common.String("ocid1.instance.oc1..a")}, nil).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	})
+
+	t.Run("NoFallbackOnOtherErrors", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("LaunchInstance", mock.Anything, mock.Anything).
+			Return(nil, &serviceError{statusCode: http.StatusBadRequest, code: "InvalidParameter", message: "Invalid imageId."}).Once()
+
+		p := newTestProvider(client)
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "InvalidParameter")
+	})
+
+	t.Run("AllAvailabilityDomainsFull", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("LaunchInstance", mock.Anything, mock.Anything).
+			Return(nil, &serviceError{statusCode: http.StatusInternalServerError, code: "InternalError", message: "Out of host capacity."}).Twice()
+
+		p := newTestProvider(client)
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "oci: LaunchInstance: Error returned by service. Http Status Code: 500. Error Code: InternalError. Message: Out of host capacity.")
+	})
+
+	t.Run("ShapeDefaults", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("LaunchInstance", mock.Anything, mock.MatchedBy(func(d core.LaunchInstanceDetails) bool {
+			userData, _ := base64.StdEncoding.DecodeString(d.Metadata["user_data"])
+			return d.ShapeConfig == nil &&
+				*d.Shape == "VM.Standard.E4.Flex" &&
+				strings.Contains(string(userData), "autoscaler.arch=amd64")
+		})).Return(&core.Instance{Id: common.String("ocid1.instance.oc1..a")}, nil).Once()
+
+		p := newTestProvider(client)
+		p.shape, p.ocpus, p.memory = "VM.Standard.E4.Flex", 0, 0
+		assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	})
+}
+
+func instance(id, name string, state core.InstanceLifecycleStateEnum, tags map[string]string) core.Instance {
+	return core.Instance{Id: common.String(id), DisplayName: common.String(name), LifecycleState: state, FreeformTags: tags}
+}
+
+func instances() []core.Instance {
+	return []core.Instance{
+		instance("ocid1.instance.oc1..a", "pool-1-agent-a", core.InstanceLifecycleStateRunning, map[string]string{tagPool: "1"}),
+		instance("ocid1.instance.oc1..b", "pool-1-agent-b", core.InstanceLifecycleStateProvisioning, map[string]string{tagPool: "1"}),
+		instance("ocid1.instance.oc1..c", "pool-1-agent-c", core.InstanceLifecycleStateTerminated, map[string]string{tagPool: "1"}),
+		instance("ocid1.instance.oc1..d", "pool-2-agent-d", core.InstanceLifecycleStateRunning, map[string]string{tagPool: "2"}),
+		instance("ocid1.instance.oc1..e", "web", core.InstanceLifecycleStateRunning, nil),
+	}
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	client := mocks.NewMockClient(t)
+	client.On("ListInstances", mock.Anything, "ocid1.compartment.oc1..c").Return(instances(), nil).Once()
+
+	p := newTestProvider(client)
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-a", "pool-1-agent-b"}, names)
+}
+
+func TestRemoveAgent(t *testing.T) {
+	t.Run("TerminatedWithBootVolume", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("ListInstances", mock.Anything, "ocid1.compartment.oc1..c").Return(instances(), nil).Once()
+		client.On("TerminateInstance", mock.Anything, "ocid1.instance.oc1..b", false).Return(nil).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-b"}))
+	})
+
+	t.Run("AlreadyGone", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("ListInstances", mock.Anything, "ocid1.compartment.oc1..c").Return(instances(), nil).Once()
+		client.On("TerminateInstance", mock.Anything, "ocid1.instance.oc1..a", false).
+			Return(&serviceError{statusCode: http.StatusNotFound, code: "NotAuthorizedOrNotFound"}).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-a"}))
+	})
+
+	t.Run("OtherPoolUntouched", func(t *testing.T) {
+		client := mocks.NewMockClient(t)
+		client.On("ListInstances", mock.Anything, "ocid1.compartment.oc1..c").Return(instances(), nil).Once()
+
+		p := newTestProvider(client)
+		assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-2-agent-d"}))
+	})
+}
+
+func TestVCPUs(t *testing.T) {
+	assert.Equal(t, 4, vcpus("VM.Standard.A1.Flex", 4))
+	assert.Equal(t, 8, vcpus("VM.Standard.E4.Flex", 4))
+	assert.Equal(t, "arm64", arch("VM.Standard.A2.Flex"))
+	assert.Equal(t, "amd64", arch("VM.Standard3.Flex"))
+}
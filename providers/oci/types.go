@@ -0,0 +1,11 @@
+package oci
+
+import (
+	"errors"
+)
+
+var (
+	ErrMissingSetting   = errors.New("missing setting")
+	ErrInvalidAuth      = errors.New("invalid auth, use api-key or instance-principal")
+	ErrIllegalTagPrefix = errors.New("illegal tag prefix")
+)
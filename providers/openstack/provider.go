@@ -14,11 +14,16 @@ import (
 	"github.com/urfave/cli/v3"
 
 	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
 )
 
+// UserDataLimit is the maximum size of server user data in bytes, nova
+// accepts 65535 bytes after base64 encoding.
+const UserDataLimit = 65535 / 4 * 3
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://docs.openstack.org/nova/latest/user/metadata.html
 // IPv6 metadata (fe80::a9fe:a9fe) is deployment-specific and requires an interface
@@ -112,11 +117,17 @@ func New(ctx context.Context, c *cli.Command, cfg *config.Config) (types.Provide
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: p.flavorName,
+			Region:       p.region,
+			Workflows:    p.config.Workflows(p.flavorName, 0, 0),
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
 	}
 
 	var networks []servers.Network
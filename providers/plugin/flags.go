@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+const category = "Plugin"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "plugin-path",
+			Usage:    "path of the plugin executable",
+			Sources:  cli.EnvVars("WOODPECKER_PLUGIN_PATH"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "plugin-args",
+			Usage:    "arguments the plugin is started with",
+			Sources:  cli.EnvVars("WOODPECKER_PLUGIN_ARGS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "plugin-config",
+			Usage:    "config passed to the plugin as list of key=value pairs, values may be file:, env: or exec: secret references",
+			Sources:  cli.EnvVars("WOODPECKER_PLUGIN_CONFIG"),
+			Category: category,
+		},
+		&cli.DurationFlag{
+			Name:     "plugin-timeout",
+			Value:    5 * time.Minute, //nolint:mnd
+			Usage:    "timeout of each call of the plugin",
+			Sources:  cli.EnvVars("WOODPECKER_PLUGIN_TIMEOUT"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,280 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// client calls the plugin, starting it on first use and again after it
+// exited.
+type client struct {
+	path    string
+	args    []string
+	timeout time.Duration
+	// initialize is sent to every started process
+	initialize *InitializeParams
+
+	mu      sync.Mutex
+	current *process
+}
+
+func newClient(path string, args []string, timeout time.Duration, initialize *InitializeParams) *client {
+	return &client{
+		path:       path,
+		args:       args,
+		timeout:    timeout,
+		initialize: initialize,
+	}
+}
+
+// call calls the method of the plugin and decodes the result into result,
+// if not nil. The call fails after the timeout.
+func (c *client) call(ctx context.Context, method string, params, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	proc, err := c.process(ctx)
+	if err != nil {
+		return err
+	}
+	return proc.call(ctx, method, params, result)
+}
+
+// process returns the running plugin process, it is started and initialized
+// if not running.
+func (c *client) process(ctx context.Context) (*process, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current != nil && !c.current.exited() {
+		return c.current, nil
+	}
+
+	proc, err := startProcess(c.path, c.args)
+	if err != nil {
+		return nil, err
+	}
+
+	var initialized InitializeResult
+	if err := proc.call(ctx, MethodInitialize, c.initialize, &initialized); err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("%s: %w", MethodInitialize, err)
+	}
+	if initialized.ProtocolVersion != ProtocolVersion {
+		proc.kill()
+		return nil, fmt.Errorf("%w: plugin speaks %d, expected %d", ErrProtocolVersion, initialized.ProtocolVersion, ProtocolVersion)
+	}
+
+	c.current = proc
+	return proc, nil
+}
+
+// process is a started plugin process.
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	// done is closed when the process has exited
+	done chan struct{}
+
+	// writeMu serializes the requests so lines do not interleave
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	nextID uint64
+	// pending is nil once the process has exited or was killed
+	pending map[uint64]chan *Response
+}
+
+// environ are the environment variables the plugin inherits, it must not see
+// the credentials of the autoscaler. Its settings are passed as config.
+var environ = []string{
+	"PATH", "HOME", "USER", "TMPDIR", "LANG", "TZ",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+}
+
+func startProcess(path string, args []string) (*process, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Env = []string{}
+	for _, key := range environ {
+		if value, ok := os.LookupEnv(key); ok {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	log.Debug().Msgf("plugin started: %s (pid %d)", path, cmd.Process.Pid)
+
+	p := &process{
+		cmd:     cmd,
+		stdin:   stdin,
+		done:    make(chan struct{}),
+		pending: map[uint64]chan *Response{},
+	}
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Info().Str("plugin", path).Msg(scanner.Text())
+		}
+	}()
+
+	go func() {
+		p.read(stdout)
+		// stderr has to be read until the end before waiting
+		<-logged
+		err := cmd.Wait()
+		log.Warn().Msgf("plugin exited: %s: %v", path, err)
+
+		p.mu.Lock()
+		close(p.done)
+		p.pending = nil
+		p.mu.Unlock()
+	}()
+
+	return p, nil
+}
+
+// read delivers the responses until stdout is closed.
+func (p *process) read(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var resp Response
+			if err := json.Unmarshal(line, &resp); err != nil {
+				log.Error().Err(err).Msgf("plugin: invalid response: %s", line)
+			} else {
+				p.deliver(&resp)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Error().Err(err).Msg("plugin: reading responses")
+			}
+			return
+		}
+	}
+}
+
+func (p *process) deliver(resp *Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// responses to calls which timed out are dropped
+	if ch, ok := p.pending[resp.ID]; ok {
+		delete(p.pending, resp.ID)
+		ch <- resp
+	}
+}
+
+func (p *process) exited() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending == nil
+}
+
+// kill kills the process, it counts as exited right away.
+func (p *process) kill() {
+	p.mu.Lock()
+	p.pending = nil
+	p.mu.Unlock()
+	_ = p.cmd.Process.Kill()
+}
+
+func (p *process) forget(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, id)
+}
+
+func (p *process) call(ctx context.Context, method string, params, result any) error {
+	ch := make(chan *Response, 1)
+
+	p.mu.Lock()
+	if p.pending == nil {
+		p.mu.Unlock()
+		return ErrPluginExited
+	}
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	data, err := json.Marshal(&Request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		p.forget(id)
+		return err
+	}
+
+	// writing blocks while the plugin does not read its stdin, so it is
+	// bounded by the context as well
+	written := make(chan error, 1)
+	go func() {
+		p.writeMu.Lock()
+		defer p.writeMu.Unlock()
+		_, err := p.stdin.Write(append(data, '\n'))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			p.forget(id)
+			return err
+		}
+	case <-ctx.Done():
+		// the plugin hangs, a fresh one is started on the next call
+		p.kill()
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+
+	var resp *Response
+	select {
+	case resp = <-ch:
+	case <-p.done:
+		// the response may have been delivered right before the exit
+		select {
+		case resp = <-ch:
+		default:
+			return ErrPluginExited
+		}
+	case <-ctx.Done():
+		// the plugin hangs, a fresh one is started on the next call
+		p.kill()
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
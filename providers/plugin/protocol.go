@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the version of the plugin protocol. It is increased on
+// incompatible changes, plugins reply to Initialize with the version they
+// speak.
+const ProtocolVersion = 1
+
+// Methods of the plugin protocol. Plugins are JSON-RPC 2.0 servers reading
+// one request per line from stdin and writing one response per line to
+// stdout. Initialize is called first after the plugin was started.
+const (
+	MethodInitialize             = "Initialize"
+	MethodBillingModel           = "BillingModel"
+	MethodDeployAgent            = "DeployAgent"
+	MethodRemoveAgent            = "RemoveAgent"
+	MethodListDeployedAgentNames = "ListDeployedAgentNames"
+)
+
+type Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is the error of a failed request.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+type InitializeParams struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	PoolID          string `json:"poolID"`
+	// Config is passed through from the plugin-config setting.
+	Config map[string]string `json:"config"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+type BillingModelResult struct {
+	// BillingModel is per-second or hourly-round-up.
+	BillingModel string `json:"billingModel"`
+}
+
+type Agent struct {
+	Name string `json:"name"`
+}
+
+type DeployAgentParams struct {
+	Agent Agent `json:"agent"`
+	// UserData is the rendered cloud-init user data of the agent.
+	UserData string `json:"userData"`
+}
+
+type RemoveAgentParams struct {
+	Agent Agent `json:"agent"`
+}
+
+type ListDeployedAgentNamesResult struct {
+	Names []string `json:"names"`
+}
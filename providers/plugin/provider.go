@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+type provider struct {
+	name         string
+	billingModel types.BillingModel
+	config       *config.Config
+	client       *client
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:   "plugin",
+		config: config,
+	}
+
+	path := c.String("plugin-path")
+	if path == "" {
+		return nil, fmt.Errorf("%s: %w: plugin-path", p.name, ErrMissingSetting)
+	}
+
+	pluginConfig, err := resolveConfig(ctx, c.StringSlice("plugin-config"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	p.client = newClient(path, c.StringSlice("plugin-args"), c.Duration("plugin-timeout"), &InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		PoolID:          config.PoolID,
+		Config:          pluginConfig,
+	})
+
+	// the billing model is queried once, it is needed without a context
+	billingModel, err := p.queryBillingModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.billingModel = billingModel
+
+	return p, nil
+}
+
+func (p *provider) queryBillingModel(ctx context.Context) (types.BillingModel, error) {
+	var result BillingModelResult
+	if err := p.client.call(ctx, MethodBillingModel, nil, &result); err != nil {
+		return 0, fmt.Errorf("%s: %w", MethodBillingModel, err)
+	}
+
+	for _, billingModel := range []types.BillingModel{types.BillingPerSecond, types.BillingHourlyRoundUp} {
+		if billingModel.String() == result.BillingModel {
+			return billingModel, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrBillingModel, result.BillingModel)
+}
+
+// resolveConfig parses the key=value pairs of the plugin config, each value
+// may reference a secret as the values of secret flags do.
+func resolveConfig(ctx context.Context, pairs []string) (map[string]string, error) {
+	pluginConfig, err := utils.SliceToMap(pairs, "=")
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range pluginConfig {
+		if pluginConfig[key], err = config.ResolveSecret(ctx, value); err != nil {
+			return nil, fmt.Errorf("plugin-config %s: %w", key, err)
+		}
+	}
+	return pluginConfig, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Workflows: p.config.Workflows("", 0, 0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+	}
+
+	params := &DeployAgentParams{
+		Agent:    Agent{Name: agent.Name},
+		UserData: userData,
+	}
+	if err := p.client.call(ctx, MethodDeployAgent, params, nil); err != nil {
+		return fmt.Errorf("%s: %s: %w", p.name, MethodDeployAgent, err)
+	}
+
+	return nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	params := &RemoveAgentParams{
+		Agent: Agent{Name: agent.Name},
+	}
+	if err := p.client.call(ctx, MethodRemoveAgent, params, nil); err != nil {
+		return fmt.Errorf("%s: %s: %w", p.name, MethodRemoveAgent, err)
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var result ListDeployedAgentNamesResult
+	if err := p.client.call(ctx, MethodListDeployedAgentNames, nil, &result); err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", p.name, MethodListDeployedAgentNames, err)
+	}
+
+	return result.Names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return p.billingModel
+}
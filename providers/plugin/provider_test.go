@@ -0,0 +1,231 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// fakePluginArg followed by the mode makes the test binary act as plugin, see
+// fakePlugin.
+const fakePluginArg = "-fake-plugin"
+
+func TestMain(m *testing.M) {
+	if len(os.Args) == 3 && os.Args[1] == fakePluginArg {
+		fakePlugin(os.Args[2])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakePlugin serves the protocol on stdin/stdout. Agents are kept in memory,
+// the agent named "crash" makes it exit and the agent named "hang" makes it
+// never respond. In the mode "env" it lists its environment variables as
+// agents.
+func fakePlugin(mode string) {
+	var (
+		agents  []string
+		config  map[string]string
+		version = ProtocolVersion
+	)
+	if mode == "unsupported" {
+		version = ProtocolVersion + 1
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		var (
+			result any
+			rpcErr *Error
+		)
+		switch req.Method {
+		case MethodInitialize:
+			var params InitializeParams
+			_ = json.Unmarshal(req.Params, &params)
+			config = params.Config
+			result = &InitializeResult{ProtocolVersion: version}
+		case MethodBillingModel:
+			result = &BillingModelResult{BillingModel: config["billing-model"]}
+		case MethodDeployAgent:
+			var params DeployAgentParams
+			_ = json.Unmarshal(req.Params, &params)
+			switch {
+			case params.Agent.Name == "hang":
+				continue
+			case !strings.Contains(params.UserData, "WOODPECKER_AGENT_SECRET=token"):
+				rpcErr = &Error{Code: 1, Message: "user data without token"}
+			default:
+				agents = append(agents, params.Agent.Name)
+			}
+		case MethodRemoveAgent:
+			var params RemoveAgentParams
+			_ = json.Unmarshal(req.Params, &params)
+			if params.Agent.Name == "crash" {
+				fmt.Fprintln(os.Stderr, "crashing")
+				os.Exit(1)
+			}
+			for i, name := range agents {
+				if name == params.Agent.Name {
+					agents = append(agents[:i], agents[i+1:]...)
+					break
+				}
+			}
+		case MethodListDeployedAgentNames:
+			if mode == "env" {
+				agents = os.Environ()
+			}
+			result = &ListDeployedAgentNamesResult{Names: agents}
+		default:
+			rpcErr = &Error{Code: -32601, Message: "method not found"}
+		}
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		data, _ := json.Marshal(resp)
+		fmt.Println(string(data))
+	}
+}
+
+func newTestProvider(t *testing.T, mode string, timeout time.Duration) *provider {
+	executable, err := os.Executable()
+	require.NoError(t, err)
+
+	p := &provider{
+		name: "plugin",
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: newClient(executable, []string{fakePluginArg, mode}, timeout, &InitializeParams{
+			ProtocolVersion: ProtocolVersion,
+			PoolID:          "1",
+			Config:          map[string]string{"billing-model": "hourly-round-up"},
+		}),
+	}
+	t.Cleanup(func() {
+		if p.client.current != nil {
+			p.client.current.kill()
+		}
+	})
+	return p
+}
+
+func TestAgents(t *testing.T) {
+	p := newTestProvider(t, "ok", time.Minute)
+
+	billingModel, err := p.queryBillingModel(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, types.BillingHourlyRoundUp, billingModel)
+
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-efgh", Token: "token"}))
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-abcd", "pool-1-agent-efgh"}, names)
+
+	require.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+
+	names, err = p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-efgh"}, names)
+}
+
+func TestPluginError(t *testing.T) {
+	p := newTestProvider(t, "ok", time.Minute)
+
+	err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, "user data without token", rpcErr.Message)
+}
+
+func TestTimeout(t *testing.T) {
+	p := newTestProvider(t, "ok", 500*time.Millisecond)
+
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+	hung := p.client.current
+
+	err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "hang", Token: "token"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the hanging plugin is killed and a fresh one is started
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+	assert.NotSame(t, hung, p.client.current)
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Setenv("WOODPECKER_TOKEN", "secret")
+	t.Setenv("PATH", "/usr/bin")
+	p := newTestProvider(t, "env", time.Minute)
+
+	env, err := p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Contains(t, env, "PATH=/usr/bin")
+	for _, variable := range env {
+		assert.False(t, strings.HasPrefix(variable, "WOODPECKER_"), variable)
+	}
+}
+
+func TestRestartOnCrash(t *testing.T) {
+	p := newTestProvider(t, "ok", time.Minute)
+
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+	crashed := p.client.current
+
+	err := p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "crash"})
+	assert.ErrorIs(t, err, ErrPluginExited)
+
+	// the plugin is restarted, its in memory agents are gone
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+	assert.NotSame(t, crashed, p.client.current)
+}
+
+func TestUnsupportedProtocolVersion(t *testing.T) {
+	p := newTestProvider(t, "unsupported", time.Minute)
+
+	_, err := p.ListDeployedAgentNames(t.Context())
+	assert.ErrorIs(t, err, ErrProtocolVersion)
+}
+
+func TestResolveConfig(t *testing.T) {
+	t.Setenv("TEST_PLUGIN_TOKEN", "secret")
+
+	pluginConfig, err := resolveConfig(t.Context(), []string{"region=fra1", "token=env:TEST_PLUGIN_TOKEN"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "fra1", "token": "secret"}, pluginConfig)
+
+	_, err = resolveConfig(t.Context(), []string{"token=env:TEST_PLUGIN_MISSING"})
+	assert.ErrorIs(t, err, config.ErrSecret)
+}
@@ -0,0 +1,12 @@
+package plugin
+
+import (
+	"errors"
+)
+
+var (
+	ErrMissingSetting  = errors.New("missing setting")
+	ErrProtocolVersion = errors.New("unsupported protocol version")
+	ErrPluginExited    = errors.New("plugin exited, it is restarted on the next call")
+	ErrBillingModel    = errors.New("unknown billing model")
+)
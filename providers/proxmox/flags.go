@@ -0,0 +1,131 @@
+package proxmox
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+const category = "Proxmox VE"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "proxmox-url",
+			Usage:    "url of the Proxmox VE API, e.g. https://pve.example.com:8006",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_URL"),
+			Category: category,
+		},
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "proxmox-token",
+			Usage:    "API token as USER@REALM!TOKENID=SECRET",
+			Sources:  config.SecretSources("WOODPECKER_PROXMOX_TOKEN"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "proxmox-ca-cert",
+			Usage:    "path to the ca certificate the API is verified with, for self-signed certificates",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_CA_CERT"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "proxmox-insecure-skip-verify",
+			Usage:    "do not verify the certificate of the API",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_INSECURE_SKIP_VERIFY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-template",
+			Usage:    "template VM the agents are cloned from as node/vmid, e.g. pve1/9000",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_TEMPLATE"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "proxmox-nodes",
+			Usage:    "nodes the agents are created on, the online node with the most free memory is chosen; defaults to the node of the template",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_NODES"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "proxmox-full-clone",
+			Usage:    "copy the disks of the template instead of linking them, required for nodes other than the template's without shared storage",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_FULL_CLONE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-storage",
+			Usage:    "storage of the disks of full clones, defaults to the storage of the template",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_STORAGE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-pool",
+			Usage:    "resource pool the agents are added to",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_POOL"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "proxmox-cores",
+			Usage:    "cpu cores of the agents, defaults to those of the template",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_CORES"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "proxmox-memory",
+			Usage:    "memory of the agents in MiB, defaults to that of the template",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_MEMORY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-ipconfig",
+			Value:    "ip=dhcp",
+			Usage:    "cloud-init network configuration of the first network interface",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_IPCONFIG"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-snippets-storage",
+			Value:    "local",
+			Usage:    "storage with the snippets content type the user data is stored in",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_SNIPPETS_STORAGE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-snippets-dir",
+			Value:    "/var/lib/vz/snippets",
+			Usage:    "snippets directory of the snippets storage, on the nodes or, without ssh user, mounted on the machine the autoscaler runs on",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_SNIPPETS_DIR"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-ssh-user",
+			Usage:    "user the snippets are written with over ssh to the nodes, if set",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_SSH_USER"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-ssh-key",
+			Usage:    "path to the private key of the ssh user",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_SSH_KEY"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "proxmox-ssh-known-hosts",
+			Usage:    "path to the known hosts file the nodes are verified with, defaults to ~/.ssh/known_hosts",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_SSH_KNOWN_HOSTS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "proxmox-node-addresses",
+			Usage:    "ssh addresses of the nodes as list of node=host[:port], defaults to the node names",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_NODE_ADDRESSES"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "proxmox-tags",
+			Usage:    "additional tags of the agent VMs",
+			Sources:  cli.EnvVars("WOODPECKER_PROXMOX_TAGS"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,370 @@
+package proxmox
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/proxmox/proxmoxapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Override because Proxmox tags are plain lowercase words
+const tagPrefix = "wp-autoscaler-"
+
+// invalidTagChars matches what is not allowed in tags.
+var invalidTagChars = regexp.MustCompile(`[^a-z0-9_+.-]`)
+
+type provider struct {
+	name            string
+	templateNode    string
+	templateID      int
+	nodes           []string
+	fullClone       bool
+	storage         string
+	pool            string
+	cores           int
+	memory          int64
+	arch            string
+	ipConfig        string
+	snippetsStorage string
+	snippets        snippetStore
+	tags            []string
+	config          *config.Config
+	client          proxmoxapi.Client
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:            "proxmox",
+		nodes:           c.StringSlice("proxmox-nodes"),
+		fullClone:       c.Bool("proxmox-full-clone"),
+		storage:         c.String("proxmox-storage"),
+		pool:            c.String("proxmox-pool"),
+		ipConfig:        c.String("proxmox-ipconfig"),
+		snippetsStorage: c.String("proxmox-snippets-storage"),
+		config:          config,
+	}
+
+	for _, flag := range []string{"proxmox-url", "proxmox-token", "proxmox-template"} {
+		if c.String(flag) == "" {
+			return nil, fmt.Errorf("%s: %w: %s", p.name, ErrMissingSetting, flag)
+		}
+	}
+
+	node, id, ok := strings.Cut(c.String("proxmox-template"), "/")
+	templateID, err := strconv.Atoi(id)
+	if !ok || node == "" || err != nil {
+		return nil, fmt.Errorf("%s: %w: %q", p.name, ErrInvalidTemplate, c.String("proxmox-template"))
+	}
+	p.templateNode, p.templateID = node, templateID
+	if len(p.nodes) == 0 {
+		p.nodes = []string{p.templateNode}
+	}
+
+	httpClient, err := newHTTPClient(c.String("proxmox-ca-cert"), c.Bool("proxmox-insecure-skip-verify"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.client = proxmoxapi.NewClient(httpClient, c.String("proxmox-url"), c.String("proxmox-token"))
+
+	if err := p.resolveTemplate(ctx, c.Int("proxmox-cores"), int64(c.Int("proxmox-memory"))); err != nil {
+		return nil, err
+	}
+
+	if p.snippets, err = newSnippetStore(c); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	userTags := c.StringSlice("proxmox-tags")
+	if err := utils.CheckReservedTags(userTags, tagPrefix, ErrIllegalTagPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.tags = append([]string{poolTag(config.PoolID)}, userTags...)
+
+	return p, nil
+}
+
+func newHTTPClient(caFile string, insecureSkipVerify bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+	}
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}}, nil
+}
+
+func newSnippetStore(c *cli.Command) (snippetStore, error) {
+	dir := c.String("proxmox-snippets-dir")
+	user := c.String("proxmox-ssh-user")
+	if user == "" {
+		return &dirStore{dir: dir}, nil
+	}
+
+	knownHosts := c.String("proxmox-ssh-known-hosts")
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	addresses, err := utils.SliceToMap(c.StringSlice("proxmox-node-addresses"), "=")
+	if err != nil {
+		return nil, err
+	}
+
+	return newSSHStore(dir, user, c.String("proxmox-ssh-key"), knownHosts, addresses)
+}
+
+// resolveTemplate checks the template and takes the resources of the agents
+// from it unless overridden.
+func (p *provider) resolveTemplate(ctx context.Context, cores int, memory int64) error {
+	template, err := p.client.GetVMConfig(ctx, p.templateNode, p.templateID)
+	if err != nil {
+		return fmt.Errorf("%s: GetVMConfig: %w", p.name, err)
+	}
+	if template.Template != 1 {
+		return fmt.Errorf("%s: %w: %s/%d", p.name, ErrNotTemplate, p.templateNode, p.templateID)
+	}
+
+	p.cores = cores
+	if p.cores == 0 {
+		p.cores = int(max(template.Cores, 1) * max(template.Sockets, 1))
+	}
+	p.memory = memory
+	if p.memory == 0 {
+		p.memory = int64(template.Memory)
+	}
+	p.arch = "amd64"
+	if template.Arch == "aarch64" {
+		p.arch = "arm64"
+	}
+
+	return nil
+}
+
+func poolTag(poolID string) string {
+	return tagPrefix + "pool-" + invalidTagChars.ReplaceAllString(strings.ToLower(poolID), "-")
+}
+
+// snippetName returns the name of the agent's user data snippet.
+func snippetName(agentName string) string {
+	return tagPrefix + agentName + ".yaml"
+}
+
+// selectNode returns the online candidate node with the most free memory.
+func (p *provider) selectNode(ctx context.Context) (string, error) {
+	nodes, err := p.client.ListNodes(ctx)
+	if err != nil {
+		return "", fmt.Errorf("ListNodes: %w", err)
+	}
+
+	selected := ""
+	var mostFree int64
+	for _, n := range nodes {
+		if n.Status != "online" || !slices.Contains(p.nodes, n.Node) {
+			continue
+		}
+		if free := n.MaxMem - n.Mem; selected == "" || free > mostFree {
+			selected, mostFree = n.Node, free
+		}
+	}
+
+	if selected == "" {
+		return "", ErrNoNodeOnline
+	}
+	return selected, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	node, err := p.selectNode(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Region:    node,
+			Arch:      p.arch,
+			Workflows: p.config.Workflows("", p.cores, p.memory),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+	}
+
+	vmid, err := p.client.NextID(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: NextID: %w", p.name, err)
+	}
+
+	log.Info().Msgf("create agent: node = %s vmid = %d", node, vmid)
+
+	snippet := snippetName(agent.Name)
+	if err := p.snippets.Write(ctx, node, snippet, []byte(userData)); err != nil {
+		return fmt.Errorf("%s: writing snippet: %w", p.name, err)
+	}
+
+	err = p.client.CloneVM(ctx, p.templateNode, p.templateID, &proxmoxapi.CloneOptions{
+		NewID:   vmid,
+		Name:    agent.Name,
+		Target:  node,
+		Full:    p.fullClone,
+		Storage: p.storage,
+		Pool:    p.pool,
+	})
+	if err != nil {
+		// a clone task failing late leaves the VM behind
+		err = fmt.Errorf("CloneVM: %w", err)
+		if cleanupErr := p.removeClone(ctx, agent.Name, node, vmid); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	if err := p.startVM(ctx, node, vmid, snippet); err != nil {
+		if cleanupErr := p.removeClone(ctx, agent.Name, node, vmid); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	return nil
+}
+
+// startVM configures the cloned VM to use the snippet as user data and starts
+// it.
+func (p *provider) startVM(ctx context.Context, node string, vmid int, snippet string) error {
+	err := p.client.SetVMConfig(ctx, node, vmid, map[string]string{
+		"cicustom":  fmt.Sprintf("user=%s:snippets/%s", p.snippetsStorage, snippet),
+		"ipconfig0": p.ipConfig,
+		"cores":     strconv.Itoa(p.cores),
+		"memory":    strconv.FormatInt(p.memory, 10),
+		"tags":      strings.Join(p.tags, ";"),
+	})
+	if err != nil {
+		return fmt.Errorf("SetVMConfig: %w", err)
+	}
+
+	if err := p.client.StartVM(ctx, node, vmid); err != nil {
+		return fmt.Errorf("StartVM: %w", err)
+	}
+
+	return nil
+}
+
+// removeClone removes the VM cloned for the agent after the deploy failed,
+// and its snippet. The VM is identified by the id it was cloned to and the
+// agent's name, as the id may have been taken by another client meanwhile
+// and the tags may not be set yet. A VM that is not the agent's is left in
+// place.
+func (p *provider) removeClone(ctx context.Context, agentName, node string, vmid int) error {
+	vm, err := p.client.GetVMConfig(ctx, node, vmid)
+	if err != nil && !isVMMissing(err) {
+		return fmt.Errorf("GetVMConfig: %w", err)
+	}
+	if err == nil && vm.Name != agentName {
+		err = fmt.Errorf("%w: VM %d on %s left in place", ErrNotAgentVM, vmid, node)
+		if cleanupErr := p.snippets.Remove(ctx, node, snippetName(agentName)); cleanupErr != nil {
+			err = errors.Join(err, fmt.Errorf("removing snippet: %w", cleanupErr))
+		}
+		return err
+	}
+
+	return p.removeVM(ctx, agentName, node, vmid)
+}
+
+// removeVM stops and destroys the VM, if it exists, and removes its snippet.
+func (p *provider) removeVM(ctx context.Context, agentName, node string, vmid int) error {
+	if err := p.client.StopVM(ctx, node, vmid); err != nil && !isVMMissing(err) {
+		return fmt.Errorf("StopVM: %w", err)
+	}
+	if err := p.client.DeleteVM(ctx, node, vmid); err != nil && !isVMMissing(err) {
+		return fmt.Errorf("DeleteVM: %w", err)
+	}
+
+	if err := p.snippets.Remove(ctx, node, snippetName(agentName)); err != nil {
+		return fmt.Errorf("removing snippet: %w", err)
+	}
+	return nil
+}
+
+// isVMMissing reports whether the error is caused by a missing VM, Proxmox
+// responds with 500 instead of 404 then.
+func isVMMissing(err error) bool {
+	var apiErr *proxmoxapi.Error
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "does not exist")
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	vms, err := p.client.ListVMs(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: ListVMs: %w", p.name, err)
+	}
+
+	for _, vm := range p.poolVMs(vms) {
+		if vm.Name != agent.Name {
+			continue
+		}
+		if err := p.removeVM(ctx, agent.Name, vm.Node, vm.VMID); err != nil {
+			return fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+// poolVMs returns the VMs tagged with the pool tag.
+func (p *provider) poolVMs(vms []*proxmoxapi.VM) []*proxmoxapi.VM {
+	tag := poolTag(p.config.PoolID)
+	var pool []*proxmoxapi.VM
+	for _, vm := range vms {
+		if vm.Type == "qemu" && vm.Template != 1 && vm.HasTag(tag) {
+			pool = append(pool, vm)
+		}
+	}
+	return pool
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	vms, err := p.client.ListVMs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ListVMs: %w", p.name, err)
+	}
+
+	var names []string
+	for _, vm := range p.poolVMs(vms) {
+		names = append(names, vm.Name)
+	}
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingPerSecond
+}
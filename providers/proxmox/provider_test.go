@@ -0,0 +1,348 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/proxmox/proxmoxapi"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// stubAPI is a minimal stateful stub of the Proxmox VE API.
+type stubAPI struct {
+	mu     sync.Mutex
+	nodes  []*proxmoxapi.Node
+	vms    map[int]*proxmoxapi.VM
+	config map[int]map[string]string
+	nextID int
+	// failClone, failConfig and failStart let cloning, after the VM was
+	// created, configuring and starting VMs fail
+	failClone, failConfig, failStart bool
+}
+
+func newStubAPI(t *testing.T) (*stubAPI, proxmoxapi.Client) {
+	t.Helper()
+	s := &stubAPI{
+		nodes: []*proxmoxapi.Node{
+			{Node: "pve1", Status: "online", Mem: 6 << 30, MaxMem: 8 << 30},
+			{Node: "pve2", Status: "online", Mem: 2 << 30, MaxMem: 8 << 30},
+			{Node: "pve3", Status: "offline", MaxMem: 64 << 30},
+		},
+		vms: map[int]*proxmoxapi.VM{
+			9000: {Type: "qemu", VMID: 9000, Node: "pve1", Name: "debian", Status: "stopped", Template: 1},
+		},
+		config: map[int]map[string]string{},
+		nextID: 100,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api2/json/nodes", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, s.nodes)
+	})
+	mux.HandleFunc("GET /api2/json/cluster/nextid", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, strconv.Itoa(s.nextID))
+	})
+	mux.HandleFunc("GET /api2/json/cluster/resources", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "vm", r.URL.Query().Get("type"))
+		vms := []*proxmoxapi.VM{{Type: "lxc", VMID: 50, Node: "pve1", Name: "pool-1-agent-lxc", Tags: poolTag("1")}}
+		for _, vm := range s.vms {
+			vms = append(vms, vm)
+		}
+		s.respond(w, vms)
+	})
+	mux.HandleFunc("GET /api2/json/nodes/{node}/qemu/{vmid}/config", func(w http.ResponseWriter, r *http.Request) {
+		vm, ok := s.vm(w, r)
+		if ok {
+			s.respond(w, map[string]any{"name": vm.Name, "cores": 2, "sockets": 1, "memory": "2048", "template": vm.Template, "tags": vm.Tags})
+		}
+	})
+	mux.HandleFunc("POST /api2/json/nodes/{node}/qemu/{vmid}/clone", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := s.vm(w, r); !ok {
+			return
+		}
+		newID, _ := strconv.Atoi(r.FormValue("newid"))
+		assert.Equal(t, "0", r.FormValue("full"))
+		if _, ok := s.vms[newID]; ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprintf(w, `{"data": null, "message": "unable to create VM %d: config file already exists\n"}`, newID)
+			return
+		}
+		s.vms[newID] = &proxmoxapi.VM{Type: "qemu", VMID: newID, Node: r.FormValue("target"), Name: r.FormValue("name"), Status: "stopped"}
+		s.nextID++
+		if s.failClone {
+			s.task(w, r.PathValue("node"), "failedclone")
+			return
+		}
+		s.task(w, r.PathValue("node"), "qmclone")
+	})
+	mux.HandleFunc("PUT /api2/json/nodes/{node}/qemu/{vmid}/config", func(w http.ResponseWriter, r *http.Request) {
+		vm, ok := s.vm(w, r)
+		if !ok {
+			return
+		}
+		if s.failConfig {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprint(w, `{"data": null, "message": "unable to parse value of 'cicustom'\n"}`)
+			return
+		}
+		assert.NoError(t, r.ParseForm())
+		s.config[vm.VMID] = map[string]string{}
+		for key := range r.PostForm {
+			s.config[vm.VMID][key] = r.PostForm.Get(key)
+		}
+		vm.Tags = r.PostForm.Get("tags")
+		s.respond(w, nil)
+	})
+	mux.HandleFunc("POST /api2/json/nodes/{node}/qemu/{vmid}/status/{action}", func(w http.ResponseWriter, r *http.Request) {
+		vm, ok := s.vm(w, r)
+		if !ok {
+			return
+		}
+		switch r.PathValue("action") {
+		case "start":
+			if s.failStart {
+				s.task(w, vm.Node, "failedstart")
+				return
+			}
+			vm.Status = "running"
+		case "stop":
+			vm.Status = "stopped"
+		}
+		s.task(w, vm.Node, "qm"+r.PathValue("action"))
+	})
+	mux.HandleFunc("DELETE /api2/json/nodes/{node}/qemu/{vmid}", func(w http.ResponseWriter, r *http.Request) {
+		vm, ok := s.vm(w, r)
+		if !ok {
+			return
+		}
+		assert.Equal(t, "1", r.URL.Query().Get("purge"))
+		delete(s.vms, vm.VMID)
+		s.task(w, vm.Node, "qmdestroy")
+	})
+	mux.HandleFunc("GET /api2/json/nodes/{node}/tasks/{upid}/status", func(w http.ResponseWriter, r *http.Request) {
+		status := "OK"
+		switch r.PathValue("upid") {
+		case fmt.Sprintf("UPID:%s:failedclone:", r.PathValue("node")):
+			status = "clone failed: storage 'local-lvm' is full"
+		case fmt.Sprintf("UPID:%s:failedstart:", r.PathValue("node")):
+			status = "start failed: QEMU exited with code 1"
+		}
+		s.respond(w, map[string]string{"status": "stopped", "exitstatus": status})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PVEAPIToken=autoscaler@pve!wp=secret", r.Header.Get("Authorization"))
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return s, proxmoxapi.NewClient(server.Client(), server.URL, "autoscaler@pve!wp=secret")
+}
+
+func (s *stubAPI) respond(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func (s *stubAPI) task(w http.ResponseWriter, node, name string) {
+	s.respond(w, fmt.Sprintf("UPID:%s:%s:", node, name))
+}
+
+// vm looks up the VM of the request, Proxmox responds with 500 if it does
+// not exist.
+func (s *stubAPI) vm(w http.ResponseWriter, r *http.Request) (*proxmoxapi.VM, bool) {
+	vmid, _ := strconv.Atoi(r.PathValue("vmid"))
+	vm, ok := s.vms[vmid]
+	if !ok || vm.Node != r.PathValue("node") {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, `{"data": null, "message": "Configuration file 'nodes/%s/qemu-server/%d.conf' does not exist\n"}`, r.PathValue("node"), vmid)
+		return nil, false
+	}
+	return vm, true
+}
+
+func newTestProvider(t *testing.T, client proxmoxapi.Client, snippetsDir string) *provider {
+	t.Helper()
+	p := &provider{
+		name:            "proxmox",
+		templateNode:    "pve1",
+		templateID:      9000,
+		nodes:           []string{"pve1", "pve2", "pve3"},
+		ipConfig:        "ip=dhcp",
+		snippetsStorage: "local",
+		snippets:        &dirStore{dir: snippetsDir},
+		tags:            []string{poolTag("1"), "ci"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: client,
+	}
+	assert.NoError(t, p.resolveTemplate(t.Context(), 0, 0))
+	return p
+}
+
+func TestResolveTemplate(t *testing.T) {
+	stub, client := newStubAPI(t)
+	dir := t.TempDir()
+	p := newTestProvider(t, client, dir)
+	assert.Equal(t, 2, p.cores)
+	assert.Equal(t, int64(2048), p.memory)
+	assert.Equal(t, "amd64", p.arch)
+
+	stub.vms[100] = &proxmoxapi.VM{Type: "qemu", VMID: 100, Node: "pve1", Name: "not-a-template"}
+	p.templateID = 100
+	assert.ErrorIs(t, p.resolveTemplate(t.Context(), 4, 4096), ErrNotTemplate)
+}
+
+func TestPoolTag(t *testing.T) {
+	assert.Equal(t, "wp-autoscaler-pool-1", poolTag("1"))
+	assert.Equal(t, "wp-autoscaler-pool-ci-arm64_x", poolTag("CI Arm64_x"))
+}
+
+func TestDeployAgent(t *testing.T) {
+	t.Run("MostFreeNode", func(t *testing.T) {
+		stub, client := newStubAPI(t)
+		dir := t.TempDir()
+		p := newTestProvider(t, client, dir)
+
+		agent := &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}
+		assert.NoError(t, p.DeployAgent(t.Context(), agent))
+
+		vm := stub.vms[100]
+		if assert.NotNil(t, vm) {
+			assert.Equal(t, "pve2", vm.Node)
+			assert.Equal(t, "pool-1-agent-abcd", vm.Name)
+			assert.Equal(t, "running", vm.Status)
+		}
+		assert.Equal(t, map[string]string{
+			"cicustom":  "user=local:snippets/wp-autoscaler-pool-1-agent-abcd.yaml",
+			"ipconfig0": "ip=dhcp",
+			"cores":     "2",
+			"memory":    "2048",
+			"tags":      "wp-autoscaler-pool-1;ci",
+		}, stub.config[100])
+
+		userData, err := os.ReadFile(filepath.Join(dir, "wp-autoscaler-pool-1-agent-abcd.yaml"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(userData), "#cloud-config")
+		assert.Contains(t, string(userData), "autoscaler.region=pve2")
+	})
+
+	t.Run("NoNodeOnline", func(t *testing.T) {
+		_, client := newStubAPI(t)
+		dir := t.TempDir()
+		p := newTestProvider(t, client, dir)
+		p.nodes = []string{"pve3"}
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorIs(t, err, ErrNoNodeOnline)
+	})
+
+	t.Run("RemovedIfNotStarted", func(t *testing.T) {
+		stub, client := newStubAPI(t)
+		stub.failStart = true
+		dir := t.TempDir()
+		p := newTestProvider(t, client, dir)
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "QEMU exited with code 1")
+		assert.NotContains(t, stub.vms, 100)
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("RemovedIfNotConfigured", func(t *testing.T) {
+		stub, client := newStubAPI(t)
+		stub.failConfig = true
+		dir := t.TempDir()
+		p := newTestProvider(t, client, dir)
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "SetVMConfig")
+		assert.NotContains(t, stub.vms, 100)
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("RemovedIfCloneFailed", func(t *testing.T) {
+		stub, client := newStubAPI(t)
+		stub.failClone = true
+		dir := t.TempDir()
+		p := newTestProvider(t, client, dir)
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "storage 'local-lvm' is full")
+		assert.NotContains(t, stub.vms, 100)
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("IDTakenMeanwhile", func(t *testing.T) {
+		stub, client := newStubAPI(t)
+		// another client created a VM with the next id after it was fetched
+		stub.vms[100] = &proxmoxapi.VM{Type: "qemu", VMID: 100, Node: "pve2", Name: "web", Status: "running"}
+		dir := t.TempDir()
+		p := newTestProvider(t, client, dir)
+
+		err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"})
+		assert.ErrorContains(t, err, "already exists")
+		if assert.Contains(t, stub.vms, 100) {
+			assert.Equal(t, "running", stub.vms[100].Status)
+		}
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestRemoveAgent(t *testing.T) {
+	stub, client := newStubAPI(t)
+	dir := t.TempDir()
+	p := newTestProvider(t, client, dir)
+	stub.vms[100] = &proxmoxapi.VM{Type: "qemu", VMID: 100, Node: "pve2", Name: "pool-1-agent-abcd", Status: "running", Tags: "ci;" + poolTag("1")}
+	// same name in another pool
+	stub.vms[101] = &proxmoxapi.VM{Type: "qemu", VMID: 101, Node: "pve2", Name: "pool-1-agent-abcd", Status: "running", Tags: poolTag("2")}
+	assert.NoError(t, p.snippets.Write(t.Context(), "pve2", snippetName("pool-1-agent-abcd"), []byte("#cloud-config")))
+
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	assert.NotContains(t, stub.vms, 100)
+	assert.Contains(t, stub.vms, 101)
+	assert.NoFileExists(t, filepath.Join(dir, snippetName("pool-1-agent-abcd")))
+
+	// already removed
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	stub, client := newStubAPI(t)
+	dir := t.TempDir()
+	p := newTestProvider(t, client, dir)
+	stub.vms[100] = &proxmoxapi.VM{Type: "qemu", VMID: 100, Node: "pve1", Name: "pool-1-agent-a", Tags: poolTag("1")}
+	stub.vms[101] = &proxmoxapi.VM{Type: "qemu", VMID: 101, Node: "pve2", Name: "pool-2-agent-b", Tags: poolTag("2")}
+	stub.vms[102] = &proxmoxapi.VM{Type: "qemu", VMID: 102, Node: "pve2", Name: "template", Tags: poolTag("1"), Template: 1}
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-a"}, names)
+}
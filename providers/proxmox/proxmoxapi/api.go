@@ -0,0 +1,118 @@
+package proxmoxapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Client is the subset of the Proxmox VE API the proxmox provider uses.
+// Operations running as task wait until the task has finished.
+type Client interface {
+	ListNodes(ctx context.Context) ([]*Node, error)
+	NextID(ctx context.Context) (int, error)
+	GetVMConfig(ctx context.Context, node string, vmid int) (*VMConfig, error)
+	CloneVM(ctx context.Context, node string, vmid int, options *CloneOptions) error
+	// SetVMConfig sets the options of the VM, e.g. cicustom or tags.
+	SetVMConfig(ctx context.Context, node string, vmid int, options map[string]string) error
+	StartVM(ctx context.Context, node string, vmid int) error
+	StopVM(ctx context.Context, node string, vmid int) error
+	// DeleteVM destroys the VM with all its disks.
+	DeleteVM(ctx context.Context, node string, vmid int) error
+	// ListVMs lists the VMs of all nodes of the cluster.
+	ListVMs(ctx context.Context) ([]*VM, error)
+}
+
+type Node struct {
+	Node   string  `json:"node"`
+	Status string  `json:"status"`
+	CPU    float64 `json:"cpu"`
+	MaxCPU int     `json:"maxcpu"`
+	Mem    int64   `json:"mem"`
+	MaxMem int64   `json:"maxmem"`
+}
+
+// VMConfig is the configuration of a VM, memory in MiB.
+type VMConfig struct {
+	Name     string  `json:"name"`
+	Cores    Integer `json:"cores"`
+	Sockets  Integer `json:"sockets"`
+	Memory   Integer `json:"memory"`
+	Template Integer `json:"template"`
+	Arch     string  `json:"arch"`
+}
+
+type CloneOptions struct {
+	NewID int
+	Name  string
+	// Target is the node the clone is created on.
+	Target string
+	// Full copies the disks instead of linking them to the template.
+	Full bool
+	// Storage of the disks of a full clone, the template's if empty.
+	Storage string
+	// Pool is the resource pool the clone is added to.
+	Pool string
+}
+
+type VM struct {
+	// Type is qemu for VMs and lxc for containers.
+	Type     string  `json:"type"`
+	VMID     int     `json:"vmid"`
+	Node     string  `json:"node"`
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Tags     string  `json:"tags"`
+	Template Integer `json:"template"`
+}
+
+// HasTag reports whether the VM has the tag, tags are separated by
+// semicolons.
+func (vm *VM) HasTag(tag string) bool {
+	return slices.Contains(strings.Split(vm.Tags, ";"), tag)
+}
+
+// Integer is an integer the API returns as number or string, optionally
+// prefixed with current= as memory is.
+type Integer int64
+
+func (i *Integer) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*i = 0
+		return nil
+	}
+	s = strings.TrimPrefix(s, "current=")
+	if before, _, ok := strings.Cut(s, ","); ok {
+		s = before
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", data, err)
+	}
+	*i = Integer(n)
+	return nil
+}
+
+// Error is an error response of the API or the exit status of a failed task.
+type Error struct {
+	// StatusCode is the HTTP status code, 0 for a failed task.
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode == 0 {
+		return "proxmox: task failed: " + e.Message
+	}
+	return fmt.Sprintf("proxmox: error %d: %s", e.StatusCode, e.Message)
+}
+
+// IsError reports whether err is an Error with one of the status codes.
+func IsError(err error, statusCodes ...int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && slices.Contains(statusCodes, apiErr.StatusCode)
+}
@@ -0,0 +1,211 @@
+package proxmoxapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+// taskPollInterval is how often the status of a running task is checked.
+const taskPollInterval = time.Second
+
+type client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+}
+
+// NewClient creates a client for the API at baseURL, e.g.
+// https://pve.example.com:8006, authenticating with the API token given as
+// USER@REALM!TOKENID=SECRET.
+func NewClient(httpClient *http.Client, baseURL, token string) Client {
+	return &client{
+		http:    httpClient,
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/api2/json",
+		token:   token,
+	}
+}
+
+// do sends the request with the form values and decodes the data of the
+// response into out, if not nil.
+func (c *client) do(ctx context.Context, method, path string, form url.Values, out any) error {
+	rawURL := c.baseURL + path
+	var body *strings.Reader
+	if method == http.MethodGet || method == http.MethodDelete {
+		if len(form) > 0 {
+			rawURL += "?" + form.Encode()
+		}
+		body = strings.NewReader("")
+	} else {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "woodpecker-autoscaler/"+version.String())
+	req.Header.Set("Authorization", "PVEAPIToken="+c.token)
+	if body.Len() > 0 {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		// the reason phrase carries the message, parameter errors are in
+		// the body
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))}
+		var errResp struct {
+			Message string            `json:"message"`
+			Errors  map[string]string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil {
+			if errResp.Message != "" {
+				apiErr.Message = strings.TrimSpace(errResp.Message)
+			}
+			for param, message := range errResp.Errors {
+				apiErr.Message += fmt.Sprintf("; %s: %s", param, strings.TrimSpace(message))
+			}
+		}
+		return apiErr
+	}
+
+	var data struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data.Data, out)
+}
+
+// task sends the request starting a task and waits until it has finished.
+func (c *client) task(ctx context.Context, method, path string, form url.Values) error {
+	var upid string
+	if err := c.do(ctx, method, path, form, &upid); err != nil {
+		return err
+	}
+
+	// UPID:node:pid:pstart:starttime:type:id:user:
+	fields := strings.Split(upid, ":")
+	if len(fields) < 2 { //nolint:mnd
+		return fmt.Errorf("proxmox: invalid task id %q", upid)
+	}
+	statusPath := fmt.Sprintf("/nodes/%s/tasks/%s/status", fields[1], url.PathEscape(upid))
+
+	for {
+		var status struct {
+			Status     string `json:"status"`
+			ExitStatus string `json:"exitstatus"`
+		}
+		if err := c.do(ctx, http.MethodGet, statusPath, nil, &status); err != nil {
+			return err
+		}
+		if status.Status == "stopped" {
+			if status.ExitStatus != "OK" {
+				return &Error{Message: status.ExitStatus}
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+func vmPath(node string, vmid int) string {
+	return fmt.Sprintf("/nodes/%s/qemu/%d", node, vmid)
+}
+
+func (c *client) ListNodes(ctx context.Context) ([]*Node, error) {
+	var nodes []*Node
+	if err := c.do(ctx, http.MethodGet, "/nodes", nil, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (c *client) NextID(ctx context.Context) (int, error) {
+	var id Integer
+	if err := c.do(ctx, http.MethodGet, "/cluster/nextid", nil, &id); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (c *client) GetVMConfig(ctx context.Context, node string, vmid int) (*VMConfig, error) {
+	var config VMConfig
+	if err := c.do(ctx, http.MethodGet, vmPath(node, vmid)+"/config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c *client) CloneVM(ctx context.Context, node string, vmid int, options *CloneOptions) error {
+	form := url.Values{
+		"newid": {strconv.Itoa(options.NewID)},
+		"name":  {options.Name},
+	}
+	if options.Target != "" {
+		form.Set("target", options.Target)
+	}
+	if options.Full {
+		form.Set("full", "1")
+		if options.Storage != "" {
+			form.Set("storage", options.Storage)
+		}
+	} else {
+		form.Set("full", "0")
+	}
+	if options.Pool != "" {
+		form.Set("pool", options.Pool)
+	}
+	return c.task(ctx, http.MethodPost, vmPath(node, vmid)+"/clone", form)
+}
+
+func (c *client) SetVMConfig(ctx context.Context, node string, vmid int, options map[string]string) error {
+	form := url.Values{}
+	for key, value := range options {
+		form.Set(key, value)
+	}
+	return c.do(ctx, http.MethodPut, vmPath(node, vmid)+"/config", form, nil)
+}
+
+func (c *client) StartVM(ctx context.Context, node string, vmid int) error {
+	return c.task(ctx, http.MethodPost, vmPath(node, vmid)+"/status/start", nil)
+}
+
+func (c *client) StopVM(ctx context.Context, node string, vmid int) error {
+	return c.task(ctx, http.MethodPost, vmPath(node, vmid)+"/status/stop", nil)
+}
+
+func (c *client) DeleteVM(ctx context.Context, node string, vmid int) error {
+	form := url.Values{"purge": {"1"}, "destroy-unreferenced-disks": {"1"}}
+	return c.task(ctx, http.MethodDelete, vmPath(node, vmid), form)
+}
+
+func (c *client) ListVMs(ctx context.Context) ([]*VM, error) {
+	var vms []*VM
+	if err := c.do(ctx, http.MethodGet, "/cluster/resources", url.Values{"type": {"vm"}}, &vms); err != nil {
+		return nil, err
+	}
+	return vms, nil
+}
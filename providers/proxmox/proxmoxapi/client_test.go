@@ -0,0 +1,64 @@
+package proxmoxapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/providers/proxmox/proxmoxapi"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) proxmoxapi.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return proxmoxapi.NewClient(server.Client(), server.URL+"/", "root@pam!test=secret")
+}
+
+func TestInteger(t *testing.T) {
+	var config proxmoxapi.VMConfig
+	assert.NoError(t, json.Unmarshal([]byte(`{"cores": 4, "sockets": "2", "memory": "current=4096,min=1024"}`), &config))
+	assert.Equal(t, proxmoxapi.Integer(4), config.Cores)
+	assert.Equal(t, proxmoxapi.Integer(2), config.Sockets)
+	assert.Equal(t, proxmoxapi.Integer(4096), config.Memory)
+	assert.Equal(t, proxmoxapi.Integer(0), config.Template)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"cores": "many"}`), &config))
+}
+
+func TestTaskFailed(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api2/json/nodes/pve1/qemu/9000/clone":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "PVEAPIToken=root@pam!test=secret", r.Header.Get("Authorization"))
+			assert.Equal(t, "100", r.FormValue("newid"))
+			assert.Equal(t, "1", r.FormValue("full"))
+			assert.Equal(t, "fast", r.FormValue("storage"))
+			_, _ = w.Write([]byte(`{"data": "UPID:pve1:0000C0DE:00A1B2C3:65F0A1B2:qmclone:9000:root@pam!test:"}`))
+		case "/api2/json/nodes/pve1/tasks/UPID:pve1:0000C0DE:00A1B2C3:65F0A1B2:qmclone:9000:root@pam!test:/status":
+			_, _ = w.Write([]byte(`{"data": {"status": "stopped", "exitstatus": "storage 'fast' does not exist"}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	err := client.CloneVM(t.Context(), "pve1", 9000, &proxmoxapi.CloneOptions{NewID: 100, Name: "agent", Full: true, Storage: "fast"})
+	assert.True(t, proxmoxapi.IsError(err, 0))
+	assert.ErrorContains(t, err, "storage 'fast' does not exist")
+}
+
+func TestError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"data": null, "errors": {"tags": "invalid format\n"}}`))
+	})
+
+	err := client.SetVMConfig(t.Context(), "pve1", 100, map[string]string{"tags": "Not Valid"})
+	assert.True(t, proxmoxapi.IsError(err, http.StatusBadRequest))
+	assert.ErrorContains(t, err, "tags: invalid format")
+}
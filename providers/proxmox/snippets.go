@@ -0,0 +1,122 @@
+package proxmox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// snippetStore writes the user data snippets into the snippets directory of
+// the nodes, as the Proxmox API can not upload snippets.
+type snippetStore interface {
+	Write(ctx context.Context, node, name string, data []byte) error
+	Remove(ctx context.Context, node, name string) error
+}
+
+// dirStore writes snippets into a directory of the machine the autoscaler
+// runs on, a node itself or a mount of shared storage.
+type dirStore struct {
+	dir string
+}
+
+func (s *dirStore) Write(_ context.Context, _, name string, data []byte) error {
+	return os.WriteFile(filepath.Join(s.dir, name), data, 0o600)
+}
+
+func (s *dirStore) Remove(_ context.Context, _, name string) error {
+	err := os.Remove(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// sshStore writes snippets into the directory of the node over ssh.
+type sshStore struct {
+	dir    string
+	config *ssh.ClientConfig
+	// addresses of the nodes by name, the node name is used if missing
+	addresses map[string]string
+}
+
+func newSSHStore(dir, user, keyFile, knownHostsFile string, addresses map[string]string) (*sshStore, error) {
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("ssh key: %w", err)
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("known hosts: %w", err)
+	}
+
+	return &sshStore{
+		dir: dir,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+		},
+		addresses: addresses,
+	}, nil
+}
+
+// run runs the command on the node with stdin.
+func (s *sshStore) run(ctx context.Context, node, command string, stdin []byte) error {
+	address := node
+	if a, ok := s.addresses[node]; ok {
+		address = a
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, s.config)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stdin = bytes.NewReader(stdin)
+	session.Stderr = &stderr
+	if err := session.Run(command); err != nil {
+		return fmt.Errorf("%s: %w: %s", node, err, stderr.String())
+	}
+	return nil
+}
+
+// snippet names are generated from agent names, they need no quoting beyond
+// the directory
+func (s *sshStore) Write(ctx context.Context, node, name string, data []byte) error {
+	return s.run(ctx, node, fmt.Sprintf("umask 077 && cat > '%s'", path.Join(s.dir, name)), data)
+}
+
+func (s *sshStore) Remove(ctx context.Context, node, name string) error {
+	return s.run(ctx, node, fmt.Sprintf("rm -f '%s'", path.Join(s.dir, name)), nil)
+}
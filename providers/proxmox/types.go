@@ -0,0 +1,12 @@
+package proxmox
+
+import "errors"
+
+var (
+	ErrIllegalTagPrefix = errors.New("illegal tag prefix")
+	ErrMissingSetting   = errors.New("missing setting")
+	ErrInvalidTemplate  = errors.New("template must be given as node/vmid")
+	ErrNotTemplate      = errors.New("vm is not a template")
+	ErrNoNodeOnline     = errors.New("no candidate node is online")
+	ErrNotAgentVM       = errors.New("vm is not the agent's")
+)
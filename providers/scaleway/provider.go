@@ -15,12 +15,19 @@ import (
 
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/utils"
 	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
 )
 
+// UserDataLimit is the maximum size of the cloud-init user data key in bytes.
+const UserDataLimit = 1 << 20
+
+// server types report their memory in bytes
+const bytesPerMiB = 1 << 20
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://www.scaleway.com/en/developers/api/instance/
 var blackholeMetadataAPI = []string{
@@ -148,7 +155,7 @@ func (p *provider) createAndBoot(ctx context.Context, agent *woodpecker.Agent, c
 	if err != nil {
 		return err
 	}
-	if err := p.setCloudInit(ctx, agent, inst); err != nil {
+	if err := p.setCloudInit(ctx, agent, inst, c); err != nil {
 		return err
 	}
 	log.Info().Str("type", c.rawType).Str("zone", c.zone.String()).
@@ -182,9 +189,16 @@ func (p *provider) createInstance(ctx context.Context, agent *woodpecker.Agent,
 	return res.Server, nil
 }
 
-func (p *provider) setCloudInit(ctx context.Context, agent *woodpecker.Agent, inst *instance.Server) error {
-	ud, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
+func (p *provider) setCloudInit(ctx context.Context, agent *woodpecker.Agent, inst *instance.Server, c deployCandidate) error {
+	ud, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: "scaleway",
+		Candidate: cloudinit.Candidate{
+			InstanceType: c.rawType,
+			Region:       c.zone.String(),
+			Arch:         cloudinit.NormalizeArch(string(c.serverType.Arch)),
+			Workflows:    p.config.Workflows(c.rawType, int(c.serverType.Ncpus), int64(c.serverType.RAM/bytesPerMiB)),
+		},
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,79 @@
+package upcloud
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+const category = "UpCloud"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		// upcloud
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "upcloud-token",
+			Usage:    "upcloud api token, used instead of username and password",
+			Sources:  config.SecretSources("WOODPECKER_UPCLOUD_TOKEN"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "upcloud-username",
+			Usage:    "upcloud api username",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_USERNAME"),
+			Category: category,
+		},
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "upcloud-password",
+			Usage:    "upcloud api password",
+			Sources:  config.SecretSources("WOODPECKER_UPCLOUD_PASSWORD"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "upcloud-zone",
+			Usage:    "upcloud zone",
+			Value:    "fi-hel1",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_ZONE"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "upcloud-plan",
+			Usage:    "upcloud plan",
+			Value:    "1xCPU-2GB",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_PLAN"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "upcloud-template",
+			Usage:    "upcloud template title or uuid",
+			Value:    "Ubuntu Server 24.04 LTS (Noble Numbat)",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_TEMPLATE"),
+			Category: category,
+		},
+		&cli.IntFlag{
+			Name:     "upcloud-storage-size",
+			Usage:    "storage size of the servers in GB, the storage size of the plan if 0",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_STORAGE_SIZE"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "upcloud-ssh-keys",
+			Usage:    "public ssh keys authorized on the servers",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_SSH_KEYS"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "upcloud-labels",
+			Usage:    "upcloud server labels",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_LABELS"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "upcloud-public-ipv6-enable",
+			Value:    true,
+			Usage:    "enables public ipv6 network for agents",
+			Sources:  cli.EnvVars("WOODPECKER_UPCLOUD_PUBLIC_IPV6_ENABLE"),
+			Category: category,
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package upcloud
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+)
+
+func (p *provider) resolveZone(ctx context.Context, zone string) error {
+	zones, err := p.client.ListZones(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch zones: %w", err)
+	}
+	var match *upcloud.Zone
+	for _, z := range zones {
+		if zone == z.ID {
+			// we have an exact match
+			p.zone = &z
+			return nil
+		}
+		if match == nil && strings.HasPrefix(strings.ToLower(z.Description), strings.ToLower(zone)) {
+			match = &z
+		}
+	}
+	if match != nil {
+		log.Info().Msgf("update zone to %q based on description match", match.ID)
+		p.zone = match
+		return nil
+	}
+	return ErrInvalidZone
+}
+
+func (p *provider) resolvePlan(ctx context.Context, plan string) error {
+	plans, err := p.client.ListPlans(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch plans: %w", err)
+	}
+	for _, pl := range plans {
+		if pl.Name == plan {
+			p.plan = &pl
+			return nil
+		}
+	}
+
+	return ErrInvalidPlan
+}
+
+func (p *provider) resolveTemplate(ctx context.Context, template string) error {
+	templates, err := p.client.ListTemplates(ctx)
+	if err != nil {
+		return fmt.Errorf("could not fetch templates: %w", err)
+	}
+	var matches []upcloud.Storage
+	want := strings.ReplaceAll(strings.ToLower(template), " ", "")
+	for _, t := range templates {
+		if t.UUID == template {
+			p.template = &t
+			return nil
+		}
+		got := strings.ReplaceAll(strings.ToLower(t.Title), " ", "")
+		if strings.HasPrefix(got, want) {
+			matches = append(matches, t)
+			log.Trace().Msgf("resolve template got match: %q", t.Title)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return ErrInvalidTemplate
+	case 1:
+		p.template = &matches[0]
+	default:
+		// we first sort matches
+		slices.SortFunc(matches, func(a, b upcloud.Storage) int { return strings.Compare(a.Title, b.Title) })
+		p.template = &matches[0]
+		log.Info().Msgf("template selector had %d matches, choose %q", len(matches), matches[0].Title)
+	}
+	return nil
+}
+
+func (p *provider) printResolvedConfig() {
+	log.Info().
+		Str("id", p.zone.ID).
+		Str("description", p.zone.Description).
+		Msg("deploy zone")
+
+	log.Info().
+		Str("name", p.plan.Name).
+		Int("cpu_count", p.plan.CoreNumber).
+		Int("ram", p.plan.MemoryAmount).
+		Int("storage", p.storageSize).
+		Str("storage_tier", p.plan.StorageTier).
+		Msg("deploy with plan")
+
+	log.Info().
+		Str("title", p.template.Title).
+		Str("access", p.template.Access).
+		Msg("deploy with template")
+}
+
+// setupKeyPair checks the public keys, upcloud has no ssh keys of the account
+// so they are passed to every server.
+func (p *provider) setupKeyPair(keys []string) error {
+	for _, key := range keys {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSSHKey, err)
+		}
+		p.sshKeys = append(p.sshKeys, strings.TrimSpace(key))
+	}
+
+	if len(p.sshKeys) == 0 {
+		log.Info().Msg("no ssh keys set, agents can not be logged in to")
+	}
+	return nil
+}
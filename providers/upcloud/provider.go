@@ -0,0 +1,223 @@
+package upcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud/request"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/autoscaler/providers/upcloud/upcloudapi"
+	"go.woodpecker-ci.org/autoscaler/utils"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+var (
+	ErrIllegalLabelPrefix = errors.New("illegal label prefix")
+	ErrMissingSetting     = errors.New("missing setting")
+	ErrInvalidSSHKey      = errors.New("invalid SSH key")
+	ErrInvalidZone        = errors.New("no valid zone set")
+	ErrInvalidPlan        = errors.New("no valid plan set")
+	ErrInvalidTemplate    = errors.New("no valid template set")
+)
+
+// UserDataLimit is the maximum size of server user data in bytes.
+const UserDataLimit = 64 << 10
+
+// Override because UpCloud label keys are plain words of letters, digits,
+// dashes and underscores
+const (
+	labelPrefix = "wp-autoscaler-"
+	labelPool   = labelPrefix + "pool"
+	labelImage  = labelPrefix + "image"
+)
+
+// blackhole metadata services so running steps can not extract agent token from user-data
+// (the metadata service of upcloud servers is at 169.254.169.254)
+var blackholeMetadataAPI = []string{
+	"ip -4 route add blackhole 169.254.169.254/32",
+}
+
+type provider struct {
+	sshKeys     []string
+	labels      map[string]string
+	config      *config.Config
+	enableIPv6  bool
+	storageSize int
+	name        string
+	client      upcloudapi.Client
+	// resolved config
+	zone     *upcloud.Zone
+	plan     *upcloud.Plan
+	template *upcloud.Storage
+}
+
+func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:        "upcloud",
+		enableIPv6:  c.Bool("upcloud-public-ipv6-enable"),
+		storageSize: c.Int("upcloud-storage-size"),
+		config:      config,
+	}
+
+	if c.String("upcloud-token") == "" {
+		for _, flag := range []string{"upcloud-username", "upcloud-password"} {
+			if c.String(flag) == "" {
+				return nil, fmt.Errorf("%s: %w: upcloud-token or %s", p.name, ErrMissingSetting, flag)
+			}
+		}
+	}
+	p.client = upcloudapi.NewClient(c.String("upcloud-token"), c.String("upcloud-username"), c.String("upcloud-password"))
+
+	// first resolve and check config
+	if err := p.resolveZone(ctx, c.String("upcloud-zone")); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if err := p.resolvePlan(ctx, c.String("upcloud-plan")); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if err := p.resolveTemplate(ctx, c.String("upcloud-template")); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	if p.storageSize == 0 {
+		p.storageSize = max(p.plan.StorageSize, p.template.Size)
+	}
+	// log debug info
+	p.printResolvedConfig()
+
+	// if not done setup ssh key-pair
+	if err := p.setupKeyPair(c.StringSlice("upcloud-ssh-keys")); err != nil {
+		return nil, fmt.Errorf("%s: setupKeyPair: %w", p.name, err)
+	}
+
+	defaultLabels := make(map[string]string, 0)
+	defaultLabels[labelPool] = p.config.PoolID
+	defaultLabels[labelImage] = p.template.Title
+
+	userLabels := c.StringSlice("upcloud-labels")
+	if err := utils.CheckReservedTags(userLabels, labelPrefix, ErrIllegalLabelPrefix); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	labels, err := utils.SliceToMap(userLabels, "=")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	p.labels = utils.MergeMaps(defaultLabels, labels)
+
+	return p, nil
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: p.plan.Name,
+			Region:       p.zone.ID,
+			Workflows:    p.config.Workflows(p.plan.Name, p.plan.CoreNumber, int64(p.plan.MemoryAmount)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+	}
+
+	labels := upcloud.LabelSlice{}
+	for key, value := range p.labels {
+		labels = append(labels, upcloud.Label{Key: key, Value: value})
+	}
+
+	networking := &request.CreateServerNetworking{}
+	families := []string{upcloud.IPAddressFamilyIPv4}
+	if p.enableIPv6 {
+		families = append(families, upcloud.IPAddressFamilyIPv6)
+	}
+	for _, family := range families {
+		networking.Interfaces = append(networking.Interfaces, request.CreateServerInterface{
+			Type:        upcloud.IPAddressAccessPublic,
+			IPAddresses: request.CreateServerIPAddressSlice{{Family: family}},
+		})
+	}
+
+	server, err := p.client.CreateServer(ctx, &request.CreateServerRequest{
+		Zone:     p.zone.ID,
+		Title:    agent.Name,
+		Hostname: agent.Name,
+		Plan:     p.plan.Name,
+		Metadata: upcloud.True,
+		UserData: userData,
+		Labels:   &labels,
+		StorageDevices: request.CreateServerStorageDeviceSlice{{
+			Action:  request.CreateServerStorageDeviceActionClone,
+			Storage: p.template.UUID,
+			Title:   agent.Name,
+			Size:    p.storageSize,
+			Tier:    p.plan.StorageTier,
+		}},
+		Networking: networking,
+		LoginUser:  &request.LoginUser{CreatePassword: "no", SSHKeys: p.sshKeys},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: CreateServer: %w", p.name, err)
+	}
+	log.Debug().Msgf("created server %s", server.UUID)
+
+	return nil
+}
+
+// poolServers returns the servers of the pool.
+func (p *provider) poolServers(ctx context.Context) ([]upcloud.Server, error) {
+	servers, err := p.client.ListServers(ctx, upcloud.Label{Key: labelPool, Value: p.config.PoolID})
+	if err != nil {
+		return nil, fmt.Errorf("ListServers: %w", err)
+	}
+	return servers, nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	servers, err := p.poolServers(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	for _, server := range servers {
+		if server.Hostname != agent.Name {
+			continue
+		}
+		// servers are stopped before they can be deleted
+		if err := p.client.StopServer(ctx, server.UUID); err != nil && !upcloudapi.IsError(err, http.StatusNotFound) {
+			return fmt.Errorf("%s: StopServer: %w", p.name, err)
+		}
+		if err := p.client.DeleteServer(ctx, server.UUID); err != nil && !upcloudapi.IsError(err, http.StatusNotFound) {
+			return fmt.Errorf("%s: DeleteServer: %w", p.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	servers, err := p.poolServers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	names := make([]string, 0, len(servers))
+	for _, server := range servers {
+		names = append(names, server.Hostname)
+	}
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return types.BillingHourlyRoundUp
+}
@@ -0,0 +1,298 @@
+package upcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud/request"
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/providers/upcloud/upcloudapi"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+const testSSHKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGfFJ2xAKHXfS9Az5R2AmWSgsu5GTfIN7o6a26Ccu3BH ci@example.com"
+
+// stubServer is a server of the stub.
+type stubServer struct {
+	UUID     string `json:"uuid"`
+	Hostname string `json:"hostname"`
+	State    string `json:"state"`
+	Zone     string `json:"zone"`
+	// Labels are wrapped as the API does.
+	Labels struct {
+		Label []upcloud.Label `json:"label"`
+	} `json:"labels"`
+}
+
+// createServerRequest is the create server request as the API receives it.
+type createServerRequest struct {
+	Zone     string `json:"zone"`
+	Hostname string `json:"hostname"`
+	Plan     string `json:"plan"`
+	Metadata string `json:"metadata"`
+	UserData string `json:"user_data"`
+	Labels   struct {
+		Label []upcloud.Label `json:"label"`
+	} `json:"labels"`
+	StorageDevices struct {
+		StorageDevice []request.CreateServerStorageDevice `json:"storage_device"`
+	} `json:"storage_devices"`
+	Networking struct {
+		Interfaces struct {
+			Interface []struct {
+				Type        string `json:"type"`
+				IPAddresses struct {
+					IPAddress []request.CreateServerIPAddress `json:"ip_address"`
+				} `json:"ip_addresses"`
+			} `json:"interface"`
+		} `json:"interfaces"`
+	} `json:"networking"`
+	LoginUser struct {
+		CreatePassword string `json:"create_password"`
+		SSHKeys        struct {
+			SSHKey []string `json:"ssh_key"`
+		} `json:"ssh_keys"`
+	} `json:"login_user"`
+}
+
+// stubAPI is a minimal stateful stub of the UpCloud API.
+type stubAPI struct {
+	mu      sync.Mutex
+	servers map[string]*stubServer
+	created []*createServerRequest
+	nextID  int
+}
+
+func newStubAPI(t *testing.T) (*stubAPI, upcloudapi.Client) {
+	t.Helper()
+	s := &stubAPI{
+		servers: map[string]*stubServer{},
+		nextID:  1,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1.3/zone", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, map[string]any{"zones": map[string]any{"zone": []map[string]string{
+			{"id": "de-fra1", "description": "Frankfurt #1", "public": "yes"},
+			{"id": "fi-hel1", "description": "Helsinki #1", "public": "yes"},
+			{"id": "fi-hel2", "description": "Helsinki #2", "public": "yes"},
+		}}})
+	})
+	mux.HandleFunc("GET /1.3/plan", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, map[string]any{"plans": map[string]any{"plan": []upcloud.Plan{
+			{Name: "1xCPU-2GB", CoreNumber: 1, MemoryAmount: 2048, StorageSize: 50, StorageTier: "maxiops"},
+			{Name: "2xCPU-4GB", CoreNumber: 2, MemoryAmount: 4096, StorageSize: 80, StorageTier: "maxiops"},
+		}}})
+	})
+	mux.HandleFunc("GET /1.3/storage/template", func(w http.ResponseWriter, _ *http.Request) {
+		s.respond(w, map[string]any{"storages": map[string]any{"storage": []upcloud.Storage{
+			{UUID: "01000000-0000-4000-8000-000030220200", Title: "Ubuntu Server 22.04 LTS (Jammy Jellyfish)", Type: "template", Access: "public", Size: 4},
+			{UUID: "01000000-0000-4000-8000-000030240200", Title: "Ubuntu Server 24.04 LTS (Noble Numbat)", Type: "template", Access: "public", Size: 5},
+		}}})
+	})
+	mux.HandleFunc("GET /1.3/server/{$}", func(w http.ResponseWriter, r *http.Request) {
+		key, value, _ := strings.Cut(r.URL.Query().Get("label"), "=")
+		servers := []*stubServer{}
+		for _, server := range s.servers {
+			if slices.Contains(server.Labels.Label, upcloud.Label{Key: key, Value: value}) {
+				servers = append(servers, server)
+			}
+		}
+		s.respond(w, map[string]any{"servers": map[string]any{"server": servers}})
+	})
+	mux.HandleFunc("POST /1.3/server", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Server *createServerRequest `json:"server"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		s.created = append(s.created, req.Server)
+		server := &stubServer{
+			UUID:     fmt.Sprintf("00%d", s.nextID),
+			Hostname: req.Server.Hostname,
+			State:    upcloud.ServerStateStarted,
+			Zone:     req.Server.Zone,
+			Labels:   req.Server.Labels,
+		}
+		s.nextID++
+		s.servers[server.UUID] = server
+		w.WriteHeader(http.StatusAccepted)
+		s.respond(w, map[string]any{"server": server})
+	})
+	mux.HandleFunc("GET /1.3/server/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		if server, ok := s.server(w, r); ok {
+			s.respond(w, map[string]any{"server": server})
+		}
+	})
+	mux.HandleFunc("POST /1.3/server/{uuid}/stop", func(w http.ResponseWriter, r *http.Request) {
+		if server, ok := s.server(w, r); ok {
+			server.State = upcloud.ServerStateStopped
+			s.respond(w, map[string]any{"server": server})
+		}
+	})
+	mux.HandleFunc("DELETE /1.3/server/{uuid}/{$}", func(w http.ResponseWriter, r *http.Request) {
+		server, ok := s.server(w, r)
+		if !ok {
+			return
+		}
+		assert.Equal(t, "1", r.URL.Query().Get("storages"))
+		if server.State != upcloud.ServerStateStopped {
+			w.WriteHeader(http.StatusBadRequest)
+			s.respond(w, map[string]any{"error": map[string]string{"error_code": "SERVER_STATE_ILLEGAL", "error_message": "The server is not stopped."}})
+			return
+		}
+		delete(s.servers, server.UUID)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ucat_test", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return s, upcloudapi.NewClientWithURL(server.URL, "ucat_test", "", "")
+}
+
+func (s *stubAPI) respond(w http.ResponseWriter, data any) {
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// server looks up the server of the request.
+func (s *stubAPI) server(w http.ResponseWriter, r *http.Request) (*stubServer, bool) {
+	server, ok := s.servers[r.PathValue("uuid")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		s.respond(w, map[string]any{"error": map[string]string{"error_code": "SERVER_NOT_FOUND", "error_message": "The server does not exist."}})
+		return nil, false
+	}
+	return server, true
+}
+
+func newTestProvider(t *testing.T, client upcloudapi.Client) *provider {
+	t.Helper()
+	p := &provider{
+		name:       "upcloud",
+		enableIPv6: true,
+		labels:     map[string]string{labelPool: "1", "team": "ci"},
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: client,
+	}
+	assert.NoError(t, p.resolveZone(t.Context(), "fi-hel1"))
+	assert.NoError(t, p.resolvePlan(t.Context(), "1xCPU-2GB"))
+	assert.NoError(t, p.resolveTemplate(t.Context(), "Ubuntu Server 24.04 LTS (Noble Numbat)"))
+	p.storageSize = p.plan.StorageSize
+	return p
+}
+
+// newStubServer returns a started server of the pool.
+func newStubServer(uuid, hostname, poolID string) *stubServer {
+	server := &stubServer{UUID: uuid, Hostname: hostname, State: upcloud.ServerStateStarted}
+	if poolID != "" {
+		server.Labels.Label = []upcloud.Label{{Key: labelPool, Value: poolID}}
+	}
+	return server
+}
+
+func TestResolve(t *testing.T) {
+	_, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	assert.Equal(t, "fi-hel1", p.zone.ID)
+	assert.Equal(t, 1, p.plan.CoreNumber)
+	assert.Equal(t, "01000000-0000-4000-8000-000030240200", p.template.UUID)
+
+	assert.NoError(t, p.resolveZone(t.Context(), "frankfurt"))
+	assert.Equal(t, "de-fra1", p.zone.ID)
+	assert.ErrorIs(t, p.resolveZone(t.Context(), "us-nyc1"), ErrInvalidZone)
+
+	assert.ErrorIs(t, p.resolvePlan(t.Context(), "64xCPU-512GB"), ErrInvalidPlan)
+
+	assert.NoError(t, p.resolveTemplate(t.Context(), "ubuntu server"))
+	assert.Equal(t, "Ubuntu Server 22.04 LTS (Jammy Jellyfish)", p.template.Title)
+	assert.NoError(t, p.resolveTemplate(t.Context(), "01000000-0000-4000-8000-000030240200"))
+	assert.Equal(t, "Ubuntu Server 24.04 LTS (Noble Numbat)", p.template.Title)
+	assert.ErrorIs(t, p.resolveTemplate(t.Context(), "Windows"), ErrInvalidTemplate)
+}
+
+func TestSetupKeyPair(t *testing.T) {
+	p := &provider{}
+	assert.NoError(t, p.setupKeyPair([]string{testSSHKey + "\n"}))
+	assert.Equal(t, []string{testSSHKey}, p.sshKeys)
+
+	assert.ErrorIs(t, p.setupKeyPair([]string{"ssh-ed25519 invalid"}), ErrInvalidSSHKey)
+}
+
+func TestDeployAgent(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	assert.NoError(t, p.setupKeyPair([]string{testSSHKey}))
+
+	assert.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+
+	if assert.Len(t, stub.created, 1) {
+		req := stub.created[0]
+		assert.Equal(t, "fi-hel1", req.Zone)
+		assert.Equal(t, "pool-1-agent-abcd", req.Hostname)
+		assert.Equal(t, "1xCPU-2GB", req.Plan)
+		assert.Equal(t, "yes", req.Metadata)
+		assert.ElementsMatch(t, []upcloud.Label{{Key: labelPool, Value: "1"}, {Key: "team", Value: "ci"}}, req.Labels.Label)
+		assert.Equal(t, []request.CreateServerStorageDevice{{
+			Action:  "clone",
+			Storage: "01000000-0000-4000-8000-000030240200",
+			Title:   "pool-1-agent-abcd",
+			Size:    50,
+			Tier:    "maxiops",
+		}}, req.StorageDevices.StorageDevice)
+		if assert.Len(t, req.Networking.Interfaces.Interface, 2) {
+			assert.Equal(t, "public", req.Networking.Interfaces.Interface[1].Type)
+			assert.Equal(t, "IPv6", req.Networking.Interfaces.Interface[1].IPAddresses.IPAddress[0].Family)
+		}
+		assert.Equal(t, "no", req.LoginUser.CreatePassword)
+		assert.Equal(t, []string{testSSHKey}, req.LoginUser.SSHKeys.SSHKey)
+		assert.Contains(t, req.UserData, "#cloud-config")
+		assert.Contains(t, req.UserData, "ip -4 route add blackhole 169.254.169.254/32")
+		assert.Contains(t, req.UserData, "autoscaler.region=fi-hel1")
+	}
+}
+
+func TestRemoveAgent(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	stub.servers["001"] = newStubServer("001", "pool-1-agent-abcd", "1")
+	// same name in another pool
+	stub.servers["002"] = newStubServer("002", "pool-1-agent-abcd", "2")
+
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	assert.NotContains(t, stub.servers, "001")
+	assert.Contains(t, stub.servers, "002")
+
+	// already removed
+	assert.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+}
+
+func TestListDeployedAgentNames(t *testing.T) {
+	stub, client := newStubAPI(t)
+	p := newTestProvider(t, client)
+	stub.servers["001"] = newStubServer("001", "pool-1-agent-a", "1")
+	stub.servers["002"] = newStubServer("002", "pool-2-agent-b", "2")
+	stub.servers["003"] = newStubServer("003", "web", "")
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-a"}, names)
+}
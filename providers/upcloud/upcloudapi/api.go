@@ -0,0 +1,33 @@
+package upcloudapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud"
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud/request"
+)
+
+// Client is the subset of the UpCloud API the upcloud provider uses.
+type Client interface {
+	ListZones(ctx context.Context) ([]upcloud.Zone, error)
+	ListPlans(ctx context.Context) ([]upcloud.Plan, error)
+	// ListTemplates lists the public and private storage templates.
+	ListTemplates(ctx context.Context) ([]upcloud.Storage, error)
+	CreateServer(ctx context.Context, req *request.CreateServerRequest) (*upcloud.ServerDetails, error)
+	// ListServers lists the servers with the label.
+	ListServers(ctx context.Context, label upcloud.Label) ([]upcloud.Server, error)
+	// StopServer stops the server and waits until it has stopped, a server
+	// in maintenance is waited for before.
+	StopServer(ctx context.Context, uuid string) error
+	// DeleteServer deletes the stopped server with its storages.
+	DeleteServer(ctx context.Context, uuid string) error
+}
+
+// IsError reports whether err is a problem reported by the API with one of
+// the status codes.
+func IsError(err error, statusCodes ...int) bool {
+	var problem *upcloud.Problem
+	return errors.As(err, &problem) && slices.Contains(statusCodes, problem.Status)
+}
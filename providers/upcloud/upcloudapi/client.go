@@ -0,0 +1,124 @@
+package upcloudapi
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud"
+	upcloudclient "github.com/UpCloudLtd/upcloud-go-api/v8/upcloud/client"
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud/request"
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud/service"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+// requestTimeout is the timeout of a single API request.
+const requestTimeout = time.Minute
+
+// statePollInterval is how often the state of a server is checked while
+// waiting for it.
+const statePollInterval = 2 * time.Second
+
+type client struct {
+	api *service.Service
+}
+
+// NewClient creates a client for the API, authenticating with the API token
+// if set and with the username and password otherwise.
+func NewClient(token, username, password string) Client {
+	return NewClientWithURL(upcloudclient.APIBaseURL, token, username, password)
+}
+
+// NewClientWithURL creates a client for the API at baseURL, without the API
+// version.
+func NewClientWithURL(baseURL, token, username, password string) Client {
+	auth := upcloudclient.WithBasicAuth(username, password)
+	if token != "" {
+		auth = upcloudclient.WithBearerAuth(token)
+	}
+	c := upcloudclient.New("", "", auth, upcloudclient.WithBaseURL(baseURL), upcloudclient.WithTimeout(requestTimeout))
+	c.UserAgent = "woodpecker-autoscaler/" + version.String() + " " + c.UserAgent
+	return &client{api: service.New(c)}
+}
+
+func (c *client) ListZones(ctx context.Context) ([]upcloud.Zone, error) {
+	zones, err := c.api.GetZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return zones.Zones, nil
+}
+
+func (c *client) ListPlans(ctx context.Context) ([]upcloud.Plan, error) {
+	plans, err := c.api.GetPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return plans.Plans, nil
+}
+
+func (c *client) ListTemplates(ctx context.Context) ([]upcloud.Storage, error) {
+	storages, err := c.api.GetStorages(ctx, &request.GetStoragesRequest{Type: upcloud.StorageTypeTemplate})
+	if err != nil {
+		return nil, err
+	}
+	return storages.Storages, nil
+}
+
+func (c *client) CreateServer(ctx context.Context, req *request.CreateServerRequest) (*upcloud.ServerDetails, error) {
+	return c.api.CreateServer(ctx, req)
+}
+
+func (c *client) ListServers(ctx context.Context, label upcloud.Label) ([]upcloud.Server, error) {
+	servers, err := c.api.GetServersWithFilters(ctx, &request.GetServersWithFiltersRequest{
+		Filters: []request.QueryFilter{request.FilterLabel{Label: label}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return servers.Servers, nil
+}
+
+// waitState waits while the state of the server is one of the states and
+// returns the state it has then.
+func (c *client) waitState(ctx context.Context, uuid string, states ...string) (string, error) {
+	for {
+		server, err := c.api.GetServerDetails(ctx, &request.GetServerDetailsRequest{UUID: uuid})
+		if err != nil {
+			return "", err
+		}
+		if !slices.Contains(states, server.State) {
+			return server.State, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(statePollInterval):
+		}
+	}
+}
+
+func (c *client) StopServer(ctx context.Context, uuid string) error {
+	state, err := c.waitState(ctx, uuid, upcloud.ServerStateMaintenance)
+	if err != nil {
+		return err
+	}
+	if state == upcloud.ServerStateStopped {
+		return nil
+	}
+
+	if _, err := c.api.StopServer(ctx, &request.StopServerRequest{UUID: uuid, StopType: request.ServerStopTypeHard}); err != nil {
+		return err
+	}
+	_, err = c.waitState(ctx, uuid, upcloud.ServerStateStarted, upcloud.ServerStateMaintenance)
+	return err
+}
+
+func (c *client) DeleteServer(ctx context.Context, uuid string) error {
+	return c.api.DeleteServerAndStorages(ctx, &request.DeleteServerAndStoragesRequest{
+		UUID:    uuid,
+		Backups: request.DeleteStorageBackupsModeDelete,
+	})
+}
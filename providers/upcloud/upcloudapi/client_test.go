@@ -0,0 +1,97 @@
+package upcloudapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UpCloudLtd/upcloud-go-api/v8/upcloud"
+	"github.com/stretchr/testify/assert"
+
+	"go.woodpecker-ci.org/autoscaler/providers/upcloud/upcloudapi"
+)
+
+func newTestClient(t *testing.T, token string, handler http.HandlerFunc) upcloudapi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("User-Agent"), "woodpecker-autoscaler/"))
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return upcloudapi.NewClientWithURL(server.URL, token, "user", "secret")
+}
+
+func TestAuthorization(t *testing.T) {
+	for token, authorization := range map[string]string{
+		"":             "Basic dXNlcjpzZWNyZXQ=",
+		"ucat_example": "Bearer ucat_example",
+	} {
+		client := newTestClient(t, token, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, authorization, r.Header.Get("Authorization"))
+			assert.Equal(t, "/1.3/zone", r.URL.Path)
+			_, _ = w.Write([]byte(`{"zones": {"zone": [{"id": "fi-hel1", "description": "Helsinki #1", "public": "yes"}]}}`))
+		})
+
+		zones, err := client.ListZones(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, []upcloud.Zone{{ID: "fi-hel1", Description: "Helsinki #1", Public: upcloud.True}}, zones)
+	}
+}
+
+func TestListServers(t *testing.T) {
+	client := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1.3/server/", r.URL.Path)
+		assert.Equal(t, "wp-autoscaler-pool=1", r.URL.Query().Get("label"))
+		_, _ = w.Write([]byte(`{"servers": {"server": [{"uuid": "s1", "hostname": "pool-1-agent-a", "state": "started"}]}}`))
+	})
+
+	servers, err := client.ListServers(t.Context(), upcloud.Label{Key: "wp-autoscaler-pool", Value: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []upcloud.Server{{UUID: "s1", Hostname: "pool-1-agent-a", State: upcloud.ServerStateStarted}}, servers)
+}
+
+func TestStopServer(t *testing.T) {
+	states := []string{upcloud.ServerStateMaintenance, upcloud.ServerStateStarted}
+	var stopped bool
+	client := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /1.3/server/s1":
+			state := upcloud.ServerStateStopped
+			if !stopped {
+				state, states = states[0], states[1:]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"server": map[string]string{"uuid": "s1", "state": state}})
+		case "POST /1.3/server/s1/stop":
+			var req map[string]map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "hard", req["stop_server"]["stop_type"])
+			stopped = true
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(map[string]any{"server": map[string]string{"uuid": "s1", "state": upcloud.ServerStateStarted}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	assert.NoError(t, client.StopServer(t.Context(), "s1"))
+	assert.True(t, stopped)
+}
+
+func TestError(t *testing.T) {
+	client := newTestClient(t, "", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1.3/server/s1/", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("storages"))
+		assert.Equal(t, "delete", r.URL.Query().Get("backups"))
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": {"error_code": "SERVER_NOT_FOUND", "error_message": "The server s1 does not exist."}}`))
+	})
+
+	err := client.DeleteServer(t.Context(), "s1")
+	assert.True(t, upcloudapi.IsError(err, http.StatusNotFound))
+	assert.False(t, upcloudapi.IsError(err, http.StatusBadRequest))
+	assert.ErrorContains(t, err, "The server s1 does not exist.")
+}
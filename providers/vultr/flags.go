@@ -1,62 +1,61 @@
 package vultr
 
 import (
-	"os"
-
 	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
 )
 
 const category = "Vultr"
 
-var ProviderFlags = []cli.Flag{
-	// vultr
-	&cli.StringFlag{
-		Name:  "vultr-api-token",
-		Usage: "vultr api token",
-		Sources: cli.NewValueSourceChain(
-			cli.EnvVar("WOODPECKER_VULTR_API_TOKEN"),
-			cli.File(os.Getenv("WOODPECKER_VULTR_API_TOKEN_FILE")),
-		),
-		Category: category,
-	},
-	&cli.StringFlag{
-		Name:     "vultr-region",
-		Usage:    "vultr region",
-		Value:    "ams",
-		Sources:  cli.EnvVars("WOODPECKER_VULTR_REGION"),
-		Category: category,
-	},
-	&cli.StringFlag{
-		Name:     "vultr-plan",
-		Usage:    "vultr plan",
-		Value:    "vhp-1c-2gb",
-		Sources:  cli.EnvVars("WOODPECKER_VULTR_PLAN"),
-		Category: category,
-	},
-	&cli.StringSliceFlag{
-		Name:     "vultr-ssh-keys",
-		Usage:    "names of vultr ssh keys",
-		Sources:  cli.EnvVars("WOODPECKER_VULTR_SSH_KEYS"),
-		Category: category,
-	},
-	&cli.StringFlag{
-		Name:     "vultr-image",
-		Usage:    "vultr image",
-		Value:    "Ubuntu 24.04 LTS x64",
-		Sources:  cli.EnvVars("WOODPECKER_VULTR_IMAGE"),
-		Category: category,
-	},
-	&cli.StringSliceFlag{
-		Name:     "vultr-labels",
-		Usage:    "vultr server labels",
-		Sources:  cli.EnvVars("WOODPECKER_VULTR_LABELS"),
-		Category: category,
-	},
-	&cli.BoolFlag{
-		Name:     "vultr-public-ipv6-enable",
-		Value:    true,
-		Usage:    "enables public ipv6 network for agents",
-		Sources:  cli.EnvVars("WOODPECKER_VULTR_PUBLIC_IPV6_ENABLE"),
-		Category: category,
-	},
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		// vultr
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "vultr-api-token",
+			Usage:    "vultr api token",
+			Sources:  config.SecretSources("WOODPECKER_VULTR_API_TOKEN"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "vultr-region",
+			Usage:    "vultr region",
+			Value:    "ams",
+			Sources:  cli.EnvVars("WOODPECKER_VULTR_REGION"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "vultr-plan",
+			Usage:    "vultr plan",
+			Value:    "vhp-1c-2gb",
+			Sources:  cli.EnvVars("WOODPECKER_VULTR_PLAN"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "vultr-ssh-keys",
+			Usage:    "names of vultr ssh keys",
+			Sources:  cli.EnvVars("WOODPECKER_VULTR_SSH_KEYS"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "vultr-image",
+			Usage:    "vultr image",
+			Value:    "Ubuntu 24.04 LTS x64",
+			Sources:  cli.EnvVars("WOODPECKER_VULTR_IMAGE"),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:     "vultr-labels",
+			Usage:    "vultr server labels",
+			Sources:  cli.EnvVars("WOODPECKER_VULTR_LABELS"),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:     "vultr-public-ipv6-enable",
+			Value:    true,
+			Usage:    "enables public ipv6 network for agents",
+			Sources:  cli.EnvVars("WOODPECKER_VULTR_PUBLIC_IPV6_ENABLE"),
+			Category: category,
+		},
+	}
 }
@@ -14,6 +14,7 @@ import (
 
 	"go.woodpecker-ci.org/autoscaler/config"
 	"go.woodpecker-ci.org/autoscaler/engine"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
 	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
 	"go.woodpecker-ci.org/autoscaler/engine/types"
 	"go.woodpecker-ci.org/autoscaler/utils"
@@ -29,6 +30,9 @@ var (
 	ErrInvalidImage       = errors.New("no valid image set")
 )
 
+// UserDataLimit is the maximum size of instance user data in bytes.
+const UserDataLimit = 64 << 10
+
 // blackhole metadata services so running steps can not extract agent token from user-data
 // https://www.vultr.com/metadata/ (data is served over IPv4 169.254.169.254 only)
 var blackholeMetadataAPI = []string{
@@ -95,11 +99,17 @@ func New(ctx context.Context, c *cli.Command, config *config.Config) (types.Prov
 }
 
 func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
-	userData, err := cloudinit.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
-		PreExec: blackholeMetadataAPI,
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		PreExec:  blackholeMetadataAPI,
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			InstanceType: p.plan.ID,
+			Region:       p.region.ID,
+			Workflows:    p.config.Workflows(p.plan.ID, p.plan.VCPUCount, int64(p.plan.RAM)),
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("%s: cloudinit.RenderUserDataTemplate: %w", p.name, err)
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
 	}
 
 	tags := make([]string, 0)
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.woodpecker-ci.org/autoscaler/version"
+)
+
+// maxErrorBody limits how much of the body of a failed request is read.
+const maxErrorBody = 4 << 10
+
+// Error is the error of a request answered with a status code >= 400.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("webhook: error %d: %s", e.StatusCode, e.Message)
+}
+
+type client struct {
+	http   *http.Client
+	secret []byte
+}
+
+// sign returns the value of the SignatureHeader of the body.
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends the signed request to the endpoint, decodes the response into
+// out, if not nil, and returns its status code.
+func (c *client) post(ctx context.Context, endpoint string, in, out any) (int, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "woodpecker-autoscaler/"+version.String())
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, sign(c.secret, body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		var errResp ErrorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+			apiErr.Message = errResp.Error
+		} else if message := strings.TrimSpace(string(data)); message != "" {
+			apiErr.Message = message
+		}
+		return resp.StatusCode, apiErr
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
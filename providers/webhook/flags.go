@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+)
+
+const category = "Webhook"
+
+func ProviderFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "webhook-deploy-url",
+			Usage:    "endpoint agents are deployed with",
+			Sources:  cli.EnvVars("WOODPECKER_WEBHOOK_DEPLOY_URL"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "webhook-remove-url",
+			Usage:    "endpoint agents are removed with",
+			Sources:  cli.EnvVars("WOODPECKER_WEBHOOK_REMOVE_URL"),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:     "webhook-list-url",
+			Usage:    "endpoint the agents of the pool are listed with",
+			Sources:  cli.EnvVars("WOODPECKER_WEBHOOK_LIST_URL"),
+			Category: category,
+		},
+		&config.SecretFlag{StringFlag: cli.StringFlag{
+			Name:     "webhook-secret",
+			Usage:    "secret the requests are signed with",
+			Sources:  config.SecretSources("WOODPECKER_WEBHOOK_SECRET"),
+			Category: category,
+		}},
+		&cli.StringFlag{
+			Name:     "webhook-billing-model",
+			Value:    "per-second",
+			Usage:    "how the agents are billed, per-second or hourly-round-up",
+			Sources:  cli.EnvVars("WOODPECKER_WEBHOOK_BILLING_MODEL"),
+			Category: category,
+		},
+		&cli.DurationFlag{
+			Name:     "webhook-timeout",
+			Value:    time.Minute,
+			Usage:    "timeout of each request",
+			Sources:  cli.EnvVars("WOODPECKER_WEBHOOK_TIMEOUT"),
+			Category: category,
+		},
+		&cli.DurationFlag{
+			Name:     "webhook-provision-timeout",
+			Value:    10 * time.Minute, //nolint:mnd
+			Usage:    "how long an agent accepted for asynchronous provisioning is considered deployed before it shows up in the listing",
+			Sources:  cli.EnvVars("WOODPECKER_WEBHOOK_PROVISION_TIMEOUT"),
+			Category: category,
+		},
+	}
+}
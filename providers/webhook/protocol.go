@@ -0,0 +1,47 @@
+package webhook
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, keyed with
+// the webhook secret, as sha256=<hex>.
+const SignatureHeader = "X-Woodpecker-Signature-256"
+
+type Agent struct {
+	Name string `json:"name"`
+}
+
+// DeployRequest is sent to the deploy endpoint. It answers 200 or 201 if the
+// agent was created, or 202 if it is created asynchronously and shows up in
+// later listings.
+type DeployRequest struct {
+	PoolID string `json:"poolID"`
+	Agent  Agent  `json:"agent"`
+	// UserData is the rendered cloud-init user data of the agent.
+	UserData string `json:"userData"`
+	// Timestamp is the unix time the request was sent at, so receivers can
+	// reject replayed requests.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// RemoveRequest is sent to the remove endpoint. It answers 2xx, or 404 if the
+// agent does not exist.
+type RemoveRequest struct {
+	PoolID    string `json:"poolID"`
+	Agent     Agent  `json:"agent"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// ListRequest is sent to the list endpoint, which answers with a
+// ListResponse.
+type ListRequest struct {
+	PoolID    string `json:"poolID"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type ListResponse struct {
+	// Agents are the agents of the pool, including those being provisioned.
+	Agents []Agent `json:"agents"`
+}
+
+// ErrorResponse is the optional body of a failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/inits"
+	"go.woodpecker-ci.org/autoscaler/engine/inits/cloudinit"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+type provider struct {
+	name             string
+	deployURL        string
+	removeURL        string
+	listURL          string
+	billingModel     types.BillingModel
+	provisionTimeout time.Duration
+	config           *config.Config
+	client           *client
+
+	// pending are the agents accepted for asynchronous provisioning which
+	// did not show up in the listing yet, with the time they were accepted.
+	// They are listed as deployed so the autoscaler does not remove them
+	// from the server meanwhile.
+	pendingMu sync.Mutex
+	pending   map[string]time.Time
+}
+
+func New(_ context.Context, c *cli.Command, config *config.Config) (types.Provider, error) {
+	p := &provider{
+		name:             "webhook",
+		deployURL:        c.String("webhook-deploy-url"),
+		removeURL:        c.String("webhook-remove-url"),
+		listURL:          c.String("webhook-list-url"),
+		provisionTimeout: c.Duration("webhook-provision-timeout"),
+		config:           config,
+		pending:          map[string]time.Time{},
+	}
+
+	for _, setting := range []struct{ name, value string }{
+		{"webhook-deploy-url", p.deployURL},
+		{"webhook-remove-url", p.removeURL},
+		{"webhook-list-url", p.listURL},
+		{"webhook-secret", c.String("webhook-secret")},
+	} {
+		if setting.value == "" {
+			return nil, fmt.Errorf("%s: %w: %s", p.name, ErrMissingSetting, setting.name)
+		}
+	}
+
+	billingModel, err := parseBillingModel(c.String("webhook-billing-model"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+	p.billingModel = billingModel
+
+	p.client = &client{
+		http:   &http.Client{Timeout: c.Duration("webhook-timeout")},
+		secret: []byte(c.String("webhook-secret")),
+	}
+
+	return p, nil
+}
+
+func parseBillingModel(s string) (types.BillingModel, error) {
+	for _, billingModel := range []types.BillingModel{types.BillingPerSecond, types.BillingHourlyRoundUp} {
+		if billingModel.String() == s {
+			return billingModel, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrBillingModel, s)
+}
+
+func (p *provider) DeployAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	userData, err := inits.RenderUserDataTemplate(p.config, agent, cloudinit.RenderOption{
+		Provider: p.name,
+		Candidate: cloudinit.Candidate{
+			Workflows: p.config.Workflows("", 0, 0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s: inits.RenderUserDataTemplate: %w", p.name, err)
+	}
+
+	status, err := p.client.post(ctx, p.deployURL, &DeployRequest{
+		PoolID:    p.config.PoolID,
+		Agent:     Agent{Name: agent.Name},
+		UserData:  userData,
+		Timestamp: time.Now().Unix(),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: deploy: %w", p.name, err)
+	}
+
+	if status == http.StatusAccepted {
+		log.Debug().Str("agent", agent.Name).Msg("agent accepted for asynchronous provisioning")
+		p.pendingMu.Lock()
+		p.pending[agent.Name] = time.Now()
+		p.pendingMu.Unlock()
+	}
+
+	return nil
+}
+
+func (p *provider) RemoveAgent(ctx context.Context, agent *woodpecker.Agent) error {
+	status, err := p.client.post(ctx, p.removeURL, &RemoveRequest{
+		PoolID:    p.config.PoolID,
+		Agent:     Agent{Name: agent.Name},
+		Timestamp: time.Now().Unix(),
+	}, nil)
+	if err != nil && status != http.StatusNotFound {
+		return fmt.Errorf("%s: remove: %w", p.name, err)
+	}
+
+	p.pendingMu.Lock()
+	delete(p.pending, agent.Name)
+	p.pendingMu.Unlock()
+
+	return nil
+}
+
+func (p *provider) ListDeployedAgentNames(ctx context.Context) ([]string, error) {
+	var list ListResponse
+	if _, err := p.client.post(ctx, p.listURL, &ListRequest{
+		PoolID:    p.config.PoolID,
+		Timestamp: time.Now().Unix(),
+	}, &list); err != nil {
+		return nil, fmt.Errorf("%s: list: %w", p.name, err)
+	}
+
+	names := make([]string, 0, len(list.Agents))
+	listed := make(map[string]bool, len(list.Agents))
+	for _, agent := range list.Agents {
+		names = append(names, agent.Name)
+		listed[agent.Name] = true
+	}
+
+	p.pendingMu.Lock()
+	defer p.pendingMu.Unlock()
+	for name, accepted := range p.pending {
+		switch {
+		case listed[name]:
+			delete(p.pending, name)
+		case time.Since(accepted) > p.provisionTimeout:
+			log.Warn().Str("agent", name).Msgf("agent did not show up in the listing within %s", p.provisionTimeout)
+			delete(p.pending, name)
+		default:
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+func (p *provider) BillingModel() types.BillingModel {
+	return p.billingModel
+}
@@ -0,0 +1,207 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.woodpecker-ci.org/autoscaler/config"
+	"go.woodpecker-ci.org/autoscaler/engine/types"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+const testSecret = "secret"
+
+// platform is a stub of a platform API serving the webhook endpoints. Agents
+// named async-* are accepted and only listed once provisioned is called.
+type platform struct {
+	mu     sync.Mutex
+	agents []string
+	queued []string
+}
+
+func (s *platform) provisioned() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents = append(s.agents, s.queued...)
+	s.queued = nil
+}
+
+func (s *platform) handler(t *testing.T) http.Handler {
+	// verified reads the body if it is signed with the secret
+	verified := func(w http.ResponseWriter, r *http.Request, v any) bool {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if r.Header.Get(SignatureHeader) != sign([]byte(testSecret), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(&ErrorResponse{Error: "invalid signature"})
+			return false
+		}
+		require.NoError(t, json.Unmarshal(body, v))
+		return true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /deploy", func(w http.ResponseWriter, r *http.Request) {
+		var req DeployRequest
+		if !verified(w, r, &req) {
+			return
+		}
+		assert.Equal(t, "1", req.PoolID)
+		assert.Contains(t, req.UserData, "WOODPECKER_AGENT_SECRET=token")
+		assert.NotZero(t, req.Timestamp)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if strings.HasPrefix(req.Agent.Name, "async-") {
+			s.queued = append(s.queued, req.Agent.Name)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		s.agents = append(s.agents, req.Agent.Name)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("POST /remove", func(w http.ResponseWriter, r *http.Request) {
+		var req RemoveRequest
+		if !verified(w, r, &req) {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		i := slices.Index(s.agents, req.Agent.Name)
+		if i < 0 {
+			http.Error(w, "agent not found", http.StatusNotFound)
+			return
+		}
+		s.agents = slices.Delete(s.agents, i, i+1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /list", func(w http.ResponseWriter, r *http.Request) {
+		var req ListRequest
+		if !verified(w, r, &req) {
+			return
+		}
+		assert.Equal(t, "1", req.PoolID)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		resp := ListResponse{Agents: []Agent{}}
+		for _, name := range s.agents {
+			resp.Agents = append(resp.Agents, Agent{Name: name})
+		}
+		_ = json.NewEncoder(w).Encode(&resp)
+	})
+	return mux
+}
+
+func newTestProvider(t *testing.T, secret string) (*provider, *platform) {
+	s := &platform{}
+	server := httptest.NewServer(s.handler(t))
+	t.Cleanup(server.Close)
+
+	return &provider{
+		name:             "webhook",
+		deployURL:        server.URL + "/deploy",
+		removeURL:        server.URL + "/remove",
+		listURL:          server.URL + "/list",
+		provisionTimeout: 10 * time.Minute,
+		config: &config.Config{
+			PoolID:            "1",
+			Image:             "woodpeckerci/woodpecker-agent:next",
+			WorkflowsPerAgent: 2,
+		},
+		client: &client{
+			http:   server.Client(),
+			secret: []byte(secret),
+		},
+		pending: map[string]time.Time{},
+	}, s
+}
+
+func TestAgents(t *testing.T) {
+	p, _ := newTestProvider(t, testSecret)
+
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"}))
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pool-1-agent-abcd"}, names)
+
+	require.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+	// already removed
+	require.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd"}))
+
+	names, err = p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestAsyncProvisioning(t *testing.T) {
+	p, s := newTestProvider(t, testSecret)
+
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "async-abcd", Token: "token"}))
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "async-efgh", Token: "token"}))
+	// never shows up
+	p.pending["async-efgh"] = time.Now().Add(-time.Hour)
+
+	// listed while provisioning, until the provision timeout
+	names, err := p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"async-abcd"}, names)
+	assert.NotContains(t, p.pending, "async-efgh")
+
+	s.provisioned()
+
+	names, err = p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"async-abcd", "async-efgh"}, names)
+	assert.Empty(t, p.pending)
+}
+
+func TestRemovePending(t *testing.T) {
+	p, _ := newTestProvider(t, testSecret)
+
+	require.NoError(t, p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "async-abcd", Token: "token"}))
+	require.NoError(t, p.RemoveAgent(t.Context(), &woodpecker.Agent{Name: "async-abcd"}))
+
+	names, err := p.ListDeployedAgentNames(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestInvalidSignature(t *testing.T) {
+	p, _ := newTestProvider(t, "wrong")
+
+	err := p.DeployAgent(t.Context(), &woodpecker.Agent{Name: "pool-1-agent-abcd", Token: "token"})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "invalid signature", apiErr.Message)
+
+	_, err = p.ListDeployedAgentNames(t.Context())
+	assert.ErrorAs(t, err, &apiErr)
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"poolID":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=2c161d8c1049aa119f0bbc56c9463872d9823ef5fe638ada6ddd046416ba273c", sign([]byte(testSecret), []byte(`{"poolID":"1"}`)))
+}
+
+func TestParseBillingModel(t *testing.T) {
+	billingModel, err := parseBillingModel("hourly-round-up")
+	assert.NoError(t, err)
+	assert.Equal(t, types.BillingHourlyRoundUp, billingModel)
+
+	_, err = parseBillingModel("monthly")
+	assert.ErrorIs(t, err, ErrBillingModel)
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import (
+	"errors"
+)
+
+var (
+	ErrMissingSetting = errors.New("missing setting")
+	ErrBillingModel   = errors.New("unknown billing model")
+)